@@ -0,0 +1,259 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ParseValue returns the value of the parameter as its type: a string, an int64 or a bool.
+func (p Parameter) ParseValue(value string) (interface{}, error) {
+	switch p.Type {
+	case ParameterTypeInteger:
+		return strconv.ParseInt(value, 10, 64)
+	case ParameterTypeBoolean:
+		return strconv.ParseBool(value)
+	default:
+		return value, nil
+	}
+}
+
+// ParameterValues returns the typed values of the parameters, from the arguments or the defaults.
+// Arguments of parameters that are not declared, and missing arguments of parameters without a default, are rejected.
+// The errors are reported on the declarations under parametersPath and on the arguments under argumentsPath.
+func ParameterValues(parameters []Parameter, arguments map[string]string, parametersPath *field.Path, argumentsPath *field.Path) (map[string]interface{}, field.ErrorList) {
+	allErrs := field.ErrorList{}
+	values := map[string]interface{}{}
+	declared := map[string]struct{}{}
+
+	for i, parameter := range parameters {
+		declared[parameter.Name] = struct{}{}
+
+		var defaultValue interface{}
+		if parameter.Default != nil {
+			typed, err := parameter.ParseValue(*parameter.Default)
+			if err != nil {
+				allErrs = append(allErrs, field.Invalid(parametersPath.Index(i).Child("default"), *parameter.Default, fmt.Sprintf("must be a %s: %s", strings.ToLower(string(parameter.Type)), err)))
+			}
+			defaultValue = typed
+		}
+
+		value, ok := arguments[parameter.Name]
+		if !ok {
+			if parameter.Default == nil {
+				allErrs = append(allErrs, field.Required(argumentsPath.Key(parameter.Name), fmt.Sprintf("parameter %s has no default", parameter.Name)))
+			} else if defaultValue != nil {
+				values[parameter.Name] = defaultValue
+			}
+			continue
+		}
+
+		typed, err := parameter.ParseValue(value)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(argumentsPath.Key(parameter.Name), value, fmt.Sprintf("must be a %s: %s", strings.ToLower(string(parameter.Type)), err)))
+			continue
+		}
+		values[parameter.Name] = typed
+	}
+
+	names := make([]string, 0, len(arguments))
+	for name := range arguments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, ok := declared[name]; !ok {
+			allErrs = append(allErrs, field.Invalid(argumentsPath.Key(name), arguments[name], fmt.Sprintf("parameter %s is not declared", name)))
+		}
+	}
+
+	return values, allErrs
+}
+
+// RenderParameters substitutes the parameters referenced as {{ .Params.<name> }} in the text.
+// Referencing a parameter without a value is an error. The text is a Go template, so a literal {{ is written as {{"{{"}}.
+func RenderParameters(text string, values map[string]interface{}) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	t, err := template.New("").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, struct{ Params map[string]interface{} }{Params: values}); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// parameterReference matches a reference to a parameter, e.g. {{ .Params.host }}.
+var parameterReference = regexp.MustCompile(`\{\{\s*\.Params\.([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// RenderParameterReferences substitutes only the parameters referenced as {{ .Params.<name> }} in the text, which is not a Go template.
+// Queries are rendered this way, since their languages use {{ themselves, e.g. the line_format of LogQL.
+func RenderParameterReferences(text string, values map[string]interface{}) (string, error) {
+	missing := []string{}
+	rendered := parameterReference.ReplaceAllStringFunc(text, func(reference string) string {
+		name := parameterReference.FindStringSubmatch(reference)[1]
+		value, ok := values[name]
+		if !ok {
+			missing = append(missing, name)
+			return reference
+		}
+
+		return fmt.Sprint(value)
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("parameters without a value are referenced: %s", strings.Join(missing, ", "))
+	}
+
+	return rendered, nil
+}
+
+// RenderContainerParameters substitutes the parameters into the image, command, args and env of the container.
+func RenderContainerParameters(container *corev1.Container, values map[string]interface{}, path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	render := func(value *string, path *field.Path) {
+		rendered, err := RenderParameters(*value, values)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(path, *value, err.Error()))
+			return
+		}
+		*value = rendered
+	}
+
+	render(&container.Image, path.Child("image"))
+	for i := range container.Command {
+		render(&container.Command[i], path.Child("command").Index(i))
+	}
+	for i := range container.Args {
+		render(&container.Args[i], path.Child("args").Index(i))
+	}
+	for i := range container.Env {
+		render(&container.Env[i].Value, path.Child("env").Index(i).Child("value"))
+	}
+
+	return allErrs
+}
+
+// SubstituteParameters substitutes the parameters into the fields of the spec that accept them, in place.
+// The queries are not Go templates, only the references to the parameters are substituted in them.
+// The containers of the templates referencing a ScenarioTemplate are rendered with the parameters of that template instead.
+func (in *ScenarioSpec) SubstituteParameters(values map[string]interface{}, path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	render := func(value *string, path *field.Path) {
+		rendered, err := RenderParameters(*value, values)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(path, *value, err.Error()))
+			return
+		}
+		*value = rendered
+	}
+	renderQuery := func(value *string, path *field.Path) {
+		rendered, err := RenderParameterReferences(*value, values)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(path, *value, err.Error()))
+			return
+		}
+		*value = rendered
+	}
+
+	for i := range in.Templates {
+		template := &in.Templates[i]
+		templatePath := path.Child("templates").Index(i)
+
+		if template.Container != nil {
+			allErrs = append(allErrs, RenderContainerParameters(template.Container, values, templatePath.Child("container"))...)
+		}
+
+		if template.TemplateRef != nil {
+			for name, value := range template.TemplateRef.Parameters {
+				render(&value, templatePath.Child("templateRef", "parameters").Key(name))
+				template.TemplateRef.Parameters[name] = value
+			}
+		}
+
+		if template.Workload != nil {
+			render(&template.Workload.Namespace, templatePath.Child("workload", "namespace"))
+		}
+
+		if template.Stratus != nil {
+			render(&template.Stratus.Technique, templatePath.Child("stratus", "technique"))
+		}
+	}
+
+	renderExpectations := func(expectations []Expectation, path *field.Path) {
+		for i := range expectations {
+			expectation := &expectations[i]
+			expectationPath := path.Index(i)
+
+			render(&expectation.Timeout, expectationPath.Child("timeout"))
+			if expectation.Datadog != nil && expectation.Datadog.Monitor != nil {
+				monitor := expectation.Datadog.Monitor
+				monitorPath := expectationPath.Child("datadog", "monitor")
+
+				render(&monitor.ID, monitorPath.Child("id"))
+				render(&monitor.Status, monitorPath.Child("status"))
+				render(&monitor.Group, monitorPath.Child("group"))
+			}
+			if expectation.Datadog != nil && expectation.Datadog.Logs != nil {
+				renderQuery(&expectation.Datadog.Logs.Query, expectationPath.Child("datadog", "logs", "query"))
+			}
+			if expectation.Elastic != nil {
+				elasticPath := expectationPath.Child("elastic")
+				render(&expectation.Elastic.URL, elasticPath.Child("url"))
+				render(&expectation.Elastic.Index, elasticPath.Child("index"))
+				renderQuery(&expectation.Elastic.Query, elasticPath.Child("query"))
+			}
+			if expectation.Loki != nil {
+				lokiPath := expectationPath.Child("loki")
+				render(&expectation.Loki.URL, lokiPath.Child("url"))
+				renderQuery(&expectation.Loki.Query, lokiPath.Child("query"))
+				renderQuery(&expectation.Loki.Selector, lokiPath.Child("selector"))
+			}
+			if expectation.Sigma != nil {
+				render(&expectation.Sigma.ID, expectationPath.Child("sigma", "id"))
+			}
+		}
+	}
+
+	renderExpectations(in.Expectations, path.Child("expectations"))
+	for i := range in.Steps {
+		renderExpectations(in.Steps[i].Expectations, path.Child("steps").Index(i).Child("expectations"))
+	}
+
+	if in.Cleanup != nil && in.Cleanup.Datadog != nil && in.Cleanup.Datadog.ArchiveSignals != nil {
+		renderQuery(&in.Cleanup.Datadog.ArchiveSignals.Query, path.Child("cleanup", "datadog", "archiveSignals", "query"))
+	}
+
+	return allErrs
+}
@@ -31,8 +31,47 @@ type ScenarioSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	Templates    []Template    `json:"templates"`
-	Expectations []Expectation `json:"expectations,omitempty"`
+	// +kubebuilder:validation:MinItems=1
+	Templates []Template `json:"templates"`
+	// MitreAttack maps the scenario onto the tactics and techniques of MITRE ATT&CK it simulates,
+	// so that its results are aggregated into the detection coverage.
+	MitreAttack *MitreAttack `json:"mitreAttack,omitempty"`
+	// Parameters are the inputs of the scenario, substituted as {{ .Params.<name> }} into the container image, command, args and env
+	// of the templates, the parameters of the template references, the workload namespaces, the expectations and the cleanup.
+	Parameters []Parameter `json:"parameters,omitempty"`
+	// Arguments are the values of the parameters, by name.
+	Arguments map[string]string `json:"arguments,omitempty"`
+	// PodTemplate is merged into the pod of every scenario job, e.g. to run the templates with a service account or on the host network.
+	PodTemplate *PodTemplate `json:"podTemplate,omitempty"`
+	// JobTemplate configures the scenario jobs.
+	JobTemplate *JobTemplate `json:"jobTemplate,omitempty"`
+	// Timeout bounds the execution of each scenario job, as its activeDeadlineSeconds, and of each workload template, e.g. "10m".
+	Timeout string `json:"timeout,omitempty"`
+	// Deadline bounds a whole run of the scenario, from the start of the attack to the end of the expectations, e.g. "30m".
+	// A run reaching its deadline is aborted and the scenario ends as TimedOut.
+	Deadline string `json:"deadline,omitempty"`
+	// CleanupPolicy decides whether the scenario jobs are deleted once the scenario has run. Defaults to Always.
+	// Jobs that are kept are deleted along with the scenario.
+	CleanupPolicy JobCleanupPolicy `json:"cleanupPolicy,omitempty"`
+	// Target pins the scenario to nodes. The scenario runs once on each selected node
+	// and its expectations are evaluated per node.
+	Target *Target `json:"target,omitempty"`
+	// Steps run templates one after another, in order, each in its own job.
+	// When a step declares dependsOn, the steps are scheduled as a DAG instead and independent steps run in parallel.
+	// When empty, all templates run at the same time in a single job.
+	Steps []Step `json:"steps,omitempty"`
+	// FailurePolicy decides whether the remaining steps run when a step fails. Defaults to Abort.
+	FailurePolicy FailurePolicy `json:"failurePolicy,omitempty"`
+	Expectations  []Expectation `json:"expectations,omitempty"`
+	// Cleanup restores the state of the detection backends after the scenario has run,
+	// so that test alerts do not keep paging on-call.
+	Cleanup *Cleanup `json:"cleanup,omitempty"`
+	// Suppression mutes the notifications of the alerts generated by the scenario while it is running and until the
+	// alerts are resolved, so that the SOC can distinguish test alerts from real ones.
+	Suppression *Suppression `json:"suppression,omitempty"`
+	// DryRun renders the plan of the scenario into the status and validates its expectations against the detection
+	// backends, without running anything. The scenario ends as Planned, or Failed when an expectation is not valid.
+	DryRun bool `json:"dryRun,omitempty"`
 }
 
 // ScenarioStatus defines the observed state of Scenario
@@ -42,6 +81,88 @@ type ScenarioStatus struct {
 	Status     string             `json:"status,omitempty"`
 	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
 	Result     ExpectationResult  `json:"result,omitempty"`
+	// StartTime is the time at which the scenario job was started.
+	// Expectations only accept detections that happened after this time.
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// CleanupTime is the time at which the cleanup phase was completed.
+	CleanupTime *metav1.Time `json:"cleanupTime,omitempty"`
+	// Suppressions are the notification suppressions created by the runs of the scenario.
+	// They are removed once the cleanup phase has resolved the monitors, when the run is cancelled, or by the finalizer.
+	// Otherwise they last until their downtimes end.
+	Suppressions []SuppressionRef `json:"suppressions,omitempty"`
+	// Steps is the state of each step of the scenario.
+	Steps []StepStatus `json:"steps,omitempty"`
+	// Nodes is the state of the scenario on each node selected by the target.
+	Nodes []NodeStatus `json:"nodes,omitempty"`
+	// Executions are the outcomes of the templates that ran inside existing workloads.
+	Executions []ExecutionStatus `json:"executions,omitempty"`
+	// Containers are the outcomes of the containers of the scenario jobs, collected before the jobs are deleted.
+	Containers []ContainerResult `json:"containers,omitempty"`
+	// Resources are the Kubernetes objects created for the scenario. They are owned by the scenario
+	// and deleted by its finalizer.
+	Resources []ResourceRef `json:"resources,omitempty"`
+	// TemplateRevisions are the revisions of the referenced templates that the scenario last ran with.
+	// The scenario runs again when one of them is updated.
+	TemplateRevisions []TemplateRevision `json:"templateRevisions,omitempty"`
+	// SigmaRules are the revisions of the Sigma rules referenced by the expectations of the last run,
+	// and whether their expectations passed.
+	SigmaRules []SigmaRuleStatus `json:"sigmaRules,omitempty"`
+	// RerunRequest is the value of the threatester.github.io/rerun annotation that the current run was requested with.
+	RerunRequest string `json:"rerunRequest,omitempty"`
+	// Plan is what a dry run of the scenario would create and evaluate.
+	Plan *ScenarioPlan `json:"plan,omitempty"`
+	// FinalizerAttempts is the number of times the finalizer failed to clean up the scenario or to remove its
+	// suppressions. The scenario is deleted anyway once the attempts are exhausted.
+	FinalizerAttempts int32 `json:"finalizerAttempts,omitempty"`
+}
+
+// ScenarioPlan is the fully resolved scenario, as rendered by a dry run.
+type ScenarioPlan struct {
+	// Generation is the generation of the scenario the plan was rendered for.
+	Generation int64 `json:"generation,omitempty"`
+	// Parameters are the values substituted for the parameters of the scenario, by name.
+	Parameters map[string]string `json:"parameters,omitempty"`
+	// Resources are the Kubernetes objects the scenario would create, and the templates it would run inside existing workloads.
+	Resources []PlannedResource `json:"resources,omitempty"`
+	// Expectations are the expectations the scenario would evaluate, with the queries sent to the detection backends.
+	Expectations []PlannedExpectation `json:"expectations,omitempty"`
+}
+
+type PlannedResource struct {
+	// Kind of the resource, e.g. "Job", or "Workload" for a template running inside an existing pod.
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Step is the name of the step the resource runs. Empty for the resources of the scenario.
+	Step string `json:"step,omitempty"`
+	// Node is the name of the node the resource is pinned to. Empty unless the scenario has a target.
+	Node string `json:"node,omitempty"`
+	// ServiceAccountName is the service account the pods of the resource run as, which grants the attack its permissions.
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// Manifest is the YAML manifest of the resource.
+	Manifest string `json:"manifest"`
+}
+
+type PlannedExpectation struct {
+	// Step is the name of the step the expectation belongs to. Empty for the expectations of the scenario.
+	Step        string      `json:"step,omitempty"`
+	Expectation Expectation `json:"expectation"`
+	// Valid is true when the detection backend accepted the credentials, the monitor and the query of the expectation.
+	Valid bool `json:"valid"`
+	// Message is why the expectation is not valid.
+	Message string `json:"message,omitempty"`
+}
+
+type SigmaRuleStatus struct {
+	ID    string `json:"id"`
+	Title string `json:"title,omitempty"`
+	// Revision is the SHA-256 digest of the rule, so that the validated revision can be traced back in git.
+	Revision string `json:"revision"`
+	// Modified is the modified date of the rule, or its date when it was never modified.
+	Modified string `json:"modified,omitempty"`
+	// Source is the ConfigMap or the file the rule was loaded from.
+	Source string `json:"source"`
+	// Validated is true when all expectations referencing the rule passed.
+	Validated bool `json:"validated"`
 }
 
 type ExpectationResult struct {
@@ -52,6 +173,10 @@ type ExpectationResult struct {
 }
 
 type FailedExpectation struct {
+	// Step is the name of the step the expectation belongs to. Empty for the expectations of the scenario.
+	Step string `json:"step,omitempty"`
+	// Node is the name of the node the expectation was evaluated for. Empty unless the scenario has a target.
+	Node        string      `json:"node,omitempty"`
 	Expectation Expectation `json:"expectation,omitempty"`
 	Reason      string      `json:"reason,omitempty"`
 }
@@ -78,23 +203,368 @@ type ScenarioList struct {
 	Items           []Scenario `json:"items"`
 }
 
+// Template runs one of container, templateRef and stratus.
+// +kubebuilder:validation:XValidation:rule="has(self.container) || has(self.templateRef) || has(self.stratus)",message="one of container, templateRef and stratus is required"
 type Template struct {
 	Name      string            `json:"name,omitempty"`
 	Container *corev1.Container `json:"container,omitempty"`
+	// TemplateRef runs the attack of a ScenarioTemplate or ClusterScenarioTemplate instead of container.
+	TemplateRef *TemplateRef `json:"templateRef,omitempty"`
+	// PodTemplate is merged into the pod running this template, over the pod template of the scenario.
+	PodTemplate *PodTemplate `json:"podTemplate,omitempty"`
+	// Workload runs the template inside an existing pod instead of a scenario job,
+	// so that the detections key on the labels of the victim workload.
+	Workload *Workload `json:"workload,omitempty"`
+	// Stratus runs a Stratus Red Team technique instead of container.
+	Stratus *StratusTemplate `json:"stratus,omitempty"`
+}
+
+// StratusTemplate runs a Stratus Red Team technique. The technique is warmed up and detonated in the scenario job,
+// and reverted in the cleanup phase of the scenario, even when the attack or the expectations fail.
+// Steps running Stratus Red Team techniques must depend on each other, since they share the volume keeping their state.
+type StratusTemplate struct {
+	// Technique is the ID of the technique, e.g. "k8s.credential-access.steal-serviceaccount-token".
+	Technique string `json:"technique"`
+	// Image of Stratus Red Team. Defaults to ghcr.io/datadog/stratus-red-team:v2.11.1.
+	Image string `json:"image,omitempty"`
+	// KubeconfigSecretName is the name of a Secret holding the kubeconfig of the cluster under the "config" key,
+	// used by the Kubernetes techniques.
+	KubeconfigSecretName string `json:"kubeconfigSecretName,omitempty"`
+	// Env is passed to Stratus Red Team, e.g. the credentials of the cloud provider of the technique.
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// StorageClassName of the volume keeping the state of Stratus Red Team between the phases.
+	// Defaults to the default storage class of the cluster.
+	StorageClassName *string `json:"storageClassName,omitempty"`
+}
+
+type MitreAttack struct {
+	// Tactics are the IDs of the tactics, e.g. "TA0006".
+	Tactics []string `json:"tactics,omitempty"`
+	// Techniques are the IDs of the techniques or sub-techniques, e.g. "T1552" or "T1552.001".
+	Techniques []string `json:"techniques,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=String;Integer;Boolean
+type ParameterType string
+
+const (
+	ParameterTypeString  ParameterType = "String"
+	ParameterTypeInteger ParameterType = "Integer"
+	ParameterTypeBoolean ParameterType = "Boolean"
+)
+
+type Parameter struct {
+	Name string `json:"name"`
+	// Type of the value of the parameter. Defaults to String.
+	Type        ParameterType `json:"type,omitempty"`
+	Description string        `json:"description,omitempty"`
+	// Default is the value of the parameter when it is not set.
+	// A parameter without a default must be set.
+	Default *string `json:"default,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=ScenarioTemplate;ClusterScenarioTemplate
+type TemplateKind string
+
+const (
+	TemplateKindScenarioTemplate        TemplateKind = "ScenarioTemplate"
+	TemplateKindClusterScenarioTemplate TemplateKind = "ClusterScenarioTemplate"
+)
+
+type TemplateRef struct {
+	// Name of the ScenarioTemplate in the namespace of the scenario, or of the ClusterScenarioTemplate.
+	Name string `json:"name"`
+	// Kind of the referenced template. Defaults to ScenarioTemplate.
+	Kind TemplateKind `json:"kind,omitempty"`
+	// Parameters are the values of the parameters of the referenced template.
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// TemplateRevision is the revision of a referenced template that the scenario last ran with.
+type TemplateRevision struct {
+	Kind       TemplateKind `json:"kind"`
+	Name       string       `json:"name"`
+	Generation int64        `json:"generation"`
+}
+
+// +kubebuilder:validation:Enum=Exec;EphemeralContainer
+type WorkloadMode string
+
+const (
+	// WorkloadModeExec runs the command of the template container in the target container through the pods/exec subresource.
+	WorkloadModeExec WorkloadMode = "Exec"
+	// WorkloadModeEphemeralContainer injects the template container into the pod as an ephemeral container.
+	WorkloadModeEphemeralContainer WorkloadMode = "EphemeralContainer"
+)
+
+type Workload struct {
+	// Namespace of the pod. Defaults to the namespace of the scenario.
+	Namespace string `json:"namespace,omitempty"`
+	// Selector selects the pod to run the template in. The first running pod by name is used.
+	Selector metav1.LabelSelector `json:"selector"`
+	// Container is the name of the target container. Defaults to the first container of the pod.
+	Container string `json:"container,omitempty"`
+	// Mode is how the template runs in the pod. Defaults to Exec.
+	Mode WorkloadMode `json:"mode,omitempty"`
+}
+
+// ContainerResult is the outcome of a container of a scenario job.
+type ContainerResult struct {
+	Job       string `json:"job"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	// ExitCode is the exit code of the container, when it terminated.
+	ExitCode *int32 `json:"exitCode,omitempty"`
+	// Reason is the reason of the termination of the container, e.g. "Error" or "OOMKilled".
+	Reason string `json:"reason,omitempty"`
+	// Logs is the tail of the logs of the container.
+	Logs string `json:"logs,omitempty"`
+	// LogsConfigMap is the name of the ConfigMap holding a longer tail of the logs, when they do not fit in the status.
+	LogsConfigMap string `json:"logsConfigMap,omitempty"`
+}
+
+// ExecutionStatus is the outcome of a template that ran inside an existing workload.
+type ExecutionStatus struct {
+	Template string `json:"template"`
+	// Step is the name of the step that ran the template, if any.
+	Step      string `json:"step,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+	// ExitCode is the exit code of the command, when it could be observed.
+	ExitCode *int32 `json:"exitCode,omitempty"`
+	// Output is the tail of the combined stdout and stderr of the command.
+	Output         string       `json:"output,omitempty"`
+	StartTime      *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	Message        string       `json:"message,omitempty"`
+}
+
+// PodTemplate holds the pod settings that the scenario job builder merges into the pod spec.
+// Scalar fields override the ones set before, lists are appended and maps are merged.
+type PodTemplate struct {
+	Labels             map[string]string `json:"labels,omitempty"`
+	Annotations        map[string]string `json:"annotations,omitempty"`
+	ServiceAccountName string            `json:"serviceAccountName,omitempty"`
+	// AutomountServiceAccountToken indicates whether a service account token should be automatically mounted.
+	AutomountServiceAccountToken *bool                         `json:"automountServiceAccountToken,omitempty"`
+	HostPID                      bool                          `json:"hostPID,omitempty"`
+	HostIPC                      bool                          `json:"hostIPC,omitempty"`
+	HostNetwork                  bool                          `json:"hostNetwork,omitempty"`
+	SecurityContext              *corev1.PodSecurityContext    `json:"securityContext,omitempty"`
+	Volumes                      []corev1.Volume               `json:"volumes,omitempty"`
+	NodeSelector                 map[string]string             `json:"nodeSelector,omitempty"`
+	Tolerations                  []corev1.Toleration           `json:"tolerations,omitempty"`
+	Affinity                     *corev1.Affinity              `json:"affinity,omitempty"`
+	ImagePullSecrets             []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	PriorityClassName            string                        `json:"priorityClassName,omitempty"`
+}
+
+// JobTemplate holds the settings of the scenario jobs.
+type JobTemplate struct {
+	// BackoffLimit is the number of retries before the job is marked as failed. Defaults to 0.
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+	// ActiveDeadlineSeconds is the duration in seconds the job may be active before it is terminated.
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+	// TTLSecondsAfterFinished limits the lifetime of a finished job.
+	// It applies to the jobs kept by the OnSuccess and Never cleanup policies, since the controller deletes the other jobs once the scenario has run.
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+}
+
+type Step struct {
+	Name string `json:"name"`
+	// Template is the name of the template to run in this step.
+	Template string `json:"template"`
+	// DependsOn is the names of the steps that must complete before this step starts.
+	DependsOn []string `json:"dependsOn,omitempty"`
+	// Expectations are evaluated against the execution window of this step, from the time it started until it completed.
+	Expectations []Expectation `json:"expectations,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=Abort;Continue
+type FailurePolicy string
+
+const (
+	// FailurePolicyAbort skips the remaining steps when a step fails.
+	FailurePolicyAbort FailurePolicy = "Abort"
+	// FailurePolicyContinue runs the remaining steps even when a step fails.
+	FailurePolicyContinue FailurePolicy = "Continue"
+)
+
+// +kubebuilder:validation:Enum=Always;OnSuccess;Never
+type JobCleanupPolicy string
+
+const (
+	// JobCleanupPolicyAlways deletes the scenario jobs once the scenario has run.
+	JobCleanupPolicyAlways JobCleanupPolicy = "Always"
+	// JobCleanupPolicyOnSuccess keeps the scenario jobs of a failed scenario for debugging.
+	JobCleanupPolicyOnSuccess JobCleanupPolicy = "OnSuccess"
+	// JobCleanupPolicyNever keeps the scenario jobs until the scenario is deleted.
+	JobCleanupPolicyNever JobCleanupPolicy = "Never"
+)
+
+type StepPhase string
+
+const (
+	StepPhasePending   StepPhase = "Pending"
+	StepPhaseRunning   StepPhase = "Running"
+	StepPhaseSucceeded StepPhase = "Succeeded"
+	StepPhaseFailed    StepPhase = "Failed"
+	StepPhaseSkipped   StepPhase = "Skipped"
+)
+
+type StepStatus struct {
+	Name           string       `json:"name"`
+	Phase          StepPhase    `json:"phase,omitempty"`
+	StartTime      *metav1.Time `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	Message        string       `json:"message,omitempty"`
+	// Result is the result of the expectations of the step.
+	Result *ExpectationResult `json:"result,omitempty"`
+}
+
+type Target struct {
+	// NodeName pins the scenario to the node with this name.
+	NodeName string `json:"nodeName,omitempty"`
+	// NodeSelector selects the nodes to run the scenario on.
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+	// NodePoolLabel runs the scenario on one node of each node pool, the pool of a node being the value of this label,
+	// e.g. "cloud.google.com/gke-nodepool". Combined with nodeSelector, only the selected nodes are considered.
+	NodePoolLabel string `json:"nodePoolLabel,omitempty"`
+	// NodeTag is the tag holding the node name in the detection backends, used to scope the expectations to each node.
+	// Defaults to "host".
+	NodeTag string `json:"nodeTag,omitempty"`
+}
+
+// NodeStatus is the state of the scenario on a node selected by the target.
+type NodeStatus struct {
+	Name    string    `json:"name"`
+	Phase   StepPhase `json:"phase,omitempty"`
+	Message string    `json:"message,omitempty"`
+	// Result is the result of the expectations of the scenario on this node.
+	Result *ExpectationResult `json:"result,omitempty"`
 }
 
 type Expectation struct {
+	// Timeout is how long the expectation is retried until it passes, e.g. "5m".
+	// +kubebuilder:validation:Pattern=`^(([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+|.*\{\{[^}]*\.Params\.[^}]*\}\}.*)$`
 	Timeout string              `json:"timeout,omitempty"`
 	Datadog *DatadogExpectation `json:"datadog,omitempty"`
+	// Elastic expects documents matching a query in Elasticsearch.
+	Elastic *ElasticExpectation `json:"elastic,omitempty"`
+	// Loki expects log lines matching a query in Grafana Loki.
+	Loki *LokiExpectation `json:"loki,omitempty"`
+	// Sigma is the Sigma rule that the expectation proves. The detection of the rule is translated into the query
+	// of the Datadog logs, Elastic or Loki expectation when the query is empty.
+	Sigma *SigmaRuleRef `json:"sigma,omitempty"`
 }
 
 type DatadogExpectation struct {
 	Monitor *DatadogMonitor `json:"monitor,omitempty"`
+	// Logs expects logs matching a query after the scenario started.
+	Logs *DatadogLogs `json:"logs,omitempty"`
+}
+
+type DatadogLogs struct {
+	// Query is a log search query, e.g. "source:kubernetes.audit @objectRef.resource:secrets".
+	Query string `json:"query,omitempty"`
+}
+
+type ElasticExpectation struct {
+	// URL of Elasticsearch, e.g. "https://elasticsearch.logging:9200".
+	URL string `json:"url"`
+	// Index is the index or index pattern to search, e.g. "logs-*".
+	Index string `json:"index"`
+	// Query is a Lucene query string, e.g. "objectRef.resource:secrets AND verb:list".
+	Query string `json:"query,omitempty"`
+	// TimestampField is the field holding the time of the documents. Defaults to "@timestamp".
+	TimestampField string `json:"timestampField,omitempty"`
+}
+
+type LokiExpectation struct {
+	// URL of Loki, e.g. "http://loki-gateway.logging".
+	URL string `json:"url"`
+	// TenantID is sent as X-Scope-OrgID to a multi-tenant Loki.
+	TenantID string `json:"tenantID,omitempty"`
+	// Query is a LogQL log query, e.g. `{job="kubernetes-audit"} | json | objectRef_resource="secrets"`.
+	Query string `json:"query,omitempty"`
+	// Selector is the stream selector of the query translated from the Sigma rule, e.g. `{job="kubernetes-audit"}`.
+	Selector string `json:"selector,omitempty"`
+}
+
+// SigmaRuleRef references a Sigma rule by its ID, in a ConfigMap of the namespace of the scenario
+// or in a file mounted into the manager.
+type SigmaRuleRef struct {
+	// ID of the rule.
+	ID string `json:"id"`
+	// ConfigMapName is the name of a ConfigMap holding rules in its values.
+	ConfigMapName string `json:"configMapName,omitempty"`
+	// Path of a rule file, or of a directory of rule files, relative to the Sigma rules directory of the manager.
+	Path string `json:"path,omitempty"`
+	// Fields maps the fields of the rule onto the fields of the backend,
+	// e.g. "Image: @process.executable" for Datadog logs.
+	Fields map[string]string `json:"fields,omitempty"`
 }
 
 type DatadogMonitor struct {
-	ID     string `json:"id,omitempty"`
+	// ID of the monitor, e.g. "12345".
+	// +kubebuilder:validation:Pattern=`^([0-9]+|.*\{\{[^}]*\.Params\.[^}]*\}\}.*)$`
+	ID string `json:"id,omitempty"`
+	// Status is the state the monitor is expected to transition into, one of Alert, Warn, OK and No Data.
+	// +kubebuilder:validation:Pattern=`^(Alert|Warn|OK|No Data|.*\{\{[^}]*\.Params\.[^}]*\}\}.*)$`
 	Status string `json:"status,omitempty"`
+	// Group restricts the expectation to a monitor group, e.g. "pod_name:foo,kube_namespace:bar".
+	// A monitor group matches when it contains all of the given tags.
+	// When empty, any group of the monitor may satisfy the expectation.
+	Group string `json:"group,omitempty"`
+}
+
+type Cleanup struct {
+	Datadog *DatadogCleanup `json:"datadog,omitempty"`
+}
+
+type DatadogCleanup struct {
+	// ResolveMonitors resolves the triggered groups of the monitors referenced by the expectations of the scenario and its steps.
+	ResolveMonitors bool `json:"resolveMonitors,omitempty"`
+	// ArchiveSignals archives the security signals generated while the scenario was running.
+	ArchiveSignals *DatadogSignalArchive `json:"archiveSignals,omitempty"`
+	// Downtime schedules a downtime on the monitors referenced by the expectations of the scenario and its steps for the scenario window.
+	Downtime *DatadogDowntime `json:"downtime,omitempty"`
+}
+
+type DatadogSignalArchive struct {
+	// Query selects the security signals to archive, e.g. "@workflow.rule.id:abc-def-ghi".
+	Query string `json:"query"`
+}
+
+type DatadogDowntime struct {
+	// Duration extends the downtime beyond the end of the scenario. Defaults to 1h.
+	Duration string `json:"duration,omitempty"`
+}
+
+type Suppression struct {
+	Datadog *DatadogSuppression `json:"datadog,omitempty"`
+}
+
+// DatadogSuppression creates a Datadog downtime scoped to the tags of the scenario pods.
+type DatadogSuppression struct {
+	// MonitorTags restricts the downtime to the monitors with all of these tags. Defaults to all monitors.
+	MonitorTags []string `json:"monitorTags,omitempty"`
+	// Duration is the length of the downtime, unless the cleanup phase resolves the monitors. Defaults to 1h.
+	Duration string `json:"duration,omitempty"`
+}
+
+type ResourceRef struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+type SuppressionRef struct {
+	// Provider is the backend that holds the suppression, e.g. "datadog".
+	Provider string `json:"provider"`
+	// ID is the identifier of the suppression in the backend, e.g. the Datadog downtime ID.
+	ID string `json:"id"`
 }
 
 func init() {
@@ -0,0 +1,591 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	"github.com/mrtc0/threatester/internal/dag"
+)
+
+// log is for logging in this package.
+var scenariolog = logf.Log.WithName("scenario-resource")
+
+var (
+	mitreTacticPattern    = regexp.MustCompile(`^TA[0-9]{4}$`)
+	mitreTechniquePattern = regexp.MustCompile(`^T[0-9]{4}(\.[0-9]{3})?$`)
+
+	// datadogMonitorStatuses are the states a monitor can be expected to transition into.
+	datadogMonitorStatuses = []string{"Alert", "Warn", "OK", "No Data"}
+)
+
+func (r *Scenario) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-threatester-github-io-v1alpha1-scenario,mutating=false,failurePolicy=fail,sideEffects=None,groups=threatester.github.io,resources=scenarios,verbs=create;update,versions=v1alpha1,name=vscenario.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &Scenario{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *Scenario) ValidateCreate() error {
+	scenariolog.Info("validate create", "name", r.Name)
+
+	return r.validateScenario()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *Scenario) ValidateUpdate(old runtime.Object) error {
+	scenariolog.Info("validate update", "name", r.Name)
+
+	return r.validateScenario()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *Scenario) ValidateDelete() error {
+	return nil
+}
+
+func (r *Scenario) validateScenario() error {
+	allErrs := field.ErrorList{}
+	// the name of the scenario labels its jobs
+	for _, msg := range validation.IsValidLabelValue(r.Name) {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("metadata", "name"), r.Name, msg))
+	}
+	allErrs = append(allErrs, validateTemplates(r.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateSteps(r.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateTarget(r.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateWorkloads(r.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateStratus(r.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateMitreAttack(r.Spec, field.NewPath("spec"))...)
+	substitutedSpec, substituted := substituteParameters(r.Spec)
+	allErrs = append(allErrs, validateExpectations(substitutedSpec, substituted, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateTimeouts(r.Spec, field.NewPath("spec"))...)
+	allErrs = append(allErrs, validateParameters(r.Spec, field.NewPath("spec"))...)
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("Scenario").GroupKind(), r.Name, allErrs)
+}
+
+// validateTemplates checks that the templates have unique names, and either define their container, reference a template
+// or run a Stratus Red Team technique.
+func validateTemplates(spec ScenarioSpec, specPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if len(spec.Templates) == 0 {
+		allErrs = append(allErrs, field.Required(specPath.Child("templates"), "at least one template is required"))
+	}
+
+	names := map[string]struct{}{}
+	for i, template := range spec.Templates {
+		templatePath := specPath.Child("templates").Index(i)
+
+		if template.Name != "" {
+			if _, ok := names[template.Name]; ok {
+				allErrs = append(allErrs, field.Duplicate(templatePath.Child("name"), template.Name))
+			}
+			names[template.Name] = struct{}{}
+		}
+
+		// a workload template without container is reported by validateWorkloads
+		if template.Container == nil && template.TemplateRef == nil && template.Stratus == nil && template.Workload == nil {
+			allErrs = append(allErrs, field.Required(templatePath.Child("container"), "one of container, templateRef and stratus is required"))
+		}
+
+		if template.TemplateRef == nil {
+			continue
+		}
+
+		if template.Container != nil {
+			allErrs = append(allErrs, field.Forbidden(templatePath.Child("container"), "container cannot be combined with templateRef"))
+		}
+
+		if template.TemplateRef.Name == "" {
+			allErrs = append(allErrs, field.Required(templatePath.Child("templateRef", "name"), "name of the referenced template is required"))
+		}
+	}
+
+	return allErrs
+}
+
+// validateSteps checks that the steps reference existing templates and steps, and that their dependencies do not form a cycle.
+func validateSteps(spec ScenarioSpec, specPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	stepsPath := specPath.Child("steps")
+
+	templates := map[string]struct{}{}
+	for _, template := range spec.Templates {
+		templates[template.Name] = struct{}{}
+	}
+
+	steps := map[string]struct{}{}
+	for i, step := range spec.Steps {
+		// the name of the step labels its job and is part of the name of the job
+		for _, msg := range validation.IsDNS1123Label(step.Name) {
+			allErrs = append(allErrs, field.Invalid(stepsPath.Index(i).Child("name"), step.Name, msg))
+		}
+
+		if _, ok := steps[step.Name]; ok {
+			allErrs = append(allErrs, field.Duplicate(stepsPath.Index(i).Child("name"), step.Name))
+		}
+		steps[step.Name] = struct{}{}
+
+		if _, ok := templates[step.Template]; !ok {
+			allErrs = append(allErrs, field.NotFound(stepsPath.Index(i).Child("template"), step.Template))
+		}
+	}
+
+	names := []string{}
+	dependencies := map[string][]string{}
+	for i, step := range spec.Steps {
+		names = append(names, step.Name)
+		dependencies[step.Name] = step.DependsOn
+
+		for j, dependency := range step.DependsOn {
+			if _, ok := steps[dependency]; !ok {
+				allErrs = append(allErrs, field.NotFound(stepsPath.Index(i).Child("dependsOn").Index(j), dependency))
+			}
+		}
+	}
+
+	if _, err := dag.New(names, dependencies); err != nil {
+		var cycleErr *dag.CycleError
+		if errors.As(err, &cycleErr) {
+			allErrs = append(allErrs, field.Invalid(stepsPath, strings.Join(cycleErr.Path, " -> "), fmt.Sprintf("steps must not depend on each other in a cycle: %s", err)))
+		}
+	}
+
+	return allErrs
+}
+
+// validateTarget checks that the target selects nodes in a single way.
+// Steps run one job per step, so they can only be pinned to a single node.
+func validateTarget(spec ScenarioSpec, specPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if spec.Target == nil {
+		return allErrs
+	}
+
+	targetPath := specPath.Child("target")
+	if spec.Target.NodeName != "" && (spec.Target.NodeSelector != nil || spec.Target.NodePoolLabel != "") {
+		allErrs = append(allErrs, field.Invalid(targetPath.Child("nodeName"), spec.Target.NodeName, "nodeName cannot be combined with nodeSelector or nodePoolLabel"))
+	}
+
+	if spec.Target.NodeSelector != nil {
+		if _, err := metav1.LabelSelectorAsSelector(spec.Target.NodeSelector); err != nil {
+			allErrs = append(allErrs, field.Invalid(targetPath.Child("nodeSelector"), spec.Target.NodeSelector, err.Error()))
+		}
+	}
+
+	if len(spec.Steps) > 0 && spec.Target.NodeName == "" {
+		allErrs = append(allErrs, field.Forbidden(targetPath, "scenarios with steps can only target a single node with nodeName"))
+	}
+
+	return allErrs
+}
+
+// validateWorkloads checks that the templates running inside existing workloads have something to run.
+// The target pins scenario jobs to nodes, which does not apply to existing pods.
+func validateWorkloads(spec ScenarioSpec, specPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for i, template := range spec.Templates {
+		if template.Workload == nil {
+			continue
+		}
+		templatePath := specPath.Child("templates").Index(i)
+
+		if spec.Target != nil {
+			allErrs = append(allErrs, field.Forbidden(templatePath.Child("workload"), "workload cannot be combined with target"))
+		}
+
+		// the container of a referenced template is only known once it is resolved
+		if template.TemplateRef != nil || template.Stratus != nil {
+			continue
+		}
+
+		if template.Container == nil {
+			allErrs = append(allErrs, field.Required(templatePath.Child("container"), "container is required to run in a workload"))
+			continue
+		}
+
+		if template.Workload.Mode != WorkloadModeEphemeralContainer && len(template.Container.Command) == 0 && len(template.Container.Args) == 0 {
+			allErrs = append(allErrs, field.Required(templatePath.Child("container", "command"), "command is required to exec in a workload"))
+		}
+	}
+
+	return allErrs
+}
+
+// validateStratus checks that the templates running Stratus Red Team techniques run nothing else.
+// The state of the techniques lives on a single volume, so they cannot fan out over the nodes of the target.
+func validateStratus(spec ScenarioSpec, specPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	for i, template := range spec.Templates {
+		if template.Stratus == nil {
+			continue
+		}
+		templatePath := specPath.Child("templates").Index(i)
+
+		if template.Container != nil {
+			allErrs = append(allErrs, field.Forbidden(templatePath.Child("container"), "container cannot be combined with stratus"))
+		}
+		if template.TemplateRef != nil {
+			allErrs = append(allErrs, field.Forbidden(templatePath.Child("templateRef"), "templateRef cannot be combined with stratus"))
+		}
+		if template.Workload != nil {
+			allErrs = append(allErrs, field.Forbidden(templatePath.Child("workload"), "workload cannot be combined with stratus"))
+		}
+		if spec.Target != nil {
+			allErrs = append(allErrs, field.Forbidden(templatePath.Child("stratus"), "stratus cannot be combined with target"))
+		}
+
+		if template.Stratus.Technique == "" {
+			allErrs = append(allErrs, field.Required(templatePath.Child("stratus", "technique"), "ID of the Stratus Red Team technique is required"))
+		}
+	}
+
+	return append(allErrs, validateStratusSteps(spec, specPath)...)
+}
+
+// validateStratusSteps checks that the steps running Stratus Red Team techniques depend on each other, so that
+// they run one after another on the volume keeping their state. Steps without dependsOn already run in order.
+func validateStratusSteps(spec ScenarioSpec, specPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	stratusTemplates := map[string]struct{}{}
+	for _, template := range spec.Templates {
+		if template.Stratus != nil {
+			stratusTemplates[template.Name] = struct{}{}
+		}
+	}
+
+	names := []string{}
+	dependencies := map[string][]string{}
+	hasDependencies := false
+	for _, step := range spec.Steps {
+		names = append(names, step.Name)
+		dependencies[step.Name] = step.DependsOn
+		hasDependencies = hasDependencies || len(step.DependsOn) > 0
+	}
+
+	if len(stratusTemplates) == 0 || !hasDependencies {
+		return allErrs
+	}
+
+	// invalid dependencies are reported by validateSteps
+	graph, err := dag.New(names, dependencies)
+	if err != nil {
+		return allErrs
+	}
+
+	stratusSteps := []int{}
+	for i, step := range spec.Steps {
+		if _, ok := stratusTemplates[step.Template]; !ok {
+			continue
+		}
+
+		for _, j := range stratusSteps {
+			other := spec.Steps[j].Name
+			if !graph.DependsOn(step.Name, other) && !graph.DependsOn(other, step.Name) {
+				allErrs = append(allErrs, field.Forbidden(specPath.Child("steps").Index(i).Child("dependsOn"), fmt.Sprintf("step %s runs a Stratus Red Team technique in parallel with step %s, steps running Stratus Red Team techniques must depend on each other", step.Name, other)))
+			}
+		}
+		stratusSteps = append(stratusSteps, i)
+	}
+
+	return allErrs
+}
+
+// validateMitreAttack checks that the tactics and techniques are MITRE ATT&CK IDs, e.g. "TA0006" and "T1552.001".
+func validateMitreAttack(spec ScenarioSpec, specPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if spec.MitreAttack == nil {
+		return allErrs
+	}
+	mitreAttackPath := specPath.Child("mitreAttack")
+
+	for i, tactic := range spec.MitreAttack.Tactics {
+		if !mitreTacticPattern.MatchString(tactic) {
+			allErrs = append(allErrs, field.Invalid(mitreAttackPath.Child("tactics").Index(i), tactic, "must be a tactic ID, e.g. TA0006"))
+		}
+	}
+
+	for i, technique := range spec.MitreAttack.Techniques {
+		if !mitreTechniquePattern.MatchString(technique) {
+			allErrs = append(allErrs, field.Invalid(mitreAttackPath.Child("techniques").Index(i), technique, "must be a technique or sub-technique ID, e.g. T1552 or T1552.001"))
+		}
+	}
+
+	return allErrs
+}
+
+// validateExpectations checks that each expectation has a single backend with a query, or a Sigma rule to translate into one.
+// The values referencing parameters are only checked when the parameters could be substituted.
+func validateExpectations(spec ScenarioSpec, substituted bool, specPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	validate := func(expectations []Expectation, path *field.Path) {
+		for i, expectation := range expectations {
+			allErrs = append(allErrs, validateExpectation(expectation, substituted, path.Index(i))...)
+		}
+	}
+
+	validate(spec.Expectations, specPath.Child("expectations"))
+	for i, step := range spec.Steps {
+		validate(step.Expectations, specPath.Child("steps").Index(i).Child("expectations"))
+	}
+
+	return allErrs
+}
+
+func validateExpectation(expectation Expectation, substituted bool, path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	backends := 0
+	for _, set := range []bool{expectation.Datadog != nil, expectation.Elastic != nil, expectation.Loki != nil} {
+		if set {
+			backends++
+		}
+	}
+	switch {
+	case backends == 0:
+		allErrs = append(allErrs, field.Required(path, "one of datadog, elastic and loki is required"))
+	case backends > 1:
+		allErrs = append(allErrs, field.Invalid(path, "", "only one of datadog, elastic and loki can be set"))
+	}
+
+	if expectation.Timeout != "" && !isUnsubstituted(expectation.Timeout, substituted) {
+		timeout, err := time.ParseDuration(expectation.Timeout)
+		switch {
+		case err != nil:
+			allErrs = append(allErrs, field.Invalid(path.Child("timeout"), expectation.Timeout, err.Error()))
+		case timeout < 0:
+			allErrs = append(allErrs, field.Invalid(path.Child("timeout"), expectation.Timeout, "must not be a negative duration"))
+		}
+	}
+
+	hasSigma := expectation.Sigma != nil
+	if hasSigma {
+		sigmaPath := path.Child("sigma")
+		if expectation.Sigma.ID == "" {
+			allErrs = append(allErrs, field.Required(sigmaPath.Child("id"), "ID of the Sigma rule is required"))
+		}
+		if (expectation.Sigma.ConfigMapName == "") == (expectation.Sigma.Path == "") {
+			allErrs = append(allErrs, field.Invalid(sigmaPath, "", "exactly one of configMapName and path is required"))
+		}
+		if expectation.Sigma.Path != "" && !isLocalPath(expectation.Sigma.Path) {
+			allErrs = append(allErrs, field.Invalid(sigmaPath.Child("path"), expectation.Sigma.Path, "must be a path relative to the Sigma rules directory of the manager, without .. escaping it"))
+		}
+	}
+
+	if datadog := expectation.Datadog; datadog != nil {
+		datadogPath := path.Child("datadog")
+		if (datadog.Monitor == nil) == (datadog.Logs == nil) {
+			allErrs = append(allErrs, field.Invalid(datadogPath, "", "exactly one of monitor and logs is required"))
+		}
+		if datadog.Monitor != nil {
+			allErrs = append(allErrs, validateDatadogMonitor(*datadog.Monitor, substituted, datadogPath.Child("monitor"))...)
+		}
+		if datadog.Logs != nil && datadog.Logs.Query == "" && !hasSigma {
+			allErrs = append(allErrs, field.Required(datadogPath.Child("logs", "query"), "query is required without a Sigma rule"))
+		}
+	}
+
+	if elastic := expectation.Elastic; elastic != nil {
+		elasticPath := path.Child("elastic")
+		if elastic.URL == "" {
+			allErrs = append(allErrs, field.Required(elasticPath.Child("url"), "URL of Elasticsearch is required"))
+		}
+		if elastic.Index == "" {
+			allErrs = append(allErrs, field.Required(elasticPath.Child("index"), "index is required"))
+		}
+		if elastic.Query == "" && !hasSigma {
+			allErrs = append(allErrs, field.Required(elasticPath.Child("query"), "query is required without a Sigma rule"))
+		}
+	}
+
+	if loki := expectation.Loki; loki != nil {
+		lokiPath := path.Child("loki")
+		if loki.URL == "" {
+			allErrs = append(allErrs, field.Required(lokiPath.Child("url"), "URL of Loki is required"))
+		}
+		switch {
+		case loki.Query == "" && !hasSigma:
+			allErrs = append(allErrs, field.Required(lokiPath.Child("query"), "query is required without a Sigma rule"))
+		case loki.Query == "" && loki.Selector == "":
+			allErrs = append(allErrs, field.Required(lokiPath.Child("selector"), "selector is required to translate the Sigma rule"))
+		}
+	}
+
+	return allErrs
+}
+
+// isLocalPath reports whether the slash-separated path is relative and stays within the directory it is relative to.
+func isLocalPath(p string) bool {
+	if path.IsAbs(p) {
+		return false
+	}
+
+	cleaned := path.Clean(p)
+	return cleaned != ".." && !strings.HasPrefix(cleaned, "../")
+}
+
+// validateDatadogMonitor checks that the monitor is referenced by its numeric ID, and expected to transition into a state it can be in.
+func validateDatadogMonitor(monitor DatadogMonitor, substituted bool, path *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	switch {
+	case monitor.ID == "":
+		allErrs = append(allErrs, field.Required(path.Child("id"), "ID of the monitor is required"))
+	case isUnsubstituted(monitor.ID, substituted):
+	default:
+		if _, err := strconv.ParseInt(monitor.ID, 10, 64); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("id"), monitor.ID, "must be the numeric ID of a monitor"))
+		}
+	}
+
+	switch {
+	case monitor.Status == "":
+		allErrs = append(allErrs, field.Required(path.Child("status"), "status the monitor is expected to transition into is required"))
+	case isUnsubstituted(monitor.Status, substituted):
+	default:
+		supported := false
+		for _, status := range datadogMonitorStatuses {
+			if monitor.Status == status {
+				supported = true
+			}
+		}
+		if !supported {
+			allErrs = append(allErrs, field.NotSupported(path.Child("status"), monitor.Status, datadogMonitorStatuses))
+		}
+	}
+
+	return allErrs
+}
+
+// substituteParameters returns the spec with its parameters substituted, so that the values of the parameters are
+// validated where they are used. The spec is returned as is, and false, when its parameters are not valid, which is
+// reported by validateParameters. A spec without parameters is not rendered, so it is returned as is, and true.
+func substituteParameters(spec ScenarioSpec) (ScenarioSpec, bool) {
+	if len(spec.Parameters) == 0 && len(spec.Arguments) == 0 {
+		return spec, true
+	}
+
+	specPath := field.NewPath("spec")
+	values, errs := ParameterValues(spec.Parameters, spec.Arguments, specPath.Child("parameters"), specPath.Child("arguments"))
+	if len(errs) > 0 {
+		return spec, false
+	}
+
+	substituted := spec.DeepCopy()
+	if errs := substituted.SubstituteParameters(values, specPath); len(errs) > 0 {
+		return spec, false
+	}
+
+	return *substituted, true
+}
+
+// isUnsubstituted reports whether the value references parameters that could not be substituted.
+func isUnsubstituted(value string, substituted bool) bool {
+	return !substituted && strings.Contains(value, "{{")
+}
+
+// validateTimeouts checks that the timeout and the deadline are positive durations.
+func validateTimeouts(spec ScenarioSpec, specPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	durations := []struct {
+		name  string
+		value string
+	}{
+		{name: "timeout", value: spec.Timeout},
+		{name: "deadline", value: spec.Deadline},
+	}
+
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+
+		duration, err := time.ParseDuration(d.value)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child(d.name), d.value, err.Error()))
+			continue
+		}
+
+		if duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(specPath.Child(d.name), d.value, "must be a positive duration"))
+		}
+	}
+
+	return allErrs
+}
+
+// validateParameters checks that the arguments match the declared parameters,
+// and that the fields accepting parameters only reference declared ones.
+func validateParameters(spec ScenarioSpec, specPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if len(spec.Parameters) == 0 && len(spec.Arguments) == 0 {
+		return allErrs
+	}
+
+	parametersPath := specPath.Child("parameters")
+	declared := map[string]struct{}{}
+	for i, parameter := range spec.Parameters {
+		if _, ok := declared[parameter.Name]; ok {
+			allErrs = append(allErrs, field.Duplicate(parametersPath.Index(i).Child("name"), parameter.Name))
+		}
+		declared[parameter.Name] = struct{}{}
+	}
+
+	values, errs := ParameterValues(spec.Parameters, spec.Arguments, parametersPath, specPath.Child("arguments"))
+	allErrs = append(allErrs, errs...)
+
+	// parameters without a valid value are still declared, so that they are not reported again where they are referenced
+	for name := range declared {
+		if _, ok := values[name]; !ok {
+			values[name] = ""
+		}
+	}
+
+	rendered := spec.DeepCopy()
+	allErrs = append(allErrs, rendered.SubstituteParameters(values, specPath)...)
+
+	return allErrs
+}
@@ -0,0 +1,317 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+var _ = Describe("Scenario Webhook", func() {
+	newScenario := func(name string, steps []Step) *Scenario {
+		return &Scenario{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: ScenarioSpec{
+				Templates: []Template{
+					{Name: "recon", Container: &corev1.Container{Name: "recon", Image: "alpine"}},
+					{Name: "credential-access", Container: &corev1.Container{Name: "credential-access", Image: "alpine"}},
+				},
+				Steps: steps,
+			},
+		}
+	}
+
+	It("should admit steps forming a DAG", func() {
+		scenario := newScenario("dag", []Step{
+			{Name: "recon", Template: "recon"},
+			{Name: "credential-access", Template: "credential-access", DependsOn: []string{"recon"}},
+		})
+
+		Expect(k8sClient.Create(ctx, scenario)).To(Succeed())
+	})
+
+	It("should reject steps depending on each other in a cycle", func() {
+		scenario := newScenario("cycle", []Step{
+			{Name: "recon", Template: "recon", DependsOn: []string{"credential-access"}},
+			{Name: "credential-access", Template: "credential-access", DependsOn: []string{"recon"}},
+		})
+
+		err := k8sClient.Create(ctx, scenario)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("cycle"))
+	})
+
+	It("should reject steps referencing unknown steps and templates", func() {
+		scenario := newScenario("unknown", []Step{
+			{Name: "recon", Template: "unknown", DependsOn: []string{"missing"}},
+		})
+
+		err := k8sClient.Create(ctx, scenario)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.steps[0].template"))
+		Expect(err.Error()).To(ContainSubstring("spec.steps[0].dependsOn[0]"))
+	})
+
+	It("should reject scenario and step names too long for labels", func() {
+		scenario := newScenario(strings.Repeat("s", 64), []Step{
+			{Name: strings.Repeat("r", 64), Template: "recon"},
+		})
+
+		err := k8sClient.Create(ctx, scenario)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("metadata.name"))
+		Expect(err.Error()).To(ContainSubstring("spec.steps[0].name"))
+	})
+
+	It("should reject steps fanned out to several nodes", func() {
+		scenario := newScenario("fan-out", []Step{
+			{Name: "recon", Template: "recon"},
+		})
+		scenario.Spec.Target = &Target{NodePoolLabel: "cloud.google.com/gke-nodepool"}
+
+		err := k8sClient.Create(ctx, scenario)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.target"))
+	})
+
+	It("should reject an invalid timeout and deadline", func() {
+		scenario := newScenario("timeout", nil)
+		scenario.Spec.Timeout = "ten minutes"
+		scenario.Spec.Deadline = "-1m"
+
+		err := k8sClient.Create(ctx, scenario)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.timeout"))
+		Expect(err.Error()).To(ContainSubstring("spec.deadline"))
+	})
+
+	It("should admit arguments of the declared parameters", func() {
+		scenario := newScenario("parameters", nil)
+		scenario.Spec.Parameters = []Parameter{
+			{Name: "path"},
+			{Name: "count", Type: ParameterTypeInteger, Default: pointer.String("1")},
+		}
+		scenario.Spec.Arguments = map[string]string{"path": "/etc/shadow"}
+		scenario.Spec.Templates[0].Container.Command = []string{"head", "-n", "{{ .Params.count }}", "{{ .Params.path }}"}
+
+		Expect(k8sClient.Create(ctx, scenario)).To(Succeed())
+	})
+
+	It("should admit a literal {{ escaped in a scenario with parameters", func() {
+		scenario := newScenario("escaped-parameters", nil)
+		scenario.Spec.Parameters = []Parameter{{Name: "namespace", Default: pointer.String("default")}}
+		scenario.Spec.Templates[0].Container.Command = []string{"sh", "-c", `kubectl get pods -n {{ .Params.namespace }} -o go-template='{{"{{"}}range .items}}{{"{{"}}.metadata.name}} {{"{{"}}end}}'`}
+
+		Expect(k8sClient.Create(ctx, scenario)).To(Succeed())
+	})
+
+	It("should reject an unescaped literal {{ in a scenario with parameters", func() {
+		scenario := newScenario("unescaped-parameters", nil)
+		scenario.Spec.Parameters = []Parameter{{Name: "namespace", Default: pointer.String("default")}}
+		scenario.Spec.Templates[0].Container.Command = []string{"sh", "-c", `kubectl get pods -n {{ .Params.namespace }} -o go-template='{{range .items}}{{.metadata.name}} {{end}}'`}
+
+		err := k8sClient.Create(ctx, scenario)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.templates[0].container.command[2]"))
+	})
+
+	It("should reject undeclared and missing parameters", func() {
+		scenario := newScenario("invalid-parameters", nil)
+		scenario.Spec.Parameters = []Parameter{
+			{Name: "path"},
+			{Name: "count", Type: ParameterTypeInteger, Default: pointer.String("many")},
+		}
+		scenario.Spec.Arguments = map[string]string{"unknown": "value"}
+		scenario.Spec.Templates[0].Container.Command = []string{"cat", "{{ .Params.file }}"}
+
+		err := k8sClient.Create(ctx, scenario)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.arguments[path]"))
+		Expect(err.Error()).To(ContainSubstring("spec.arguments[unknown]"))
+		Expect(err.Error()).To(ContainSubstring("spec.parameters[1].default"))
+		Expect(err.Error()).To(ContainSubstring("spec.templates[0].container.command[1]"))
+	})
+
+	It("should reject a Stratus Red Team template running a container", func() {
+		scenario := newScenario("stratus", nil)
+		scenario.Spec.Templates[0].Stratus = &StratusTemplate{}
+
+		err := k8sClient.Create(ctx, scenario)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.templates[0].container"))
+		Expect(err.Error()).To(ContainSubstring("spec.templates[0].stratus.technique"))
+	})
+
+	It("should reject steps running Stratus Red Team techniques in parallel", func() {
+		scenario := newScenario("parallel-stratus", []Step{
+			{Name: "recon", Template: "recon"},
+			{Name: "steal-token", Template: "steal-token", DependsOn: []string{"recon"}},
+			{Name: "dump-secrets", Template: "dump-secrets", DependsOn: []string{"recon"}},
+		})
+		scenario.Spec.Templates = append(scenario.Spec.Templates,
+			Template{Name: "steal-token", Stratus: &StratusTemplate{Technique: "k8s.credential-access.steal-serviceaccount-token"}},
+			Template{Name: "dump-secrets", Stratus: &StratusTemplate{Technique: "k8s.credential-access.dump-secrets"}},
+		)
+
+		err := k8sClient.Create(ctx, scenario)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.steps[2].dependsOn"))
+
+		scenario.Spec.Steps[2].DependsOn = []string{"steal-token"}
+		Expect(k8sClient.Create(ctx, scenario)).To(Succeed())
+	})
+
+	It("should reject MITRE ATT&CK IDs in the wrong format", func() {
+		scenario := newScenario("mitre-attack", nil)
+		scenario.Spec.MitreAttack = &MitreAttack{Tactics: []string{"credential-access"}, Techniques: []string{"T1552", "T1552.1"}}
+
+		err := k8sClient.Create(ctx, scenario)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.mitreAttack.tactics[0]"))
+		Expect(err.Error()).To(ContainSubstring("spec.mitreAttack.techniques[1]"))
+		Expect(err.Error()).NotTo(ContainSubstring("spec.mitreAttack.techniques[0]"))
+	})
+
+	It("should reject expectations referencing Sigma rules without a source", func() {
+		scenario := newScenario("sigma", []Step{
+			{Name: "recon", Template: "recon", Expectations: []Expectation{
+				{Loki: &LokiExpectation{URL: "http://loki:3100"}, Sigma: &SigmaRuleRef{ID: "6f1c4a0e-0d3e-4f7e-9d1b-1a2b3c4d5e6f"}},
+			}},
+		})
+		scenario.Spec.Expectations = []Expectation{
+			{Elastic: &ElasticExpectation{URL: "http://elasticsearch:9200", Index: "audit-*"}},
+		}
+
+		err := k8sClient.Create(ctx, scenario)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.expectations[0].elastic.query"))
+		Expect(err.Error()).To(ContainSubstring("spec.steps[0].expectations[0].sigma"))
+		Expect(err.Error()).To(ContainSubstring("spec.steps[0].expectations[0].loki.selector"))
+	})
+
+	It("should reject Sigma rule paths escaping the Sigma rules directory", func() {
+		scenario := newScenario("sigma-path", nil)
+		scenario.Spec.Expectations = []Expectation{
+			{Loki: &LokiExpectation{URL: "http://loki:3100", Selector: `{job="audit"}`}, Sigma: &SigmaRuleRef{ID: "6f1c4a0e-0d3e-4f7e-9d1b-1a2b3c4d5e6f", Path: "/proc/self/environ"}},
+			{Loki: &LokiExpectation{URL: "http://loki:3100", Selector: `{job="audit"}`}, Sigma: &SigmaRuleRef{ID: "6f1c4a0e-0d3e-4f7e-9d1b-1a2b3c4d5e6f", Path: "kubernetes/../../etc"}},
+		}
+
+		err := k8sClient.Create(ctx, scenario)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.expectations[0].sigma.path"))
+		Expect(err.Error()).To(ContainSubstring("spec.expectations[1].sigma.path"))
+
+		scenario.Spec.Expectations = scenario.Spec.Expectations[:1]
+		scenario.Spec.Expectations[0].Sigma.Path = "kubernetes/exec.yml"
+		Expect(k8sClient.Create(ctx, scenario)).To(Succeed())
+	})
+
+	It("should reject a template without container, templateRef nor stratus", func() {
+		scenario := newScenario("no-container", nil)
+		scenario.Spec.Templates[1].Container = nil
+
+		err := k8sClient.Create(ctx, scenario)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.templates[1]"))
+		Expect(err.Error()).To(ContainSubstring("one of container, templateRef and stratus is required"))
+
+		err = scenario.ValidateCreate()
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.templates[1].container"))
+	})
+
+	It("should reject templates with the same name", func() {
+		scenario := newScenario("duplicate-templates", nil)
+		scenario.Spec.Templates[1].Name = "recon"
+
+		err := k8sClient.Create(ctx, scenario)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.templates[1].name"))
+	})
+
+	It("should reject an invalid expectation timeout, monitor ID and monitor status", func() {
+		scenario := newScenario("invalid-monitor", []Step{
+			{Name: "recon", Template: "recon", Expectations: []Expectation{
+				{Datadog: &DatadogExpectation{Monitor: &DatadogMonitor{ID: "12345", Status: "Triggered"}}},
+			}},
+		})
+		scenario.Spec.Expectations = []Expectation{
+			{Timeout: "5 minutes", Datadog: &DatadogExpectation{Monitor: &DatadogMonitor{ID: "cpu-usage", Status: "Alert"}}},
+		}
+
+		err := k8sClient.Create(ctx, scenario)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.expectations[0].timeout"))
+		Expect(err.Error()).To(ContainSubstring("spec.expectations[0].datadog.monitor.id"))
+		Expect(err.Error()).To(ContainSubstring("spec.steps[0].expectations[0].datadog.monitor.status"))
+
+		err = scenario.ValidateCreate()
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(`spec.expectations[0].timeout: Invalid value: "5 minutes"`))
+		Expect(err.Error()).To(ContainSubstring(`spec.expectations[0].datadog.monitor.id: Invalid value: "cpu-usage": must be the numeric ID of a monitor`))
+		Expect(err.Error()).To(ContainSubstring(`spec.steps[0].expectations[0].datadog.monitor.status: Unsupported value: "Triggered"`))
+	})
+
+	It("should validate the monitor ID and status given by parameters once they are substituted", func() {
+		scenario := newScenario("parameterized-monitor", nil)
+		scenario.Spec.Parameters = []Parameter{{Name: "monitor"}, {Name: "status", Default: pointer.String("No Data")}}
+		scenario.Spec.Arguments = map[string]string{"monitor": "12345"}
+		scenario.Spec.Expectations = []Expectation{
+			{Datadog: &DatadogExpectation{Monitor: &DatadogMonitor{ID: "{{ .Params.monitor }}", Status: "{{ .Params.status }}"}}},
+		}
+
+		Expect(k8sClient.Create(ctx, scenario)).To(Succeed())
+
+		scenario = newScenario("invalid-parameterized-monitor", nil)
+		scenario.Spec.Parameters = []Parameter{{Name: "monitor"}}
+		scenario.Spec.Arguments = map[string]string{"monitor": "cpu-usage"}
+		scenario.Spec.Expectations = []Expectation{
+			{Datadog: &DatadogExpectation{Monitor: &DatadogMonitor{ID: "{{ .Params.monitor }}", Status: "Alert"}}},
+		}
+
+		err := k8sClient.Create(ctx, scenario)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(`spec.expectations[0].datadog.monitor.id: Invalid value: "cpu-usage"`))
+	})
+
+	It("should validate the monitor ID and status of a scenario without parameters as they are", func() {
+		scenario := newScenario("unparameterized-monitor", nil)
+		scenario.Spec.Expectations = []Expectation{
+			{Datadog: &DatadogExpectation{Monitor: &DatadogMonitor{ID: "{{ .Params.monitor }}", Status: "Alert"}}},
+		}
+
+		err := k8sClient.Create(ctx, scenario)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring(`spec.expectations[0].datadog.monitor.id: Invalid value: "{{ .Params.monitor }}": must be the numeric ID of a monitor`))
+
+		scenario = newScenario("templated-monitor", nil)
+		scenario.Spec.Expectations = []Expectation{
+			{Datadog: &DatadogExpectation{Monitor: &DatadogMonitor{ID: "12345", Status: "{{ .Status }}"}}},
+		}
+
+		err = k8sClient.Create(ctx, scenario)
+		Expect(apierrors.IsInvalid(err)).To(BeTrue())
+		Expect(err.Error()).To(ContainSubstring("spec.expectations[0].datadog.monitor.status"))
+	})
+})
@@ -0,0 +1,56 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ScenarioTemplateSpec defines an attack that scenarios reference from their templates.
+type ScenarioTemplateSpec struct {
+	// Description describes the attack, e.g. the technique it simulates.
+	Description string `json:"description,omitempty"`
+	// Parameters are the inputs of the attack, substituted as {{ .Params.<name> }} into the image, command, args and env of the container.
+	Parameters []Parameter      `json:"parameters,omitempty"`
+	Container  corev1.Container `json:"container"`
+	// PodTemplate is merged into the pod running the attack, unless the template referencing it sets its own.
+	PodTemplate *PodTemplate `json:"podTemplate,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ScenarioTemplate is the Schema for the scenariotemplates API
+type ScenarioTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ScenarioTemplateSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ScenarioTemplateList contains a list of ScenarioTemplate
+type ScenarioTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ScenarioTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ScenarioTemplate{}, &ScenarioTemplateList{})
+}
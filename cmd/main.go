@@ -26,14 +26,20 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/mrtc0/threatester/internal/application/cleanup"
+	"github.com/mrtc0/threatester/internal/application/coverage"
 	"github.com/mrtc0/threatester/internal/application/expectation"
+	"github.com/mrtc0/threatester/internal/application/report"
 	"github.com/mrtc0/threatester/internal/application/scenario"
+	"github.com/mrtc0/threatester/internal/application/suppression"
 	"github.com/mrtc0/threatester/internal/controller"
 	//+kubebuilder:scaffold:imports
 )
@@ -54,11 +60,15 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var sigmaRulesDir string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&sigmaRulesDir, "sigma-rules-dir", "/etc/threatester/sigma",
+		"The directory the paths of the Sigma rules referenced by the scenarios are relative to. "+
+			"Scenarios cannot read rules outside of it.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -91,18 +101,51 @@ func main() {
 		os.Exit(1)
 	}
 
+	clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		setupLog.Error(err, "unable to create clientset")
+		os.Exit(1)
+	}
+
 	client := mgr.GetClient()
 	if err = (&controller.ScenarioReconciler{
 		Client:              client,
 		Scheme:              mgr.GetScheme(),
 		ExpectationService:  expectation.NewExpectationService(),
-		ScenarioJobExecutor: scenario.NewScenarioJobExecutor(client),
+		ScenarioJobExecutor: scenario.NewScenarioJobExecutor(client, clientset),
+		WorkloadExecutor:    scenario.NewWorkloadExecutor(clientset, mgr.GetConfig()),
+		CleanupService:      cleanup.NewCleanupService(),
+		SuppressionService:  suppression.NewSuppressionService(),
+		SigmaRulesDir:       sigmaRulesDir,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Scenario")
 		os.Exit(1)
 	}
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&threatestergithubiov1alpha1.Scenario{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Scenario")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
+	if err := metrics.Registry.Register(coverage.NewCollector(client)); err != nil {
+		setupLog.Error(err, "unable to register coverage metrics")
+		os.Exit(1)
+	}
+	if err := mgr.AddMetricsExtraHandler(coverage.NavigatorPath, coverage.NewNavigatorHandler(client)); err != nil {
+		setupLog.Error(err, "unable to serve coverage ATT&CK Navigator layer")
+		os.Exit(1)
+	}
+	if err := mgr.AddMetricsExtraHandler(report.JUnitPath, report.NewHandler(client, report.FormatJUnit)); err != nil {
+		setupLog.Error(err, "unable to serve JUnit XML report")
+		os.Exit(1)
+	}
+	if err := mgr.AddMetricsExtraHandler(report.SARIFPath, report.NewHandler(client, report.FormatSARIF)); err != nil {
+		setupLog.Error(err, "unable to serve SARIF report")
+		os.Exit(1)
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
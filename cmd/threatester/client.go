@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(threatestergithubiov1alpha1.AddToScheme(scheme))
+}
+
+// kubeFlags are the flags selecting the cluster and the namespace of the commands talking to Kubernetes.
+type kubeFlags struct {
+	kubeconfig    *string
+	context       *string
+	namespace     *string
+	allNamespaces *bool
+}
+
+func addKubeFlags(flags *flag.FlagSet, allNamespaces bool) kubeFlags {
+	k := kubeFlags{
+		kubeconfig: flags.String("kubeconfig", "", "path to the kubeconfig file (default: $KUBECONFIG or ~/.kube/config)"),
+		context:    flags.String("context", "", "kubeconfig context to use"),
+		namespace:  flags.String("namespace", "", "namespace of the scenarios (default: the namespace of the context)"),
+	}
+	if allNamespaces {
+		k.allNamespaces = flags.Bool("all-namespaces", false, "use the scenarios of all namespaces")
+	}
+
+	return k
+}
+
+// config returns the REST config of the cluster and the namespace to use, which is empty for all namespaces.
+func (k kubeFlags) config() (*rest.Config, string, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = *k.kubeconfig
+	config := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{
+		CurrentContext: *k.context,
+		Context:        clientcmdapi.Context{Namespace: *k.namespace},
+	})
+
+	restConfig, err := config.ClientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if k.allNamespaces != nil && *k.allNamespaces {
+		return restConfig, "", nil
+	}
+
+	namespace, _, err := config.Namespace()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return restConfig, namespace, nil
+}
+
+// client returns a client of the cluster and the namespace to use, which is empty for all namespaces.
+func (k kubeFlags) client() (client.Client, string, error) {
+	restConfig, namespace, err := k.config()
+	if err != nil {
+		return nil, "", err
+	}
+
+	c, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return c, namespace, nil
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/mrtc0/threatester/internal/application/report"
+)
+
+// terminalStatuses are the statuses of a scenario whose run has ended.
+var terminalStatuses = []string{"Succeeded", "Failed", "Degraded", "TimedOut", "Cancelled", "Planned"}
+
+func isTerminal(status string) bool {
+	for _, terminal := range terminalStatuses {
+		if status == terminal {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isSuccessful reports whether the run succeeded, or the dry run rendered a valid plan.
+func isSuccessful(status string) bool {
+	return status == "Succeeded" || status == "Planned"
+}
+
+// runGet lists the scenarios with the status and the expectation results of their latest run.
+func runGet(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("get", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: threatester get [flags] [scenario]...")
+		flags.PrintDefaults()
+	}
+
+	kube := addKubeFlags(flags, true)
+	selector := flags.String("selector", "", "label selector of the scenarios")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	labelSelector, err := labels.Parse(*selector)
+	if err != nil {
+		return fmt.Errorf("invalid selector: %w", err)
+	}
+
+	c, namespace, err := kube.client()
+	if err != nil {
+		return err
+	}
+
+	scenarios, err := report.Load(context.Background(), c, namespace, labelSelector)
+	if err != nil {
+		return err
+	}
+
+	scenarios, err = filterScenarios(scenarios, flags.Args())
+	if err != nil {
+		return err
+	}
+
+	return printScenarios(stdout, scenarios, namespace == "")
+}
+
+// printScenarios writes a row for each scenario, with its namespace when the scenarios of all namespaces are listed.
+func printScenarios(stdout io.Writer, scenarios []threatestergithubiov1alpha1.Scenario, allNamespaces bool) error {
+	w := tabwriter.NewWriter(stdout, 0, 8, 2, ' ', 0)
+	header := "NAME\tSTATUS\tEXPECTATIONS\tSTARTED"
+	if allNamespaces {
+		header = "NAMESPACE\t" + header
+	}
+	fmt.Fprintln(w, header)
+
+	for _, scenario := range scenarios {
+		row := fmt.Sprintf("%s\t%s\t%s\t%s", scenario.Name, valueOrNone(scenario.Status.Status), summarizeCases(report.Cases(scenario)), age(scenario.Status.StartTime))
+		if allNamespaces {
+			row = scenario.Namespace + "\t" + row
+		}
+		fmt.Fprintln(w, row)
+	}
+
+	return w.Flush()
+}
+
+// runDescribe shows the status of a scenario, with the results of its steps, expectations and containers.
+func runDescribe(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("describe", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: threatester describe [flags] <scenario>")
+		flags.PrintDefaults()
+	}
+
+	kube := addKubeFlags(flags, false)
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("a scenario name is required")
+	}
+
+	c, namespace, err := kube.client()
+	if err != nil {
+		return err
+	}
+
+	scenario := &threatestergithubiov1alpha1.Scenario{}
+	if err := c.Get(context.Background(), types.NamespacedName{Name: flags.Arg(0), Namespace: namespace}, scenario); err != nil {
+		return err
+	}
+
+	return describeScenario(stdout, *scenario)
+}
+
+// describeScenario writes the status of the latest run of the scenario.
+func describeScenario(stdout io.Writer, scenario threatestergithubiov1alpha1.Scenario) error {
+	w := tabwriter.NewWriter(stdout, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintf(w, "Name:\t%s\n", scenario.Name)
+	fmt.Fprintf(w, "Namespace:\t%s\n", scenario.Namespace)
+	fmt.Fprintf(w, "Status:\t%s\n", valueOrNone(scenario.Status.Status))
+	if condition := meta.FindStatusCondition(scenario.Status.Conditions, scenario.Status.Status); condition != nil && condition.Message != "" {
+		fmt.Fprintf(w, "Message:\t%s\n", condition.Message)
+	}
+	if scenario.Status.StartTime != nil {
+		fmt.Fprintf(w, "Started:\t%s (%s ago)\n", scenario.Status.StartTime.UTC().Format(time.RFC3339), age(scenario.Status.StartTime))
+	}
+	if scenario.Status.Result.Duration > 0 {
+		fmt.Fprintf(w, "Expectations duration:\t%s\n", scenario.Status.Result.Duration.Round(time.Second))
+	}
+	if scenario.Status.CleanupTime != nil {
+		fmt.Fprintf(w, "Cleaned up:\t%s\n", scenario.Status.CleanupTime.UTC().Format(time.RFC3339))
+	}
+	if attack := scenario.Spec.MitreAttack; attack != nil {
+		fmt.Fprintf(w, "MITRE ATT&CK:\t%s\n", strings.Join(append(append([]string{}, attack.Tactics...), attack.Techniques...), ", "))
+	}
+
+	if len(scenario.Status.Steps) > 0 {
+		fmt.Fprintln(w, "\nSteps:")
+		fmt.Fprintln(w, "  NAME\tPHASE\tMESSAGE")
+		for _, step := range scenario.Status.Steps {
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", step.Name, valueOrNone(string(step.Phase)), step.Message)
+		}
+	}
+
+	fmt.Fprintln(w, "\nExpectations:")
+	fmt.Fprintln(w, "  RESULT\tEXPECTATION\tMESSAGE")
+	for _, c := range report.Cases(scenario) {
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", c.Status, c.Name, c.Message)
+	}
+
+	if len(scenario.Status.Containers) > 0 {
+		fmt.Fprintln(w, "\nContainers:")
+		fmt.Fprintln(w, "  POD\tCONTAINER\tEXIT CODE\tREASON")
+		for _, container := range scenario.Status.Containers {
+			exitCode := "<none>"
+			if container.ExitCode != nil {
+				exitCode = fmt.Sprint(*container.ExitCode)
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", container.Pod, container.Container, exitCode, container.Reason)
+		}
+	}
+
+	if plan := scenario.Status.Plan; plan != nil {
+		if len(plan.Parameters) > 0 {
+			names := []string{}
+			for name := range plan.Parameters {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			fmt.Fprintln(w, "\nParameters:")
+			for _, name := range names {
+				fmt.Fprintf(w, "  %s:\t%s\n", name, plan.Parameters[name])
+			}
+		}
+
+		fmt.Fprintln(w, "\nPlanned resources:")
+		fmt.Fprintln(w, "  KIND\tNAME\tSTEP\tNODE\tSERVICE ACCOUNT")
+		for _, resource := range plan.Resources {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", resource.Kind, resource.Name, resource.Step, resource.Node, valueOrNone(resource.ServiceAccountName))
+		}
+
+		fmt.Fprintln(w, "\nPlanned expectations:")
+		fmt.Fprintln(w, "  VALID\tEXPECTATION\tMESSAGE")
+		for _, planned := range plan.Expectations {
+			name := report.Describe(planned.Expectation)
+			if planned.Step != "" {
+				name = fmt.Sprintf("%s: %s", planned.Step, name)
+			}
+			fmt.Fprintf(w, "  %t\t%s\t%s\n", planned.Valid, name, planned.Message)
+		}
+	}
+
+	if len(scenario.Status.SigmaRules) > 0 {
+		fmt.Fprintln(w, "\nSigma rules:")
+		fmt.Fprintln(w, "  ID\tTITLE\tMODIFIED\tVALIDATED")
+		for _, rule := range scenario.Status.SigmaRules {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%t\n", rule.ID, rule.Title, rule.Modified, rule.Validated)
+		}
+	}
+
+	return w.Flush()
+}
+
+// printManifests writes the manifests of the resources planned for the scenario as a multi-document YAML stream.
+func printManifests(stdout io.Writer, scenario threatestergithubiov1alpha1.Scenario) {
+	if scenario.Status.Plan == nil {
+		return
+	}
+
+	for _, resource := range scenario.Status.Plan.Resources {
+		fmt.Fprintf(stdout, "---\n# %s %s\n%s", resource.Kind, resource.Name, resource.Manifest)
+	}
+}
+
+// summarizeCases counts the cases by status, e.g. "1 passed, 1 failed".
+func summarizeCases(cases []report.Case) string {
+	counts := map[report.CaseStatus]int{}
+	for _, c := range cases {
+		counts[c.Status]++
+	}
+
+	summary := []string{}
+	for _, status := range []report.CaseStatus{report.CasePassed, report.CaseFailed, report.CaseSkipped} {
+		if counts[status] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+
+	return strings.Join(summary, ", ")
+}
+
+// age returns how long ago the time was, the way kubectl shows it.
+func age(t *metav1.Time) string {
+	if t == nil {
+		return "<none>"
+	}
+
+	return duration.HumanDuration(time.Since(t.Time))
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+
+	return value
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+)
+
+func TestIsTerminal(t *testing.T) {
+	testCases := []struct {
+		status     string
+		terminal   bool
+		successful bool
+	}{
+		{status: "Succeeded", terminal: true, successful: true},
+		{status: "Planned", terminal: true, successful: true},
+		{status: "Failed", terminal: true, successful: false},
+		{status: "Degraded", terminal: true, successful: false},
+		{status: "TimedOut", terminal: true, successful: false},
+		{status: "Cancelled", terminal: true, successful: false},
+		{status: "Progressing", terminal: false, successful: false},
+		{status: "", terminal: false, successful: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(valueOrNone(tc.status), func(t *testing.T) {
+			if got := isTerminal(tc.status); got != tc.terminal {
+				t.Errorf("expected terminal %t, got %t", tc.terminal, got)
+			}
+
+			if got := isSuccessful(tc.status); got != tc.successful {
+				t.Errorf("expected successful %t, got %t", tc.successful, got)
+			}
+		})
+	}
+}
+
+func TestPrintScenarios(t *testing.T) {
+	scenarios := []threatestergithubiov1alpha1.Scenario{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "reverse-shell", Namespace: "default"},
+			Status: threatestergithubiov1alpha1.ScenarioStatus{
+				Status: "Failed",
+				Result: threatestergithubiov1alpha1.ExpectationResult{
+					SucceededExpectations: []threatestergithubiov1alpha1.Expectation{{Timeout: "1m"}},
+					FailedExpectations:    []threatestergithubiov1alpha1.FailedExpectation{{Reason: "no logs"}},
+				},
+			},
+		},
+		{ObjectMeta: metav1.ObjectMeta{Name: "recon", Namespace: "attacks"}},
+	}
+
+	testCases := []struct {
+		name          string
+		allNamespaces bool
+		expected      []string
+	}{
+		{
+			name: "namespace",
+			expected: []string{
+				"NAME           STATUS  EXPECTATIONS        STARTED",
+				"reverse-shell  Failed  1 passed, 1 failed  <none>",
+				"recon          <none>  1 skipped           <none>",
+			},
+		},
+		{
+			name:          "all namespaces",
+			allNamespaces: true,
+			expected: []string{
+				"NAMESPACE  NAME           STATUS  EXPECTATIONS        STARTED",
+				"default    reverse-shell  Failed  1 passed, 1 failed  <none>",
+				"attacks    recon          <none>  1 skipped           <none>",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			if err := printScenarios(&stdout, scenarios, tc.allNamespaces); err != nil {
+				t.Fatal(err)
+			}
+
+			expected := strings.Join(tc.expected, "\n") + "\n"
+			if stdout.String() != expected {
+				t.Errorf("expected\n%s\ngot\n%s", expected, stdout.String())
+			}
+		})
+	}
+}
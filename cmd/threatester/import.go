@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/mrtc0/threatester/internal/atomic"
+)
+
+// atomicFilePattern matches the technique files of Atomic Red Team, e.g. T1552.001.yaml.
+var atomicFilePattern = regexp.MustCompile(`^T\d{4}(\.\d{3})?\.ya?ml$`)
+
+func runImport(args []string, stdout io.Writer, stderr io.Writer) error {
+	if len(args) == 0 || args[0] != "atomic" {
+		return fmt.Errorf("usage: threatester import atomic [flags] <path>...")
+	}
+
+	return runImportAtomic(args[1:], stdout, stderr)
+}
+
+// runImportAtomic writes the manifests of the Atomic Red Team tests found in the paths to stdout.
+// The paths are technique files, or directories such as the atomics directory of Atomic Red Team.
+func runImportAtomic(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("import atomic", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: threatester import atomic [flags] <path>...")
+		flags.PrintDefaults()
+	}
+
+	kind := flags.String("kind", "ScenarioTemplate", "kind of the manifests: Scenario, ScenarioTemplate or ClusterScenarioTemplate")
+	image := flags.String("image", atomic.DefaultImage, "image running the tests")
+	namespace := flags.String("namespace", "", "namespace of the manifests")
+	platforms := flags.String("platforms", strings.Join(atomic.DefaultPlatforms, ","), "comma-separated platforms of the tests to import")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("no path is given")
+	}
+
+	switch *kind {
+	case "Scenario", "ScenarioTemplate", "ClusterScenarioTemplate":
+	default:
+		return fmt.Errorf("unknown kind %q", *kind)
+	}
+
+	options := atomic.Options{
+		Image:     *image,
+		Namespace: *namespace,
+		Platforms: strings.Split(*platforms, ","),
+	}
+
+	files, err := findAtomicFiles(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		technique, err := atomic.Parse(data)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+
+		for i, test := range technique.AtomicTests {
+			if err := test.Supported(options.Platforms); err != nil {
+				fmt.Fprintf(stderr, "skip %s (%s): %s\n", atomic.Name(*technique, i), test.Name, err)
+				continue
+			}
+
+			var manifest interface{}
+			if *kind == "Scenario" {
+				manifest, err = atomic.Scenario(*technique, i, options)
+			} else {
+				manifest, err = atomic.ScenarioTemplate(*technique, i, options, *kind == "ClusterScenarioTemplate")
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := writeManifest(stdout, manifest); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// findAtomicFiles returns the technique files in the paths, walking the directories.
+func findAtomicFiles(paths []string) ([]string, error) {
+	files := []string{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !entry.IsDir() && atomicFilePattern.MatchString(entry.Name()) {
+				files = append(files, path)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// writeManifest writes the manifest as a YAML document.
+func writeManifest(w io.Writer, manifest interface{}) error {
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "---\n%s", data)
+	return err
+}
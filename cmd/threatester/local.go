@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/mrtc0/threatester/internal/application/cleanup"
+	"github.com/mrtc0/threatester/internal/application/expectation"
+	"github.com/mrtc0/threatester/internal/application/local"
+	"github.com/mrtc0/threatester/internal/application/scenario"
+)
+
+// runLocal runs the scenario of the manifest file in-process against the cluster, without the controller,
+// and exits with 1 when it did not succeed. Interrupting the run cleans it up.
+// A dry run prints the plan of the scenario instead, and exits with 1 when one of its expectations is not valid.
+func runLocal(file string, kube kubeFlags, dryRun bool, sigmaRulesDir string, stdout io.Writer) error {
+	scenarios, err := readScenarios(file)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	if len(scenarios) != 1 {
+		return fmt.Errorf("%s must hold a single scenario in local mode, got %d", file, len(scenarios))
+	}
+
+	restConfig, namespace, err := kube.config()
+	if err != nil {
+		return err
+	}
+
+	c, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+
+	s := scenarios[0]
+	if s.Namespace == "" {
+		s.Namespace = namespace
+	}
+	if dryRun {
+		s.Spec.DryRun = true
+	}
+
+	runner := &local.Runner{
+		Client:              c,
+		ScenarioJobExecutor: scenario.NewScenarioJobExecutor(c, clientset),
+		ExpectationService:  expectation.NewExpectationService(),
+		CleanupService:      cleanup.NewCleanupService(),
+		SigmaRulesDir:       sigmaRulesDir,
+		Out:                 stdout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	result, err := runner.Run(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(stdout)
+	if err := describeScenario(stdout, *result); err != nil {
+		return err
+	}
+
+	if result.Spec.DryRun {
+		printManifests(stdout, *result)
+	}
+
+	if !isSuccessful(result.Status.Status) {
+		return errFailed
+	}
+
+	return nil
+}
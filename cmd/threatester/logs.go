@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/mrtc0/threatester/internal/application/scenario"
+)
+
+// runLogs prints the logs of the containers of the scenario pods, prefixed with the pod and the container.
+// Once the pods are deleted, the logs recorded in the status of the scenario and its logs ConfigMap are printed.
+func runLogs(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("logs", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: threatester logs [flags] <scenario>")
+		flags.PrintDefaults()
+	}
+
+	kube := addKubeFlags(flags, false)
+	follow := flags.Bool("follow", false, "stream the logs of the running pods")
+	container := flags.String("container", "", "only print the logs of the containers with this name")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("a scenario name is required")
+	}
+
+	restConfig, namespace, err := kube.config()
+	if err != nil {
+		return err
+	}
+
+	c, err := client.New(restConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		return err
+	}
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	s := &threatestergithubiov1alpha1.Scenario{}
+	if err := c.Get(ctx, types.NamespacedName{Name: flags.Arg(0), Namespace: namespace}, s); err != nil {
+		return err
+	}
+
+	pods := &corev1.PodList{}
+	if err := c.List(ctx, pods, client.InNamespace(namespace), client.MatchingLabels{scenario.ScenarioLabel: s.Name}); err != nil {
+		return err
+	}
+
+	if len(pods.Items) == 0 {
+		return printRecordedLogs(ctx, c, *s, *container, stdout)
+	}
+
+	for _, pod := range pods.Items {
+		containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+		for _, podContainer := range containers {
+			if *container != "" && podContainer.Name != *container {
+				continue
+			}
+
+			if err := streamLogs(ctx, clientset, pod, podContainer.Name, *follow, stdout); err != nil {
+				fmt.Fprintf(stderr, "failed to get logs of container %s of pod %s: %s\n", podContainer.Name, pod.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func streamLogs(ctx context.Context, clientset kubernetes.Interface, pod corev1.Pod, container string, follow bool, stdout io.Writer) error {
+	stream, err := clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: container, Follow: follow}).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	return printLines(stream, fmt.Sprintf("[%s/%s]", pod.Name, container), stdout)
+}
+
+// printRecordedLogs prints the logs of the containers recorded in the status of the scenario,
+// or the longer tail stored in its logs ConfigMap.
+func printRecordedLogs(ctx context.Context, c client.Client, s threatestergithubiov1alpha1.Scenario, container string, stdout io.Writer) error {
+	if len(s.Status.Containers) == 0 {
+		return fmt.Errorf("scenario %s has no pods nor recorded logs", s.Name)
+	}
+
+	for _, result := range s.Status.Containers {
+		if container != "" && result.Container != container {
+			continue
+		}
+
+		logs := result.Logs
+		if result.LogsConfigMap != "" {
+			configMap := &corev1.ConfigMap{}
+			if err := c.Get(ctx, types.NamespacedName{Name: result.LogsConfigMap, Namespace: s.Namespace}, configMap); err != nil {
+				return err
+			}
+
+			if stored, ok := configMap.Data[scenario.LogsConfigMapKey(result)]; ok {
+				logs = stored
+			}
+		}
+
+		if err := printLines(strings.NewReader(logs), fmt.Sprintf("[%s/%s]", result.Pod, result.Container), stdout); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func printLines(r io.Reader, prefix string, stdout io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fmt.Fprintf(stdout, "%s %s\n", prefix, scanner.Text())
+	}
+
+	return scanner.Err()
+}
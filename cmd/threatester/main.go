@@ -0,0 +1,91 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// threatester is the command line interface of threatester.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `Usage: threatester <command> [flags]
+
+Commands:
+  run            Apply scenarios and wait for their runs to end
+  rerun          Run scenarios again and wait for their runs to end
+  get            List scenarios with the results of their latest run
+  describe       Show the status of a scenario and the results of its expectations
+  logs           Print the logs of the pods of a scenario
+  report         Write the results of the scenarios as a JUnit XML or SARIF report
+  import atomic  Import Atomic Red Team tests as scenarios or scenario templates
+
+Run 'threatester <command> -h' for the flags of a command.
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	var err error
+	switch args[0] {
+	case "run":
+		err = runRun(args[1:], stdout, stderr)
+	case "rerun":
+		err = runRerun(args[1:], stdout, stderr)
+	case "get":
+		err = runGet(args[1:], stdout, stderr)
+	case "describe":
+		err = runDescribe(args[1:], stdout, stderr)
+	case "logs":
+		err = runLogs(args[1:], stdout, stderr)
+	case "import":
+		err = runImport(args[1:], stdout, stderr)
+	case "report":
+		err = runReport(args[1:], stdout, stderr)
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+
+	// the failures are already reported by the command
+	if errors.Is(err, errFailed) {
+		return 1
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRunExitCode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		args     []string
+		exitCode int
+	}{
+		{name: "no command", args: []string{}, exitCode: 2},
+		{name: "unknown command", args: []string{"apply"}, exitCode: 2},
+		{name: "help", args: []string{"help"}, exitCode: 0},
+		{name: "help of a command", args: []string{"get", "-h"}, exitCode: 0},
+		{name: "invalid flag", args: []string{"get", "--unknown"}, exitCode: 1},
+		{name: "missing argument", args: []string{"describe", "--kubeconfig", "/nonexistent"}, exitCode: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if got := run(tc.args, &stdout, &stderr); got != tc.exitCode {
+				t.Errorf("expected exit code %d, got %d: %s", tc.exitCode, got, stderr.String())
+			}
+		})
+	}
+}
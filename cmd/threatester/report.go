@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/mrtc0/threatester/internal/application/report"
+)
+
+// errFailed is returned by the commands whose scenarios did not pass, to exit with a non-zero code.
+var errFailed = errors.New("scenarios failed")
+
+// runReport writes the report of the results of the scenarios in the cluster.
+func runReport(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("report", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: threatester report [flags] [scenario]...")
+		flags.PrintDefaults()
+	}
+
+	kube := addKubeFlags(flags, true)
+	format := flags.String("format", string(report.FormatJUnit), "format of the report: junit or sarif")
+	selector := flags.String("selector", "", "label selector of the scenarios")
+	output := flags.String("output", "", "file to write the report to (default: stdout)")
+	fail := flags.Bool("fail", false, "exit with 1 when an expectation of the scenarios failed")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	labelSelector, err := labels.Parse(*selector)
+	if err != nil {
+		return fmt.Errorf("invalid selector: %w", err)
+	}
+
+	c, namespace, err := kube.client()
+	if err != nil {
+		return err
+	}
+
+	scenarios, err := report.Load(context.Background(), c, namespace, labelSelector)
+	if err != nil {
+		return err
+	}
+
+	scenarios, err = filterScenarios(scenarios, flags.Args())
+	if err != nil {
+		return err
+	}
+
+	w := stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if err := report.Write(w, report.Format(*format), scenarios); err != nil {
+		return err
+	}
+
+	if *fail && report.Failed(scenarios) {
+		return errFailed
+	}
+
+	return nil
+}
+
+// filterScenarios returns the scenarios with the names, or all scenarios when no name is given.
+func filterScenarios(scenarios []threatestergithubiov1alpha1.Scenario, names []string) ([]threatestergithubiov1alpha1.Scenario, error) {
+	if len(names) == 0 {
+		return scenarios, nil
+	}
+
+	filtered := []threatestergithubiov1alpha1.Scenario{}
+	for _, name := range names {
+		found := false
+		for _, scenario := range scenarios {
+			if scenario.Name == name {
+				filtered = append(filtered, scenario)
+				found = true
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("scenario %q not found", name)
+		}
+	}
+
+	return filtered, nil
+}
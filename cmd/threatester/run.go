@@ -0,0 +1,327 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/mrtc0/threatester/internal/application/scenario"
+)
+
+// pollInterval is how often the progress of a scenario is checked.
+const pollInterval = 2 * time.Second
+
+// runRun applies the scenarios of the manifest files and waits for their runs to end.
+func runRun(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: threatester run [flags] <file>...")
+		flags.PrintDefaults()
+	}
+
+	kube := addKubeFlags(flags, false)
+	wait := flags.Bool("wait", true, "wait for the runs to end, and exit with 1 when one of them did not succeed")
+	timeout := flags.Duration("timeout", 0, "how long to wait for the runs to end (default: no limit)")
+	localMode := flags.Bool("local", false, "run the scenario of a single file in-process, without the controller")
+	dryRun := flags.Bool("dry-run", false, "render the plan of the scenarios and validate their expectations, without running them")
+	sigmaRulesDir := flags.String("sigma-rules-dir", ".", "the directory the paths of the Sigma rules are relative to in local mode")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("no file is given")
+	}
+
+	if *localMode {
+		if flags.NArg() != 1 {
+			return fmt.Errorf("local mode runs a single file")
+		}
+		return runLocal(flags.Arg(0), kube, *dryRun, *sigmaRulesDir, stdout)
+	}
+
+	scenarios := []threatestergithubiov1alpha1.Scenario{}
+	for _, file := range flags.Args() {
+		parsed, err := readScenarios(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		scenarios = append(scenarios, parsed...)
+	}
+
+	if *dryRun {
+		for i := range scenarios {
+			scenarios[i].Spec.DryRun = true
+		}
+	}
+
+	c, namespace, err := kube.client()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	request := newRerunRequest()
+	keys := []types.NamespacedName{}
+	for i := range scenarios {
+		if scenarios[i].Namespace == "" {
+			scenarios[i].Namespace = namespace
+		}
+
+		if err := applyScenario(ctx, c, &scenarios[i], request); err != nil {
+			return fmt.Errorf("failed to apply scenario %s: %w", scenarios[i].Name, err)
+		}
+		fmt.Fprintf(stdout, "scenario %s/%s applied\n", scenarios[i].Namespace, scenarios[i].Name)
+
+		keys = append(keys, client.ObjectKeyFromObject(&scenarios[i]))
+	}
+
+	if !*wait {
+		return nil
+	}
+
+	return waitForScenarios(ctx, c, keys, request, *timeout, stdout)
+}
+
+// runRerun requests the scenarios to run again and waits for their runs to end.
+func runRerun(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("rerun", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: threatester rerun [flags] <scenario>...")
+		flags.PrintDefaults()
+	}
+
+	kube := addKubeFlags(flags, false)
+	wait := flags.Bool("wait", true, "wait for the runs to end, and exit with 1 when one of them did not succeed")
+	timeout := flags.Duration("timeout", 0, "how long to wait for the runs to end (default: no limit)")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("no scenario is given")
+	}
+
+	c, namespace, err := kube.client()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	request := newRerunRequest()
+	keys := []types.NamespacedName{}
+	for _, name := range flags.Args() {
+		key := types.NamespacedName{Name: name, Namespace: namespace}
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			existing := &threatestergithubiov1alpha1.Scenario{}
+			if err := c.Get(ctx, key, existing); err != nil {
+				return err
+			}
+
+			requestRerun(existing, request)
+			return c.Update(ctx, existing)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to rerun scenario %s: %w", name, err)
+		}
+		fmt.Fprintf(stdout, "scenario %s rerun requested\n", key)
+
+		keys = append(keys, key)
+	}
+
+	if !*wait {
+		return nil
+	}
+
+	return waitForScenarios(ctx, c, keys, request, *timeout, stdout)
+}
+
+// readScenarios parses the Scenario manifests of a YAML or JSON file, which may hold several documents.
+func readScenarios(path string) ([]threatestergithubiov1alpha1.Scenario, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scenarios := []threatestergithubiov1alpha1.Scenario{}
+	decoder := utilyaml.NewYAMLOrJSONDecoder(file, 4096)
+	for {
+		s := threatestergithubiov1alpha1.Scenario{}
+		if err := decoder.Decode(&s); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+
+		// skip empty documents
+		if s.Kind == "" && s.Name == "" {
+			continue
+		}
+
+		if s.Kind != "Scenario" {
+			return nil, fmt.Errorf("%s %s is not a Scenario", s.Kind, s.Name)
+		}
+
+		scenarios = append(scenarios, s)
+	}
+
+	return scenarios, nil
+}
+
+// applyScenario creates the scenario, or updates the existing one, with the rerun request.
+func applyScenario(ctx context.Context, c client.Client, s *threatestergithubiov1alpha1.Scenario, request string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		existing := &threatestergithubiov1alpha1.Scenario{}
+		err := c.Get(ctx, client.ObjectKeyFromObject(s), existing)
+		if apierrors.IsNotFound(err) {
+			created := s.DeepCopy()
+			requestRerun(created, request)
+			return c.Create(ctx, created)
+		}
+		if err != nil {
+			return err
+		}
+
+		existing.Spec = s.Spec
+		if existing.Labels == nil {
+			existing.Labels = map[string]string{}
+		}
+		for k, v := range s.Labels {
+			existing.Labels[k] = v
+		}
+		if existing.Annotations == nil {
+			existing.Annotations = map[string]string{}
+		}
+		for k, v := range s.Annotations {
+			existing.Annotations[k] = v
+		}
+		requestRerun(existing, request)
+
+		return c.Update(ctx, existing)
+	})
+}
+
+// requestRerun annotates the scenario to run again, and withdraws the cancellation of its previous run.
+func requestRerun(s *threatestergithubiov1alpha1.Scenario, request string) {
+	if s.Annotations == nil {
+		s.Annotations = map[string]string{}
+	}
+
+	s.Annotations[scenario.RerunAnnotation] = request
+	delete(s.Annotations, scenario.CancelAnnotation)
+}
+
+func newRerunRequest() string {
+	return time.Now().UTC().Format(time.RFC3339Nano)
+}
+
+// waitForScenarios reports the progress of the runs of the scenarios requested with the rerun request until they end.
+// The manifests planned by dry runs are printed along with them.
+// It returns errFailed when one of them did not succeed.
+func waitForScenarios(ctx context.Context, c client.Client, keys []types.NamespacedName, request string, timeout time.Duration, stdout io.Writer) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	progress := map[types.NamespacedName]string{}
+	done := map[types.NamespacedName]*threatestergithubiov1alpha1.Scenario{}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for len(done) < len(keys) {
+		for _, key := range keys {
+			if _, ok := done[key]; ok {
+				continue
+			}
+
+			s := &threatestergithubiov1alpha1.Scenario{}
+			if err := c.Get(ctx, key, s); err != nil {
+				return err
+			}
+
+			// the status belongs to the previous run until the controller picks up the request
+			if s.Status.RerunRequest != request {
+				continue
+			}
+
+			if line := describeProgress(*s); line != progress[key] {
+				fmt.Fprintf(stdout, "%s: %s\n", key, line)
+				progress[key] = line
+			}
+
+			if isTerminal(s.Status.Status) {
+				done[key] = s
+			}
+		}
+
+		if len(done) == len(keys) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("scenarios did not end within %s", timeout)
+		case <-ticker.C:
+		}
+	}
+
+	failed := false
+	for _, key := range keys {
+		s := done[key]
+		fmt.Fprintln(stdout)
+		if err := describeScenario(stdout, *s); err != nil {
+			return err
+		}
+
+		if s.Spec.DryRun {
+			printManifests(stdout, *s)
+		}
+
+		if !isSuccessful(s.Status.Status) {
+			failed = true
+		}
+	}
+
+	if failed {
+		return errFailed
+	}
+
+	return nil
+}
+
+// describeProgress returns a line describing the status of the scenario and of its steps.
+func describeProgress(s threatestergithubiov1alpha1.Scenario) string {
+	line := valueOrNone(s.Status.Status)
+	if condition := meta.FindStatusCondition(s.Status.Conditions, s.Status.Status); condition != nil && condition.Message != "" {
+		line = fmt.Sprintf("%s (%s)", line, condition.Message)
+	}
+
+	for _, step := range s.Status.Steps {
+		line = fmt.Sprintf("%s, step %s %s", line, step.Name, valueOrNone(string(step.Phase)))
+	}
+
+	return line
+}
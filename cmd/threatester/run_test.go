@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/mrtc0/threatester/internal/application/scenario"
+)
+
+func TestRequestRerun(t *testing.T) {
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    map[string]string
+	}{
+		{
+			name:     "no annotations",
+			expected: map[string]string{scenario.RerunAnnotation: "2023-01-02T00:00:00Z"},
+		},
+		{
+			name:        "previous request",
+			annotations: map[string]string{scenario.RerunAnnotation: "2023-01-01T00:00:00Z", "team": "red"},
+			expected:    map[string]string{scenario.RerunAnnotation: "2023-01-02T00:00:00Z", "team": "red"},
+		},
+		{
+			name:        "cancelled run",
+			annotations: map[string]string{scenario.CancelAnnotation: "true"},
+			expected:    map[string]string{scenario.RerunAnnotation: "2023-01-02T00:00:00Z"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &threatestergithubiov1alpha1.Scenario{ObjectMeta: metav1.ObjectMeta{Name: "recon", Annotations: tc.annotations}}
+
+			requestRerun(s, "2023-01-02T00:00:00Z")
+
+			if len(s.Annotations) != len(tc.expected) {
+				t.Fatalf("expected annotations %v, got %v", tc.expected, s.Annotations)
+			}
+			for k, v := range tc.expected {
+				if s.Annotations[k] != v {
+					t.Errorf("expected annotation %s=%s, got %v", k, v, s.Annotations)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyScenario(t *testing.T) {
+	existing := &threatestergithubiov1alpha1.Scenario{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "existing",
+			Namespace:   "default",
+			Labels:      map[string]string{"team": "blue"},
+			Annotations: map[string]string{scenario.CancelAnnotation: "true"},
+		},
+		Spec: threatestergithubiov1alpha1.ScenarioSpec{Timeout: "1m"},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(existing).Build()
+
+	testCases := []struct {
+		name string
+		key  types.NamespacedName
+	}{
+		{name: "new scenario", key: types.NamespacedName{Name: "new", Namespace: "default"}},
+		{name: "existing scenario", key: types.NamespacedName{Name: "existing", Namespace: "default"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &threatestergithubiov1alpha1.Scenario{
+				ObjectMeta: metav1.ObjectMeta{Name: tc.key.Name, Namespace: tc.key.Namespace, Labels: map[string]string{"team": "red"}},
+				Spec:       threatestergithubiov1alpha1.ScenarioSpec{Timeout: "5m"},
+			}
+
+			if err := applyScenario(context.Background(), c, s, "request"); err != nil {
+				t.Fatal(err)
+			}
+
+			applied := &threatestergithubiov1alpha1.Scenario{}
+			if err := c.Get(context.Background(), tc.key, applied); err != nil {
+				t.Fatal(err)
+			}
+
+			if applied.Spec.Timeout != "5m" || applied.Labels["team"] != "red" {
+				t.Errorf("expected the spec and labels of the manifest, got %v and %v", applied.Spec, applied.Labels)
+			}
+
+			if applied.Annotations[scenario.RerunAnnotation] != "request" {
+				t.Errorf("expected the rerun request, got %v", applied.Annotations)
+			}
+
+			if _, ok := applied.Annotations[scenario.CancelAnnotation]; ok {
+				t.Errorf("expected the cancellation to be withdrawn, got %v", applied.Annotations)
+			}
+		})
+	}
+}
+
+func TestWaitForScenarios(t *testing.T) {
+	newScenario := func(name string, status string, request string) client.Object {
+		return &threatestergithubiov1alpha1.Scenario{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status:     threatestergithubiov1alpha1.ScenarioStatus{Status: status, RerunRequest: request},
+		}
+	}
+
+	testCases := []struct {
+		name      string
+		scenarios []client.Object
+		err       error
+	}{
+		{
+			name:      "all succeeded",
+			scenarios: []client.Object{newScenario("recon", "Succeeded", "request"), newScenario("plan", "Planned", "request")},
+		},
+		{
+			name:      "one failed",
+			scenarios: []client.Object{newScenario("recon", "Succeeded", "request"), newScenario("plan", "Failed", "request")},
+			err:       errFailed,
+		},
+		{
+			name:      "one degraded",
+			scenarios: []client.Object{newScenario("recon", "Degraded", "request"), newScenario("plan", "Planned", "request")},
+			err:       errFailed,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tc.scenarios...).Build()
+			keys := []types.NamespacedName{}
+			for _, s := range tc.scenarios {
+				keys = append(keys, client.ObjectKeyFromObject(s))
+			}
+
+			var stdout bytes.Buffer
+			err := waitForScenarios(context.Background(), c, keys, "request", 0, &stdout)
+			if !errors.Is(err, tc.err) {
+				t.Errorf("expected error %v, got %v", tc.err, err)
+			}
+		})
+	}
+
+	t.Run("status of the previous run", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(newScenario("recon", "Succeeded", "previous")).Build()
+
+		var stdout bytes.Buffer
+		err := waitForScenarios(context.Background(), c, []types.NamespacedName{{Name: "recon", Namespace: "default"}}, "request", pollInterval/2, &stdout)
+		if err == nil {
+			t.Error("expected the wait to time out until the controller picks up the request")
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package cleanup
+
+//go:generate moq -out cleanup_service_mock.go . CleanupService
+
+import (
+	"context"
+	"time"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+)
+
+type CleanupService interface {
+	Cleanup(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario) error
+}
+
+type cleanupService struct {
+	datadogCleanup DatadogCleanup
+}
+
+func NewCleanupService() CleanupService {
+	return &cleanupService{
+		datadogCleanup: NewDatadogCleanup(),
+	}
+}
+
+// Cleanup restores the state of the detection backends for the window in which the scenario was running.
+func (c *cleanupService) Cleanup(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario) error {
+	if scenario.Spec.Cleanup == nil || scenario.Status.StartTime == nil {
+		return nil
+	}
+
+	from := scenario.Status.StartTime.Time
+	to := time.Now()
+
+	if scenario.Spec.Cleanup.Datadog != nil {
+		if err := c.datadogCleanup.RunCleanup(ctx, *scenario.Spec.Cleanup.Datadog, scenarioExpectations(scenario), from, to); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scenarioExpectations returns the expectations of the steps of the scenario along with the expectations of the scenario.
+func scenarioExpectations(scenario threatestergithubiov1alpha1.Scenario) []threatestergithubiov1alpha1.Expectation {
+	expectations := []threatestergithubiov1alpha1.Expectation{}
+	for _, step := range scenario.Spec.Steps {
+		expectations = append(expectations, step.Expectations...)
+	}
+
+	return append(expectations, scenario.Spec.Expectations...)
+}
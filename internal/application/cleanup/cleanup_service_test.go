@@ -0,0 +1,71 @@
+package cleanup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ddv1 "github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/mrtc0/threatester/internal/service/datadog"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCleanupWithStepExpectations(t *testing.T) {
+	alert := ddv1.MONITOROVERALLSTATES_ALERT
+
+	client := &datadog.DatadogClientMock{
+		GetMonitorFunc: func(ctx context.Context, monitorID int64) (*ddv1.Monitor, error) {
+			monitor := ddv1.NewMonitorWithDefaults()
+			monitor.SetState(ddv1.MonitorState{Groups: map[string]ddv1.MonitorStateGroup{
+				"pod_name:foo": {Status: &alert},
+			}})
+			return monitor, nil
+		},
+		ResolveMonitorGroupsFunc: func(ctx context.Context, monitorID int64, groups []string) error {
+			return nil
+		},
+		CreateDowntimeFunc: func(ctx context.Context, body ddv1.Downtime) (*ddv1.Downtime, error) {
+			return &body, nil
+		},
+	}
+
+	monitorExpectation := func(id string) threatestergithubiov1alpha1.Expectation {
+		return threatestergithubiov1alpha1.Expectation{
+			Datadog: &threatestergithubiov1alpha1.DatadogExpectation{Monitor: &threatestergithubiov1alpha1.DatadogMonitor{ID: id, Status: "Alert"}},
+		}
+	}
+
+	scenario := threatestergithubiov1alpha1.Scenario{
+		Spec: threatestergithubiov1alpha1.ScenarioSpec{
+			Steps: []threatestergithubiov1alpha1.Step{
+				{Name: "recon", Template: "recon", Expectations: []threatestergithubiov1alpha1.Expectation{monitorExpectation("1")}},
+				{Name: "exec", Template: "exec", Expectations: []threatestergithubiov1alpha1.Expectation{monitorExpectation("2"), monitorExpectation("1")}},
+			},
+			Cleanup: &threatestergithubiov1alpha1.Cleanup{
+				Datadog: &threatestergithubiov1alpha1.DatadogCleanup{
+					ResolveMonitors: true,
+					Downtime:        &threatestergithubiov1alpha1.DatadogDowntime{},
+				},
+			},
+		},
+		Status: threatestergithubiov1alpha1.ScenarioStatus{StartTime: &metav1.Time{Time: time.Now().Add(-time.Minute)}},
+	}
+
+	c := &cleanupService{datadogCleanup: DatadogCleanup{datadogClient: client}}
+	if err := c.Cleanup(context.Background(), scenario); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resolved := []int64{}
+	for _, call := range client.ResolveMonitorGroupsCalls() {
+		resolved = append(resolved, call.MonitorID)
+	}
+	if len(resolved) != 2 || resolved[0] != 1 || resolved[1] != 2 {
+		t.Errorf("expected monitors 1 and 2 of the steps to be resolved once, got %v", resolved)
+	}
+
+	if downtimes := client.CreateDowntimeCalls(); len(downtimes) != 2 {
+		t.Errorf("expected a downtime for monitors 1 and 2 of the steps, got %d", len(downtimes))
+	}
+}
@@ -0,0 +1,171 @@
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/mrtc0/threatester/internal/service/datadog"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+const (
+	// Comment marks the objects touched by the cleanup as generated by a test.
+	Comment = "threatester test"
+
+	defaultDowntimeDuration = time.Hour
+)
+
+type DatadogCleanup struct {
+	datadogClient datadog.DatadogClient
+}
+
+func NewDatadogCleanup() DatadogCleanup {
+	return DatadogCleanup{
+		datadogClient: datadog.NewDatadogClient(),
+	}
+}
+
+// RunCleanup runs every configured cleanup operation and returns all errors joined,
+// so that a failure in one operation does not prevent the others from running.
+func (c *DatadogCleanup) RunCleanup(ctx context.Context, cleanup threatestergithubiov1alpha1.DatadogCleanup, expectations []threatestergithubiov1alpha1.Expectation, from time.Time, to time.Time) error {
+	var errs []error
+
+	monitors := datadogMonitors(expectations)
+
+	if cleanup.ResolveMonitors {
+		for _, monitor := range monitors {
+			if err := c.ResolveMonitor(ctx, monitor); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if cleanup.ArchiveSignals != nil {
+		if err := c.ArchiveSignals(ctx, cleanup.ArchiveSignals.Query, from, to); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if cleanup.Downtime != nil {
+		for _, monitor := range monitors {
+			if err := c.ScheduleDowntime(ctx, monitor, *cleanup.Downtime, from, to); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// ResolveMonitor resolves the triggered groups of the monitor that match the expected group.
+func (c *DatadogCleanup) ResolveMonitor(ctx context.Context, monitor threatestergithubiov1alpha1.DatadogMonitor) error {
+	monitorID, err := strconv.ParseInt(monitor.ID, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.datadogClient.GetMonitor(ctx, monitorID)
+	if err != nil {
+		return err
+	}
+
+	groups := []string{}
+	for name, group := range resp.GetState().Groups {
+		if !datadog.MatchMonitorGroup(name, monitor.Group) {
+			continue
+		}
+
+		switch group.GetStatus() {
+		case datadogV1.MONITOROVERALLSTATES_ALERT, datadogV1.MONITOROVERALLSTATES_WARN, datadogV1.MONITOROVERALLSTATES_NO_DATA:
+			groups = append(groups, name)
+		}
+	}
+
+	if len(groups) == 0 {
+		return nil
+	}
+
+	return c.datadogClient.ResolveMonitorGroups(ctx, monitorID, groups)
+}
+
+// ArchiveSignals archives the security signals matching the query that were generated between from and to.
+func (c *DatadogCleanup) ArchiveSignals(ctx context.Context, query string, from time.Time, to time.Time) error {
+	signals, err := c.datadogClient.SearchSecuritySignals(ctx, query, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to search security signals: %w", err)
+	}
+
+	for _, signal := range signals {
+		if err := c.datadogClient.ArchiveSecuritySignal(ctx, signal.GetId(), Comment); err != nil {
+			return fmt.Errorf("failed to archive security signal %s: %w", signal.GetId(), err)
+		}
+	}
+
+	return nil
+}
+
+// ScheduleDowntime mutes the monitor from the start of the scenario until the configured duration after its end.
+func (c *DatadogCleanup) ScheduleDowntime(ctx context.Context, monitor threatestergithubiov1alpha1.DatadogMonitor, downtime threatestergithubiov1alpha1.DatadogDowntime, from time.Time, to time.Time) error {
+	monitorID, err := strconv.ParseInt(monitor.ID, 10, 64)
+	if err != nil {
+		return err
+	}
+
+	duration := defaultDowntimeDuration
+	if downtime.Duration != "" {
+		duration, err = time.ParseDuration(downtime.Duration)
+		if err != nil {
+			return fmt.Errorf("invalid downtime duration %q: %w", downtime.Duration, err)
+		}
+	}
+
+	// a monitor group joins its tags with commas, while the scope of a downtime lists them
+	scope := []string{"*"}
+	if monitor.Group != "" {
+		scope = []string{}
+		for _, tag := range strings.Split(monitor.Group, ",") {
+			scope = append(scope, strings.TrimSpace(tag))
+		}
+	}
+
+	body := datadogV1.NewDowntime()
+	body.SetMonitorId(monitorID)
+	body.SetScope(scope)
+	body.SetStart(from.Unix())
+	body.SetEnd(to.Add(duration).Unix())
+	body.SetMessage(Comment)
+
+	if _, err := c.datadogClient.CreateDowntime(ctx, *body); err != nil {
+		return fmt.Errorf("failed to create downtime for monitor %d: %w", monitorID, err)
+	}
+
+	return nil
+}
+
+// datadogMonitors returns the monitors referenced by the expectations, once per monitor group
+// even when several expectations, e.g. of different steps, reference it.
+func datadogMonitors(expectations []threatestergithubiov1alpha1.Expectation) []threatestergithubiov1alpha1.DatadogMonitor {
+	monitors := []threatestergithubiov1alpha1.DatadogMonitor{}
+	seen := map[[2]string]bool{}
+	for _, expectation := range expectations {
+		if expectation.Datadog == nil || expectation.Datadog.Monitor == nil {
+			continue
+		}
+
+		monitor := *expectation.Datadog.Monitor
+		key := [2]string{monitor.ID, monitor.Group}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		monitors = append(monitors, monitor)
+	}
+
+	return monitors
+}
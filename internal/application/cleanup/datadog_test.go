@@ -0,0 +1,95 @@
+package cleanup
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	ddv1 "github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/mrtc0/threatester/internal/service/datadog"
+)
+
+func TestResolveMonitor(t *testing.T) {
+	alert := ddv1.MONITOROVERALLSTATES_ALERT
+	ok := ddv1.MONITOROVERALLSTATES_OK
+
+	client := &datadog.DatadogClientMock{
+		GetMonitorFunc: func(ctx context.Context, monitorID int64) (*ddv1.Monitor, error) {
+			monitor := ddv1.NewMonitorWithDefaults()
+			monitor.SetState(ddv1.MonitorState{Groups: map[string]ddv1.MonitorStateGroup{
+				"pod_name:foo,kube_namespace:default": {Status: &alert},
+				"pod_name:bar,kube_namespace:default": {Status: &alert},
+				"pod_name:foo,kube_namespace:other":   {Status: &ok},
+			}})
+			return monitor, nil
+		},
+		ResolveMonitorGroupsFunc: func(ctx context.Context, monitorID int64, groups []string) error {
+			return nil
+		},
+	}
+
+	c := &DatadogCleanup{datadogClient: client}
+	err := c.ResolveMonitor(context.Background(), threatestergithubiov1alpha1.DatadogMonitor{ID: "12345", Group: "pod_name:foo"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	calls := client.ResolveMonitorGroupsCalls()
+	if len(calls) != 1 {
+		t.Fatalf("expected 1 resolve call, got %d", len(calls))
+	}
+
+	if calls[0].MonitorID != 12345 {
+		t.Errorf("expected monitor 12345, got %d", calls[0].MonitorID)
+	}
+
+	expected := []string{"pod_name:foo,kube_namespace:default"}
+	if !reflect.DeepEqual(calls[0].Groups, expected) {
+		t.Errorf("expected groups %v, got %v", expected, calls[0].Groups)
+	}
+}
+
+func TestScheduleDowntime(t *testing.T) {
+	tests := []struct {
+		name  string
+		group string
+		scope []string
+	}{
+		{name: "all groups", scope: []string{"*"}},
+		{name: "single tag", group: "pod_name:foo", scope: []string{"pod_name:foo"}},
+		{name: "multiple tags", group: "env:prod, host:a", scope: []string{"env:prod", "host:a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &datadog.DatadogClientMock{
+				CreateDowntimeFunc: func(ctx context.Context, downtime ddv1.Downtime) (*ddv1.Downtime, error) {
+					return &downtime, nil
+				},
+			}
+
+			from := time.Unix(1700000000, 0)
+			to := from.Add(time.Minute)
+			c := &DatadogCleanup{datadogClient: client}
+			err := c.ScheduleDowntime(context.Background(), threatestergithubiov1alpha1.DatadogMonitor{ID: "12345", Group: tt.group}, threatestergithubiov1alpha1.DatadogDowntime{Duration: "30m"}, from, to)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			calls := client.CreateDowntimeCalls()
+			if len(calls) != 1 {
+				t.Fatalf("expected 1 downtime, got %d", len(calls))
+			}
+
+			body := calls[0].Downtime
+			if !reflect.DeepEqual(body.GetScope(), tt.scope) {
+				t.Errorf("expected scope %v, got %v", tt.scope, body.GetScope())
+			}
+			if body.GetMonitorId() != 12345 || body.GetEnd() != to.Add(30*time.Minute).Unix() {
+				t.Errorf("unexpected downtime %+v", body)
+			}
+		})
+	}
+}
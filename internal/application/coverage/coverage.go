@@ -0,0 +1,135 @@
+// Package coverage aggregates the latest results of the scenarios into the MITRE ATT&CK techniques they detect.
+package coverage
+
+import (
+	"context"
+	"sort"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type Status string
+
+const (
+	// StatusDetected is the status of a technique whose expectations passed in one of its scenarios.
+	StatusDetected Status = "detected"
+	// StatusMissed is the status of a technique whose expectations failed in all of its scenarios that ran them.
+	StatusMissed Status = "missed"
+	// StatusUntested is the status of a technique none of whose scenarios has evaluated expectations yet.
+	StatusUntested Status = "untested"
+)
+
+// Statuses are the statuses of a technique, from the best to the worst coverage.
+var Statuses = []Status{StatusDetected, StatusMissed, StatusUntested}
+
+type Technique struct {
+	// ID of the technique or sub-technique, e.g. "T1552.001".
+	ID string
+	// Tactics are the IDs of the tactics declared along with the technique, sorted.
+	Tactics []string
+	Status  Status
+	// Scenarios are the namespaced names of the scenarios simulating the technique, sorted.
+	Scenarios []string
+}
+
+type Summary struct {
+	// Techniques are sorted by ID.
+	Techniques []Technique
+}
+
+// Count returns the number of techniques with the status.
+func (s Summary) Count(status Status) int {
+	count := 0
+	for _, technique := range s.Techniques {
+		if technique.Status == status {
+			count++
+		}
+	}
+
+	return count
+}
+
+// ScenarioStatus returns the coverage of the techniques of the scenario from the expectations of its latest run.
+// A scenario without evaluated expectations, e.g. one that has not run yet or whose attack failed, is untested.
+func ScenarioStatus(scenario threatestergithubiov1alpha1.Scenario) Status {
+	result := scenario.Status.Result
+	if len(result.SucceededExpectations) == 0 && len(result.FailedExpectations) == 0 {
+		return StatusUntested
+	}
+
+	if result.Passed {
+		return StatusDetected
+	}
+
+	return StatusMissed
+}
+
+// Summarize aggregates the scenarios into the coverage of the techniques they declare.
+// A technique is detected when one of its scenarios detected it, since each scenario simulates it differently.
+func Summarize(scenarios []threatestergithubiov1alpha1.Scenario) Summary {
+	type entry struct {
+		status    Status
+		tactics   map[string]struct{}
+		scenarios []string
+	}
+
+	entries := map[string]*entry{}
+	for _, scenario := range scenarios {
+		if scenario.Spec.MitreAttack == nil {
+			continue
+		}
+
+		status := ScenarioStatus(scenario)
+		for _, id := range scenario.Spec.MitreAttack.Techniques {
+			e, ok := entries[id]
+			if !ok {
+				e = &entry{status: StatusUntested, tactics: map[string]struct{}{}}
+				entries[id] = e
+			}
+
+			if rank(status) < rank(e.status) {
+				e.status = status
+			}
+			for _, tactic := range scenario.Spec.MitreAttack.Tactics {
+				e.tactics[tactic] = struct{}{}
+			}
+			e.scenarios = append(e.scenarios, client.ObjectKeyFromObject(&scenario).String())
+		}
+	}
+
+	summary := Summary{Techniques: []Technique{}}
+	for id, e := range entries {
+		tactics := []string{}
+		for tactic := range e.tactics {
+			tactics = append(tactics, tactic)
+		}
+		sort.Strings(tactics)
+		sort.Strings(e.scenarios)
+
+		summary.Techniques = append(summary.Techniques, Technique{ID: id, Tactics: tactics, Status: e.status, Scenarios: e.scenarios})
+	}
+	sort.Slice(summary.Techniques, func(i, j int) bool { return summary.Techniques[i].ID < summary.Techniques[j].ID })
+
+	return summary
+}
+
+// Load lists the scenarios of all namespaces and summarizes their coverage.
+func Load(ctx context.Context, reader client.Reader) (Summary, error) {
+	scenarios := &threatestergithubiov1alpha1.ScenarioList{}
+	if err := reader.List(ctx, scenarios); err != nil {
+		return Summary{}, err
+	}
+
+	return Summarize(scenarios.Items), nil
+}
+
+func rank(status Status) int {
+	for i, s := range Statuses {
+		if s == status {
+			return i
+		}
+	}
+
+	return len(Statuses)
+}
@@ -0,0 +1,149 @@
+package coverage
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newScenario(name string, mitreAttack *threatestergithubiov1alpha1.MitreAttack, result threatestergithubiov1alpha1.ExpectationResult) *threatestergithubiov1alpha1.Scenario {
+	return &threatestergithubiov1alpha1.Scenario{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       threatestergithubiov1alpha1.ScenarioSpec{MitreAttack: mitreAttack},
+		Status:     threatestergithubiov1alpha1.ScenarioStatus{Result: result},
+	}
+}
+
+var (
+	detected = threatestergithubiov1alpha1.ExpectationResult{
+		Passed:                true,
+		SucceededExpectations: []threatestergithubiov1alpha1.Expectation{{}},
+	}
+	missed = threatestergithubiov1alpha1.ExpectationResult{
+		FailedExpectations: []threatestergithubiov1alpha1.FailedExpectation{{Reason: "monitor did not transition to Alert"}},
+	}
+	untested = threatestergithubiov1alpha1.ExpectationResult{}
+)
+
+func newTestScenarios() []threatestergithubiov1alpha1.Scenario {
+	return []threatestergithubiov1alpha1.Scenario{
+		*newScenario("read-token", &threatestergithubiov1alpha1.MitreAttack{Tactics: []string{"TA0006"}, Techniques: []string{"T1552.001", "T1528"}}, missed),
+		*newScenario("dump-token", &threatestergithubiov1alpha1.MitreAttack{Tactics: []string{"TA0006"}, Techniques: []string{"T1528"}}, detected),
+		*newScenario("reverse-shell", &threatestergithubiov1alpha1.MitreAttack{Tactics: []string{"TA0011", "TA0002"}, Techniques: []string{"T1059.004"}}, untested),
+		*newScenario("no-mitre-attack", nil, detected),
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	summary := Summarize(newTestScenarios())
+
+	expected := []Technique{
+		{ID: "T1059.004", Tactics: []string{"TA0002", "TA0011"}, Status: StatusUntested, Scenarios: []string{"default/reverse-shell"}},
+		{ID: "T1528", Tactics: []string{"TA0006"}, Status: StatusDetected, Scenarios: []string{"default/dump-token", "default/read-token"}},
+		{ID: "T1552.001", Tactics: []string{"TA0006"}, Status: StatusMissed, Scenarios: []string{"default/read-token"}},
+	}
+	if !reflect.DeepEqual(summary.Techniques, expected) {
+		t.Errorf("expected %+v, got %+v", expected, summary.Techniques)
+	}
+
+	for _, status := range Statuses {
+		if got := summary.Count(status); got != 1 {
+			t.Errorf("expected 1 %s technique, got %d", status, got)
+		}
+	}
+}
+
+func TestScenarioStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   threatestergithubiov1alpha1.ExpectationResult
+		expected Status
+	}{
+		{name: "passed expectations", result: detected, expected: StatusDetected},
+		{name: "failed expectations", result: missed, expected: StatusMissed},
+		{name: "no evaluated expectation", result: untested, expected: StatusUntested},
+		{name: "passed without expectation", result: threatestergithubiov1alpha1.ExpectationResult{Passed: true}, expected: StatusUntested},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ScenarioStatus(*newScenario("scenario", nil, tt.result)); got != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCollector(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := threatestergithubiov1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := newTestScenarios()
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&scenarios[0], &scenarios[1]).Build()
+
+	expected := `
+# HELP threatester_attack_techniques Number of MITRE ATT&CK techniques by coverage status.
+# TYPE threatester_attack_techniques gauge
+threatester_attack_techniques{status="detected"} 1
+threatester_attack_techniques{status="missed"} 1
+threatester_attack_techniques{status="untested"} 0
+# HELP threatester_attack_tactic_techniques Number of MITRE ATT&CK techniques of a tactic by coverage status.
+# TYPE threatester_attack_tactic_techniques gauge
+threatester_attack_tactic_techniques{status="detected",tactic="TA0006"} 1
+threatester_attack_tactic_techniques{status="missed",tactic="TA0006"} 1
+threatester_attack_tactic_techniques{status="untested",tactic="TA0006"} 0
+`
+	if err := testutil.CollectAndCompare(NewCollector(c), strings.NewReader(expected), "threatester_attack_techniques", "threatester_attack_tactic_techniques"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNavigatorHandler(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := threatestergithubiov1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	scenarios := newTestScenarios()
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&scenarios[0], &scenarios[1], &scenarios[2]).Build()
+
+	recorder := httptest.NewRecorder()
+	NewNavigatorHandler(c).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, NavigatorPath, nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", recorder.Code)
+	}
+
+	layer := Layer{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &layer); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if layer.Description != "1 detected, 1 missed, 1 untested" {
+		t.Errorf("unexpected description %q", layer.Description)
+	}
+
+	colors := map[string]string{}
+	for _, technique := range layer.Techniques {
+		colors[technique.TechniqueID] = technique.Color
+	}
+	expected := map[string]string{
+		"T1059.004": statusColors[StatusUntested],
+		"T1528":     statusColors[StatusDetected],
+		"T1552.001": statusColors[StatusMissed],
+	}
+	if !reflect.DeepEqual(colors, expected) {
+		t.Errorf("expected colors %v, got %v", expected, colors)
+	}
+}
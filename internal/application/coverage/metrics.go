@@ -0,0 +1,87 @@
+package coverage
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// collectTimeout bounds the listing of the scenarios during a scrape.
+const collectTimeout = 10 * time.Second
+
+var (
+	techniqueStatusDesc = prometheus.NewDesc(
+		"threatester_attack_technique_status",
+		"Coverage of a MITRE ATT&CK technique by the latest results of its scenarios, 1 for the current status.",
+		[]string{"technique", "status"}, nil,
+	)
+	techniquesDesc = prometheus.NewDesc(
+		"threatester_attack_techniques",
+		"Number of MITRE ATT&CK techniques by coverage status.",
+		[]string{"status"}, nil,
+	)
+	tacticTechniquesDesc = prometheus.NewDesc(
+		"threatester_attack_tactic_techniques",
+		"Number of MITRE ATT&CK techniques of a tactic by coverage status.",
+		[]string{"tactic", "status"}, nil,
+	)
+)
+
+// Collector exposes the coverage of the scenarios as Prometheus metrics.
+// The coverage is computed on each scrape, so that it always reflects the latest results.
+type Collector struct {
+	reader client.Reader
+}
+
+var _ prometheus.Collector = &Collector{}
+
+func NewCollector(reader client.Reader) *Collector {
+	return &Collector{reader: reader}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- techniqueStatusDesc
+	ch <- techniquesDesc
+	ch <- tacticTechniquesDesc
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	summary, err := Load(ctx, c.reader)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(techniqueStatusDesc, err)
+		return
+	}
+
+	tactics := map[string]map[Status]int{}
+	for _, technique := range summary.Techniques {
+		for _, status := range Statuses {
+			value := 0.0
+			if technique.Status == status {
+				value = 1
+			}
+			ch <- prometheus.MustNewConstMetric(techniqueStatusDesc, prometheus.GaugeValue, value, technique.ID, string(status))
+		}
+
+		for _, tactic := range technique.Tactics {
+			if _, ok := tactics[tactic]; !ok {
+				tactics[tactic] = map[Status]int{}
+			}
+			tactics[tactic][technique.Status]++
+		}
+	}
+
+	for _, status := range Statuses {
+		ch <- prometheus.MustNewConstMetric(techniquesDesc, prometheus.GaugeValue, float64(summary.Count(status)), string(status))
+	}
+
+	for tactic, counts := range tactics {
+		for _, status := range Statuses {
+			ch <- prometheus.MustNewConstMetric(tacticTechniquesDesc, prometheus.GaugeValue, float64(counts[status]), tactic, string(status))
+		}
+	}
+}
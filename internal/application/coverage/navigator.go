@@ -0,0 +1,93 @@
+package coverage
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// NavigatorPath is the path of the manager metrics server serving the ATT&CK Navigator layer.
+const NavigatorPath = "/coverage/navigator"
+
+// colors of the statuses in the ATT&CK Navigator layer
+var statusColors = map[Status]string{
+	StatusDetected: "#8ec843",
+	StatusMissed:   "#ff6666",
+	StatusUntested: "#d3d3d3",
+}
+
+// Layer is an ATT&CK Navigator layer, see https://github.com/mitre-attack/attack-navigator/tree/master/layers.
+type Layer struct {
+	Name        string           `json:"name"`
+	Versions    LayerVersions    `json:"versions"`
+	Domain      string           `json:"domain"`
+	Description string           `json:"description"`
+	Techniques  []LayerTechnique `json:"techniques"`
+	LegendItems []LegendItem     `json:"legendItems"`
+}
+
+type LayerVersions struct {
+	Attack    string `json:"attack"`
+	Navigator string `json:"navigator"`
+	Layer     string `json:"layer"`
+}
+
+type LayerTechnique struct {
+	TechniqueID string `json:"techniqueID"`
+	Color       string `json:"color"`
+	Comment     string `json:"comment"`
+	Enabled     bool   `json:"enabled"`
+}
+
+type LegendItem struct {
+	Label string `json:"label"`
+	Color string `json:"color"`
+}
+
+// NavigatorLayer builds the ATT&CK Navigator layer coloring the techniques by their coverage.
+func NavigatorLayer(summary Summary) Layer {
+	layer := Layer{
+		Name:        "threatester coverage",
+		Versions:    LayerVersions{Attack: "14", Navigator: "4.9.1", Layer: "4.5"},
+		Domain:      "enterprise-attack",
+		Description: fmt.Sprintf("%d detected, %d missed, %d untested", summary.Count(StatusDetected), summary.Count(StatusMissed), summary.Count(StatusUntested)),
+		Techniques:  []LayerTechnique{},
+		LegendItems: []LegendItem{},
+	}
+
+	for _, technique := range summary.Techniques {
+		layer.Techniques = append(layer.Techniques, LayerTechnique{
+			TechniqueID: technique.ID,
+			Color:       statusColors[technique.Status],
+			Comment:     fmt.Sprintf("%s by %s", technique.Status, strings.Join(technique.Scenarios, ", ")),
+			Enabled:     true,
+		})
+	}
+
+	for _, status := range Statuses {
+		layer.LegendItems = append(layer.LegendItems, LegendItem{Label: string(status), Color: statusColors[status]})
+	}
+
+	return layer
+}
+
+// NewNavigatorHandler serves the ATT&CK Navigator layer of the coverage of the scenarios.
+func NewNavigatorHandler(reader client.Reader) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		summary, err := Load(r.Context(), reader)
+		if err != nil {
+			log.FromContext(r.Context()).Error(err, "failed to load coverage")
+			http.Error(w, "failed to load coverage", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(NavigatorLayer(summary)); err != nil {
+			log.FromContext(r.Context()).Error(err, "failed to write ATT&CK Navigator layer")
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
 	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
@@ -13,6 +14,8 @@ import (
 type DatadogExpectation struct {
 	datadogClient datadog.DatadogClient
 	expectation   threatestergithubiov1alpha1.DatadogExpectation
+	startTime     time.Time
+	endTime       time.Time
 }
 
 func NewDatadogExpectation() DatadogExpectation {
@@ -22,16 +25,58 @@ func NewDatadogExpectation() DatadogExpectation {
 	return ddExpectation
 }
 
-func (e *DatadogExpectation) RunExpectation(ctx context.Context, expectation threatestergithubiov1alpha1.DatadogExpectation) (bool, error) {
+// RunExpectation checks the monitor or the logs of the expectation. The logs are searched between startTime and endTime,
+// while a monitor only has to transition after startTime, since monitors are evaluated some time after the activity
+// they detect.
+func (e *DatadogExpectation) RunExpectation(ctx context.Context, expectation threatestergithubiov1alpha1.DatadogExpectation, startTime time.Time, endTime time.Time) (bool, error) {
 	e.expectation = expectation
+	e.startTime = startTime
+	e.endTime = endTime
 
 	if expectation.Monitor != nil {
 		return e.ExpectMonitorState(ctx, expectation.Monitor.Status)
 	}
 
+	if expectation.Logs != nil {
+		return e.ExpectLogs(ctx, expectation.Logs.Query)
+	}
+
 	return false, fmt.Errorf("datadog expectation not found")
 }
 
+// Validate checks that the monitor exists, or that the logs query is accepted.
+func (e *DatadogExpectation) Validate(ctx context.Context, expectation threatestergithubiov1alpha1.DatadogExpectation) error {
+	if expectation.Monitor != nil {
+		monitorID, err := strconv.ParseInt(expectation.Monitor.ID, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid monitor ID %q: %w", expectation.Monitor.ID, err)
+		}
+
+		if _, err := e.datadogClient.GetMonitor(ctx, monitorID); err != nil {
+			return fmt.Errorf("failed to get monitor %d: %w", monitorID, err)
+		}
+
+		return nil
+	}
+
+	if expectation.Logs != nil {
+		if expectation.Logs.Query == "" {
+			return fmt.Errorf("datadog logs query is empty")
+		}
+
+		now := time.Now()
+		if _, err := e.datadogClient.SearchLogs(ctx, expectation.Logs.Query, now.Add(-ValidationWindow), now, 1); err != nil {
+			return fmt.Errorf("failed to search logs: %w", err)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("datadog expectation not found")
+}
+
+// ExpectMonitorState checks that the monitor transitioned into expectState after the scenario started.
+// A monitor that was already in expectState before the scenario started does not satisfy the expectation.
 func (e *DatadogExpectation) ExpectMonitorState(ctx context.Context, expectState string) (bool, error) {
 	monitorID, err := strconv.ParseInt(e.expectation.Monitor.ID, 10, 64)
 	if err != nil {
@@ -43,10 +88,98 @@ func (e *DatadogExpectation) ExpectMonitorState(ctx context.Context, expectState
 		return false, err
 	}
 
-	actualState := resp.GetOverallState()
-	if actualState != datadogV1.MonitorOverallStates(expectState) {
-		return false, fmt.Errorf("monitor %d state is not %s, got %s", monitorID, expectState, actualState)
+	groups := resp.GetState().Groups
+	if len(groups) == 0 {
+		return false, fmt.Errorf("monitor %d has no group states, got overall state %s", monitorID, resp.GetOverallState())
+	}
+
+	expected := datadogV1.MonitorOverallStates(expectState)
+	matched := false
+	for name, group := range groups {
+		if !datadog.MatchMonitorGroup(name, e.expectation.Monitor.Group) {
+			continue
+		}
+		matched = true
+
+		if group.GetStatus() != expected {
+			continue
+		}
+
+		transitionedAt, ok := monitorGroupTransitionTime(group, expected)
+		if !ok {
+			continue
+		}
+
+		// the transition times of Datadog are in seconds, so a transition in the same second as the start counts
+		if !transitionedAt.Before(e.startTime.Truncate(time.Second)) {
+			return true, nil
+		}
+	}
+
+	if !matched {
+		return false, fmt.Errorf("monitor %d has no group matching %q", monitorID, e.expectation.Monitor.Group)
+	}
+
+	return false, fmt.Errorf("monitor %d did not transition to %s after %s", monitorID, expectState, e.startTime.Format(time.RFC3339))
+}
+
+// ExpectLogs checks that logs matching the query were ingested between the start and the end time.
+func (e *DatadogExpectation) ExpectLogs(ctx context.Context, query string) (bool, error) {
+	if query == "" {
+		return false, fmt.Errorf("datadog logs query is empty")
+	}
+
+	logs, err := e.datadogClient.SearchLogs(ctx, query, e.startTime, e.endTime, 1)
+	if err != nil {
+		return false, err
+	}
+
+	if len(logs) == 0 {
+		return false, fmt.Errorf("no log matches %q between %s and %s", query, e.startTime.Format(time.RFC3339), e.endTime.Format(time.RFC3339))
 	}
 
 	return true, nil
 }
+
+// monitorGroupTransitionTime returns the time at which the monitor group last entered the given state.
+func monitorGroupTransitionTime(group datadogV1.MonitorStateGroup, state datadogV1.MonitorOverallStates) (time.Time, bool) {
+	var ts *int64
+
+	switch state {
+	case datadogV1.MONITOROVERALLSTATES_ALERT, datadogV1.MONITOROVERALLSTATES_WARN:
+		ts = group.LastTriggeredTs
+	case datadogV1.MONITOROVERALLSTATES_OK:
+		ts = group.LastResolvedTs
+	case datadogV1.MONITOROVERALLSTATES_NO_DATA:
+		ts = group.LastNodataTs
+	}
+
+	if ts == nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(*ts, 0), true
+}
+
+// ScopeExpectationsToTag returns a copy of the expectations restricted to the monitor groups and the logs
+// with the given tag, e.g. "host:node-a" to only accept detections on a node.
+func ScopeExpectationsToTag(expectations []threatestergithubiov1alpha1.Expectation, tag string) []threatestergithubiov1alpha1.Expectation {
+	scoped := []threatestergithubiov1alpha1.Expectation{}
+	for _, expectation := range expectations {
+		expectation := *expectation.DeepCopy()
+		if expectation.Datadog != nil && expectation.Datadog.Monitor != nil {
+			if expectation.Datadog.Monitor.Group == "" {
+				expectation.Datadog.Monitor.Group = tag
+			} else {
+				expectation.Datadog.Monitor.Group = expectation.Datadog.Monitor.Group + "," + tag
+			}
+		}
+		if expectation.Datadog != nil && expectation.Datadog.Logs != nil && expectation.Datadog.Logs.Query != "" {
+			expectation.Datadog.Logs.Query = fmt.Sprintf("(%s) %s", expectation.Datadog.Logs.Query, tag)
+		}
+
+		scoped = append(scoped, expectation)
+	}
+
+	return scoped
+}
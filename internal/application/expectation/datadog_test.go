@@ -0,0 +1,215 @@
+package expectation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	ddv1 "github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	ddv2 "github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/mrtc0/threatester/internal/service/datadog"
+)
+
+func newMonitorStateGroup(status ddv1.MonitorOverallStates, lastTriggered time.Time) ddv1.MonitorStateGroup {
+	group := ddv1.NewMonitorStateGroup()
+	group.SetStatus(status)
+	group.SetLastTriggeredTs(lastTriggered.Unix())
+
+	return *group
+}
+
+func TestExpectMonitorState(t *testing.T) {
+	startTime := time.Date(2023, 4, 1, 12, 0, 0, int(500*time.Millisecond), time.UTC)
+
+	tests := []struct {
+		name   string
+		group  string
+		groups map[string]ddv1.MonitorStateGroup
+		passed bool
+	}{
+		{
+			name: "transitioned after the scenario started",
+			groups: map[string]ddv1.MonitorStateGroup{
+				"pod_name:foo": newMonitorStateGroup(ddv1.MONITOROVERALLSTATES_ALERT, startTime.Add(time.Minute)),
+			},
+			passed: true,
+		},
+		{
+			name: "transitioned in the same second as the scenario started",
+			groups: map[string]ddv1.MonitorStateGroup{
+				"pod_name:foo": newMonitorStateGroup(ddv1.MONITOROVERALLSTATES_ALERT, startTime),
+			},
+			passed: true,
+		},
+		{
+			name: "already alerting before the scenario started",
+			groups: map[string]ddv1.MonitorStateGroup{
+				"pod_name:foo": newMonitorStateGroup(ddv1.MONITOROVERALLSTATES_ALERT, startTime.Add(-time.Minute)),
+			},
+			passed: false,
+		},
+		{
+			name:  "only the expected group is considered",
+			group: "pod_name:bar",
+			groups: map[string]ddv1.MonitorStateGroup{
+				"pod_name:foo,kube_namespace:default": newMonitorStateGroup(ddv1.MONITOROVERALLSTATES_ALERT, startTime.Add(time.Minute)),
+				"pod_name:bar,kube_namespace:default": newMonitorStateGroup(ddv1.MONITOROVERALLSTATES_OK, startTime.Add(-time.Hour)),
+			},
+			passed: false,
+		},
+		{
+			name:  "expected group matches a subset of tags",
+			group: "pod_name:foo",
+			groups: map[string]ddv1.MonitorStateGroup{
+				"pod_name:foo,kube_namespace:default": newMonitorStateGroup(ddv1.MONITOROVERALLSTATES_ALERT, startTime.Add(time.Minute)),
+			},
+			passed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &DatadogExpectation{
+				datadogClient: &datadog.DatadogClientMock{
+					GetMonitorFunc: func(ctx context.Context, monitorID int64) (*ddv1.Monitor, error) {
+						monitor := ddv1.NewMonitorWithDefaults()
+						monitor.SetOverallState(ddv1.MONITOROVERALLSTATES_ALERT)
+						monitor.SetState(ddv1.MonitorState{Groups: tt.groups})
+						return monitor, nil
+					},
+				},
+			}
+
+			expectation := threatestergithubiov1alpha1.DatadogExpectation{
+				Monitor: &threatestergithubiov1alpha1.DatadogMonitor{ID: "12345", Status: "Alert", Group: tt.group},
+			}
+
+			passed, err := e.RunExpectation(context.Background(), expectation, startTime, time.Now())
+			if passed != tt.passed {
+				t.Errorf("expected passed to be %t, got %t (err: %v)", tt.passed, passed, err)
+			}
+		})
+	}
+}
+
+func TestExpectLogs(t *testing.T) {
+	startTime := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+	endTime := startTime.Add(time.Minute)
+
+	tests := []struct {
+		name   string
+		logs   []ddv2.Log
+		passed bool
+	}{
+		{name: "logs matched after the scenario started", logs: []ddv2.Log{*ddv2.NewLog()}, passed: true},
+		{name: "no log matched", logs: []ddv2.Log{}, passed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var from, to time.Time
+			e := &DatadogExpectation{
+				datadogClient: &datadog.DatadogClientMock{
+					SearchLogsFunc: func(ctx context.Context, query string, f time.Time, t time.Time, limit int32) ([]ddv2.Log, error) {
+						from, to = f, t
+						return tt.logs, nil
+					},
+				},
+			}
+
+			expectation := threatestergithubiov1alpha1.DatadogExpectation{
+				Logs: &threatestergithubiov1alpha1.DatadogLogs{Query: "source:kubernetes.audit @objectRef.resource:secrets"},
+			}
+
+			passed, err := e.RunExpectation(context.Background(), expectation, startTime, endTime)
+			if passed != tt.passed {
+				t.Errorf("expected passed to be %t, got %t (err: %v)", tt.passed, passed, err)
+			}
+			if !from.Equal(startTime) || !to.Equal(endTime) {
+				t.Errorf("expected the logs to be searched from %s to %s, got %s to %s", startTime, endTime, from, to)
+			}
+		})
+	}
+}
+
+func TestValidateDatadogExpectation(t *testing.T) {
+	tests := []struct {
+		name        string
+		expectation threatestergithubiov1alpha1.DatadogExpectation
+		monitorErr  error
+		valid       bool
+	}{
+		{
+			name:        "monitor exists",
+			expectation: threatestergithubiov1alpha1.DatadogExpectation{Monitor: &threatestergithubiov1alpha1.DatadogMonitor{ID: "12345", Status: "Alert"}},
+			valid:       true,
+		},
+		{
+			name:        "monitor does not exist",
+			expectation: threatestergithubiov1alpha1.DatadogExpectation{Monitor: &threatestergithubiov1alpha1.DatadogMonitor{ID: "12345", Status: "Alert"}},
+			monitorErr:  errors.New("404 Not Found"),
+			valid:       false,
+		},
+		{
+			name:        "monitor ID is not a number",
+			expectation: threatestergithubiov1alpha1.DatadogExpectation{Monitor: &threatestergithubiov1alpha1.DatadogMonitor{ID: "cpu-usage", Status: "Alert"}},
+			valid:       false,
+		},
+		{
+			name:        "logs query is accepted",
+			expectation: threatestergithubiov1alpha1.DatadogExpectation{Logs: &threatestergithubiov1alpha1.DatadogLogs{Query: "source:kubernetes.audit"}},
+			valid:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &DatadogExpectation{
+				datadogClient: &datadog.DatadogClientMock{
+					GetMonitorFunc: func(ctx context.Context, monitorID int64) (*ddv1.Monitor, error) {
+						if tt.monitorErr != nil {
+							return nil, tt.monitorErr
+						}
+						return ddv1.NewMonitorWithDefaults(), nil
+					},
+					SearchLogsFunc: func(ctx context.Context, query string, from time.Time, to time.Time, limit int32) ([]ddv2.Log, error) {
+						return []ddv2.Log{}, nil
+					},
+				},
+			}
+
+			err := e.Validate(context.Background(), tt.expectation)
+			if (err == nil) != tt.valid {
+				t.Errorf("expected valid to be %t, got %v", tt.valid, err)
+			}
+		})
+	}
+}
+
+func TestScopeExpectationsToTag(t *testing.T) {
+	expectations := []threatestergithubiov1alpha1.Expectation{
+		{Datadog: &threatestergithubiov1alpha1.DatadogExpectation{Monitor: &threatestergithubiov1alpha1.DatadogMonitor{ID: "1"}}},
+		{Datadog: &threatestergithubiov1alpha1.DatadogExpectation{Monitor: &threatestergithubiov1alpha1.DatadogMonitor{ID: "2", Group: "kube_namespace:default"}}},
+		{Datadog: &threatestergithubiov1alpha1.DatadogExpectation{Logs: &threatestergithubiov1alpha1.DatadogLogs{Query: "@verb:list OR @verb:get"}}},
+	}
+
+	scoped := ScopeExpectationsToTag(expectations, "host:node-a")
+
+	if got := scoped[0].Datadog.Monitor.Group; got != "host:node-a" {
+		t.Errorf("expected group host:node-a, got %s", got)
+	}
+
+	if got := scoped[1].Datadog.Monitor.Group; got != "kube_namespace:default,host:node-a" {
+		t.Errorf("expected group kube_namespace:default,host:node-a, got %s", got)
+	}
+
+	if got := scoped[2].Datadog.Logs.Query; got != "(@verb:list OR @verb:get) host:node-a" {
+		t.Errorf("expected query (@verb:list OR @verb:get) host:node-a, got %s", got)
+	}
+
+	if got := expectations[0].Datadog.Monitor.Group; got != "" {
+		t.Errorf("expected the original expectation not to be modified, got %s", got)
+	}
+}
@@ -0,0 +1,62 @@
+package expectation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/mrtc0/threatester/internal/service/elastic"
+)
+
+const DefaultElasticTimestampField = "@timestamp"
+
+type ElasticExpectation struct {
+	elasticClient elastic.ElasticClient
+}
+
+func NewElasticExpectation() ElasticExpectation {
+	return ElasticExpectation{elasticClient: elastic.NewElasticClient()}
+}
+
+// Validate checks that the index accepts the credentials and the query.
+func (e *ElasticExpectation) Validate(ctx context.Context, expectation threatestergithubiov1alpha1.ElasticExpectation) error {
+	if expectation.Query == "" {
+		return fmt.Errorf("elastic query is empty")
+	}
+
+	timestampField := expectation.TimestampField
+	if timestampField == "" {
+		timestampField = DefaultElasticTimestampField
+	}
+
+	now := time.Now()
+	if _, err := e.elasticClient.Count(ctx, expectation.URL, expectation.Index, expectation.Query, timestampField, now.Add(-ValidationWindow), now); err != nil {
+		return fmt.Errorf("failed to search %s: %w", expectation.Index, err)
+	}
+
+	return nil
+}
+
+// RunExpectation checks that documents matching the query were indexed between startTime and endTime.
+func (e *ElasticExpectation) RunExpectation(ctx context.Context, expectation threatestergithubiov1alpha1.ElasticExpectation, startTime time.Time, endTime time.Time) (bool, error) {
+	if expectation.Query == "" {
+		return false, fmt.Errorf("elastic query is empty")
+	}
+
+	timestampField := expectation.TimestampField
+	if timestampField == "" {
+		timestampField = DefaultElasticTimestampField
+	}
+
+	count, err := e.elasticClient.Count(ctx, expectation.URL, expectation.Index, expectation.Query, timestampField, startTime, endTime)
+	if err != nil {
+		return false, err
+	}
+
+	if count == 0 {
+		return false, fmt.Errorf("no document of %s matches %q between %s and %s", expectation.Index, expectation.Query, startTime.Format(time.RFC3339), endTime.Format(time.RFC3339))
+	}
+
+	return true, nil
+}
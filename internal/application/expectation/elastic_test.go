@@ -0,0 +1,55 @@
+package expectation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/mrtc0/threatester/internal/service/elastic"
+)
+
+func TestElasticExpectation(t *testing.T) {
+	startTime := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		timestampField string
+		count          int64
+		expectedField  string
+		passed         bool
+	}{
+		{name: "documents matched", count: 2, expectedField: DefaultElasticTimestampField, passed: true},
+		{name: "no document matched", count: 0, expectedField: DefaultElasticTimestampField, passed: false},
+		{name: "custom timestamp field", timestampField: "event.created", count: 1, expectedField: "event.created", passed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var field string
+			e := &ElasticExpectation{
+				elasticClient: &elastic.ElasticClientMock{
+					CountFunc: func(ctx context.Context, baseURL string, index string, query string, timestampField string, from time.Time, to time.Time) (int64, error) {
+						field = timestampField
+						return tt.count, nil
+					},
+				},
+			}
+
+			expectation := threatestergithubiov1alpha1.ElasticExpectation{
+				URL:            "http://elasticsearch:9200",
+				Index:          "logs-*",
+				Query:          "verb:list",
+				TimestampField: tt.timestampField,
+			}
+
+			passed, err := e.RunExpectation(context.Background(), expectation, startTime, startTime.Add(time.Minute))
+			if passed != tt.passed {
+				t.Errorf("expected passed to be %t, got %t (err: %v)", tt.passed, passed, err)
+			}
+			if field != tt.expectedField {
+				t.Errorf("expected timestamp field %s, got %s", tt.expectedField, field)
+			}
+		})
+	}
+}
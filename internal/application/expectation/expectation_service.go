@@ -5,36 +5,142 @@ package expectation
 import (
 	"context"
 	"fmt"
+	"time"
 
 	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
 )
 
+const RetryInterval = 10 * time.Second
+
+// ValidationWindow is the time range searched by the queries validating an expectation.
+const ValidationWindow = 15 * time.Minute
+
 type ExpectationService interface {
 	RunExpectation(ctx context.Context) (bool, error)
+	// ValidateExpectation checks that the detection backend accepts the credentials, the monitor and the query of
+	// the expectation, without waiting for a detection.
+	ValidateExpectation(ctx context.Context, expectation threatestergithubiov1alpha1.Expectation) error
 	SetExpectations(expectations []threatestergithubiov1alpha1.Expectation)
+	SetStartTime(startTime time.Time)
+	// SetEndTime bounds the detections accepted by the expectations. A zero end time accepts the detections until now.
+	SetEndTime(endTime time.Time)
 }
 
 type expectationService struct {
 	Expectations       []threatestergithubiov1alpha1.Expectation
+	StartTime          time.Time
+	EndTime            time.Time
 	datadogExpectation DatadogExpectation
+	elasticExpectation ElasticExpectation
+	lokiExpectation    LokiExpectation
 }
 
 func NewExpectationService() ExpectationService {
 	return &expectationService{
 		datadogExpectation: NewDatadogExpectation(),
+		elasticExpectation: NewElasticExpectation(),
+		lokiExpectation:    NewLokiExpectation(),
 	}
 }
 
+// RunExpectation runs the expectations one after another and stops at the first one that is not satisfied.
 func (e *expectationService) RunExpectation(ctx context.Context) (bool, error) {
+	if len(e.Expectations) == 0 {
+		return false, fmt.Errorf("expectation not found")
+	}
+
 	for _, expect := range e.Expectations {
-		if expect.Datadog != nil {
-			return e.datadogExpectation.RunExpectation(ctx, *expect.Datadog)
+		timeout, err := parseTimeout(expect.Timeout)
+		if err != nil {
+			return false, err
 		}
+
+		passed, err := e.waitFor(ctx, timeout, func() (bool, error) {
+			return e.runExpectation(ctx, expect)
+		})
+		if !passed || err != nil {
+			return passed, err
+		}
+	}
+
+	return true, nil
+}
+
+func (e *expectationService) runExpectation(ctx context.Context, expect threatestergithubiov1alpha1.Expectation) (bool, error) {
+	endTime := e.EndTime
+	if endTime.IsZero() {
+		endTime = time.Now()
+	}
+
+	switch {
+	case expect.Datadog != nil:
+		return e.datadogExpectation.RunExpectation(ctx, *expect.Datadog, e.StartTime, endTime)
+	case expect.Elastic != nil:
+		return e.elasticExpectation.RunExpectation(ctx, *expect.Elastic, e.StartTime, endTime)
+	case expect.Loki != nil:
+		return e.lokiExpectation.RunExpectation(ctx, *expect.Loki, e.StartTime, endTime)
+	}
+
+	return false, fmt.Errorf("expectation has no backend")
+}
+
+func (e *expectationService) ValidateExpectation(ctx context.Context, expect threatestergithubiov1alpha1.Expectation) error {
+	if _, err := parseTimeout(expect.Timeout); err != nil {
+		return err
+	}
+
+	switch {
+	case expect.Datadog != nil:
+		return e.datadogExpectation.Validate(ctx, *expect.Datadog)
+	case expect.Elastic != nil:
+		return e.elasticExpectation.Validate(ctx, *expect.Elastic)
+	case expect.Loki != nil:
+		return e.lokiExpectation.Validate(ctx, *expect.Loki)
 	}
 
-	return false, fmt.Errorf("expectation not found")
+	return fmt.Errorf("expectation has no backend")
 }
 
 func (e *expectationService) SetExpectations(expectations []threatestergithubiov1alpha1.Expectation) {
 	e.Expectations = expectations
 }
+
+func (e *expectationService) SetStartTime(startTime time.Time) {
+	e.StartTime = startTime
+}
+
+func (e *expectationService) SetEndTime(endTime time.Time) {
+	e.EndTime = endTime
+}
+
+// waitFor retries the expectation until it passes or the timeout elapses.
+// Detections are usually delayed, so a failed check is only final once the timeout is reached.
+func (e *expectationService) waitFor(ctx context.Context, timeout time.Duration, expect func() (bool, error)) (bool, error) {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		passed, err := expect()
+		if passed || !time.Now().Before(deadline) {
+			return passed, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(RetryInterval):
+		}
+	}
+}
+
+func parseTimeout(timeout string) (time.Duration, error) {
+	if timeout == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid expectation timeout %q: %w", timeout, err)
+	}
+
+	return d, nil
+}
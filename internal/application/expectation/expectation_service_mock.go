@@ -7,6 +7,7 @@ import (
 	"context"
 	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
 	"sync"
+	"time"
 )
 
 // Ensure, that ExpectationServiceMock does implement ExpectationService.
@@ -22,9 +23,18 @@ var _ ExpectationService = &ExpectationServiceMock{}
 //			RunExpectationFunc: func(ctx context.Context) (bool, error) {
 //				panic("mock out the RunExpectation method")
 //			},
+//			SetEndTimeFunc: func(endTime time.Time)  {
+//				panic("mock out the SetEndTime method")
+//			},
 //			SetExpectationsFunc: func(expectations []threatestergithubiov1alpha1.Expectation)  {
 //				panic("mock out the SetExpectations method")
 //			},
+//			SetStartTimeFunc: func(startTime time.Time)  {
+//				panic("mock out the SetStartTime method")
+//			},
+//			ValidateExpectationFunc: func(ctx context.Context, expectation threatestergithubiov1alpha1.Expectation) error {
+//				panic("mock out the ValidateExpectation method")
+//			},
 //		}
 //
 //		// use mockedExpectationService in code that requires ExpectationService
@@ -35,9 +45,18 @@ type ExpectationServiceMock struct {
 	// RunExpectationFunc mocks the RunExpectation method.
 	RunExpectationFunc func(ctx context.Context) (bool, error)
 
+	// SetEndTimeFunc mocks the SetEndTime method.
+	SetEndTimeFunc func(endTime time.Time)
+
 	// SetExpectationsFunc mocks the SetExpectations method.
 	SetExpectationsFunc func(expectations []threatestergithubiov1alpha1.Expectation)
 
+	// SetStartTimeFunc mocks the SetStartTime method.
+	SetStartTimeFunc func(startTime time.Time)
+
+	// ValidateExpectationFunc mocks the ValidateExpectation method.
+	ValidateExpectationFunc func(ctx context.Context, expectation threatestergithubiov1alpha1.Expectation) error
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// RunExpectation holds details about calls to the RunExpectation method.
@@ -45,14 +64,34 @@ type ExpectationServiceMock struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 		}
+		// SetEndTime holds details about calls to the SetEndTime method.
+		SetEndTime []struct {
+			// EndTime is the endTime argument value.
+			EndTime time.Time
+		}
 		// SetExpectations holds details about calls to the SetExpectations method.
 		SetExpectations []struct {
 			// Expectations is the expectations argument value.
 			Expectations []threatestergithubiov1alpha1.Expectation
 		}
+		// SetStartTime holds details about calls to the SetStartTime method.
+		SetStartTime []struct {
+			// StartTime is the startTime argument value.
+			StartTime time.Time
+		}
+		// ValidateExpectation holds details about calls to the ValidateExpectation method.
+		ValidateExpectation []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Expectation is the expectation argument value.
+			Expectation threatestergithubiov1alpha1.Expectation
+		}
 	}
-	lockRunExpectation  sync.RWMutex
-	lockSetExpectations sync.RWMutex
+	lockRunExpectation      sync.RWMutex
+	lockSetEndTime          sync.RWMutex
+	lockSetExpectations     sync.RWMutex
+	lockSetStartTime        sync.RWMutex
+	lockValidateExpectation sync.RWMutex
 }
 
 // RunExpectation calls RunExpectationFunc.
@@ -87,6 +126,38 @@ func (mock *ExpectationServiceMock) RunExpectationCalls() []struct {
 	return calls
 }
 
+// SetEndTime calls SetEndTimeFunc.
+func (mock *ExpectationServiceMock) SetEndTime(endTime time.Time) {
+	if mock.SetEndTimeFunc == nil {
+		panic("ExpectationServiceMock.SetEndTimeFunc: method is nil but ExpectationService.SetEndTime was just called")
+	}
+	callInfo := struct {
+		EndTime time.Time
+	}{
+		EndTime: endTime,
+	}
+	mock.lockSetEndTime.Lock()
+	mock.calls.SetEndTime = append(mock.calls.SetEndTime, callInfo)
+	mock.lockSetEndTime.Unlock()
+	mock.SetEndTimeFunc(endTime)
+}
+
+// SetEndTimeCalls gets all the calls that were made to SetEndTime.
+// Check the length with:
+//
+//	len(mockedExpectationService.SetEndTimeCalls())
+func (mock *ExpectationServiceMock) SetEndTimeCalls() []struct {
+	EndTime time.Time
+} {
+	var calls []struct {
+		EndTime time.Time
+	}
+	mock.lockSetEndTime.RLock()
+	calls = mock.calls.SetEndTime
+	mock.lockSetEndTime.RUnlock()
+	return calls
+}
+
 // SetExpectations calls SetExpectationsFunc.
 func (mock *ExpectationServiceMock) SetExpectations(expectations []threatestergithubiov1alpha1.Expectation) {
 	if mock.SetExpectationsFunc == nil {
@@ -118,3 +189,71 @@ func (mock *ExpectationServiceMock) SetExpectationsCalls() []struct {
 	mock.lockSetExpectations.RUnlock()
 	return calls
 }
+
+// SetStartTime calls SetStartTimeFunc.
+func (mock *ExpectationServiceMock) SetStartTime(startTime time.Time) {
+	if mock.SetStartTimeFunc == nil {
+		panic("ExpectationServiceMock.SetStartTimeFunc: method is nil but ExpectationService.SetStartTime was just called")
+	}
+	callInfo := struct {
+		StartTime time.Time
+	}{
+		StartTime: startTime,
+	}
+	mock.lockSetStartTime.Lock()
+	mock.calls.SetStartTime = append(mock.calls.SetStartTime, callInfo)
+	mock.lockSetStartTime.Unlock()
+	mock.SetStartTimeFunc(startTime)
+}
+
+// SetStartTimeCalls gets all the calls that were made to SetStartTime.
+// Check the length with:
+//
+//	len(mockedExpectationService.SetStartTimeCalls())
+func (mock *ExpectationServiceMock) SetStartTimeCalls() []struct {
+	StartTime time.Time
+} {
+	var calls []struct {
+		StartTime time.Time
+	}
+	mock.lockSetStartTime.RLock()
+	calls = mock.calls.SetStartTime
+	mock.lockSetStartTime.RUnlock()
+	return calls
+}
+
+// ValidateExpectation calls ValidateExpectationFunc.
+func (mock *ExpectationServiceMock) ValidateExpectation(ctx context.Context, expectation threatestergithubiov1alpha1.Expectation) error {
+	if mock.ValidateExpectationFunc == nil {
+		panic("ExpectationServiceMock.ValidateExpectationFunc: method is nil but ExpectationService.ValidateExpectation was just called")
+	}
+	callInfo := struct {
+		Ctx         context.Context
+		Expectation threatestergithubiov1alpha1.Expectation
+	}{
+		Ctx:         ctx,
+		Expectation: expectation,
+	}
+	mock.lockValidateExpectation.Lock()
+	mock.calls.ValidateExpectation = append(mock.calls.ValidateExpectation, callInfo)
+	mock.lockValidateExpectation.Unlock()
+	return mock.ValidateExpectationFunc(ctx, expectation)
+}
+
+// ValidateExpectationCalls gets all the calls that were made to ValidateExpectation.
+// Check the length with:
+//
+//	len(mockedExpectationService.ValidateExpectationCalls())
+func (mock *ExpectationServiceMock) ValidateExpectationCalls() []struct {
+	Ctx         context.Context
+	Expectation threatestergithubiov1alpha1.Expectation
+} {
+	var calls []struct {
+		Ctx         context.Context
+		Expectation threatestergithubiov1alpha1.Expectation
+	}
+	mock.lockValidateExpectation.RLock()
+	calls = mock.calls.ValidateExpectation
+	mock.lockValidateExpectation.RUnlock()
+	return calls
+}
@@ -0,0 +1,50 @@
+package expectation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/mrtc0/threatester/internal/service/loki"
+)
+
+type LokiExpectation struct {
+	lokiClient loki.LokiClient
+}
+
+func NewLokiExpectation() LokiExpectation {
+	return LokiExpectation{lokiClient: loki.NewLokiClient()}
+}
+
+// Validate checks that Loki accepts the credentials and the query.
+func (e *LokiExpectation) Validate(ctx context.Context, expectation threatestergithubiov1alpha1.LokiExpectation) error {
+	if expectation.Query == "" {
+		return fmt.Errorf("loki query is empty")
+	}
+
+	now := time.Now()
+	if _, err := e.lokiClient.QueryRange(ctx, expectation.URL, expectation.TenantID, expectation.Query, now.Add(-ValidationWindow), now, 1); err != nil {
+		return fmt.Errorf("failed to query logs: %w", err)
+	}
+
+	return nil
+}
+
+// RunExpectation checks that log lines matching the query were ingested between startTime and endTime.
+func (e *LokiExpectation) RunExpectation(ctx context.Context, expectation threatestergithubiov1alpha1.LokiExpectation, startTime time.Time, endTime time.Time) (bool, error) {
+	if expectation.Query == "" {
+		return false, fmt.Errorf("loki query is empty")
+	}
+
+	lines, err := e.lokiClient.QueryRange(ctx, expectation.URL, expectation.TenantID, expectation.Query, startTime, endTime, 1)
+	if err != nil {
+		return false, err
+	}
+
+	if len(lines) == 0 {
+		return false, fmt.Errorf("no log line matches %q between %s and %s", expectation.Query, startTime.Format(time.RFC3339), endTime.Format(time.RFC3339))
+	}
+
+	return true, nil
+}
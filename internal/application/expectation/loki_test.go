@@ -0,0 +1,44 @@
+package expectation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/mrtc0/threatester/internal/service/loki"
+)
+
+func TestLokiExpectation(t *testing.T) {
+	startTime := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		query  string
+		lines  []string
+		passed bool
+	}{
+		{name: "log lines matched", query: `{job="audit"} |= "secrets"`, lines: []string{`{"verb":"list"}`}, passed: true},
+		{name: "no log line matched", query: `{job="audit"} |= "secrets"`, lines: []string{}, passed: false},
+		{name: "empty query", query: "", passed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &LokiExpectation{
+				lokiClient: &loki.LokiClientMock{
+					QueryRangeFunc: func(ctx context.Context, baseURL string, tenantID string, query string, from time.Time, to time.Time, limit int) ([]string, error) {
+						return tt.lines, nil
+					},
+				},
+			}
+
+			expectation := threatestergithubiov1alpha1.LokiExpectation{URL: "http://loki", Query: tt.query}
+
+			passed, err := e.RunExpectation(context.Background(), expectation, startTime, startTime.Add(time.Minute))
+			if passed != tt.passed {
+				t.Errorf("expected passed to be %t, got %t (err: %v)", tt.passed, passed, err)
+			}
+		})
+	}
+}
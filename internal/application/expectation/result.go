@@ -0,0 +1,89 @@
+package expectation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+)
+
+// Evaluate runs a set of expectations of the step, or of the scenario when step is empty, that only accept detections
+// that happened between startTime and endTime, or after startTime when endTime is zero.
+// Each expectation is evaluated on its own, so that a failed expectation reports its own reason and does not fail the
+// others.
+func Evaluate(ctx context.Context, service ExpectationService, step string, expectations []threatestergithubiov1alpha1.Expectation, startTime time.Time, endTime time.Time) threatestergithubiov1alpha1.ExpectationResult {
+	service.SetStartTime(startTime)
+	service.SetEndTime(endTime)
+
+	// a set without expectation fails with the reason given by the service
+	if len(expectations) == 0 {
+		service.SetExpectations(expectations)
+		passed, err := service.RunExpectation(ctx)
+		if passed && err == nil {
+			return threatestergithubiov1alpha1.ExpectationResult{Passed: true, SucceededExpectations: expectations}
+		}
+
+		return threatestergithubiov1alpha1.ExpectationResult{
+			Passed:             false,
+			FailedExpectations: []threatestergithubiov1alpha1.FailedExpectation{{Step: step, Reason: failureReason(err)}},
+		}
+	}
+
+	result := threatestergithubiov1alpha1.ExpectationResult{
+		Passed:                true,
+		SucceededExpectations: []threatestergithubiov1alpha1.Expectation{},
+		FailedExpectations:    []threatestergithubiov1alpha1.FailedExpectation{},
+	}
+	for _, expectation := range expectations {
+		service.SetExpectations([]threatestergithubiov1alpha1.Expectation{expectation})
+
+		passed, err := service.RunExpectation(ctx)
+		if passed && err == nil {
+			result.SucceededExpectations = append(result.SucceededExpectations, expectation)
+			continue
+		}
+
+		result.Passed = false
+		result.FailedExpectations = append(result.FailedExpectations, threatestergithubiov1alpha1.FailedExpectation{Step: step, Expectation: expectation, Reason: failureReason(err)})
+	}
+
+	return result
+}
+
+func failureReason(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+
+	return "expectation is not satisfied"
+}
+
+// MergeResult rolls other up into result.
+func MergeResult(result *threatestergithubiov1alpha1.ExpectationResult, other threatestergithubiov1alpha1.ExpectationResult) {
+	result.Passed = result.Passed && other.Passed
+	result.SucceededExpectations = append(result.SucceededExpectations, other.SucceededExpectations...)
+	result.FailedExpectations = append(result.FailedExpectations, other.FailedExpectations...)
+}
+
+// FailureError returns the error describing the failed expectations of the result, or nil when it passed.
+func FailureError(result threatestergithubiov1alpha1.ExpectationResult) error {
+	if result.Passed {
+		return nil
+	}
+
+	reasons := []string{}
+	for _, failed := range result.FailedExpectations {
+		switch {
+		case failed.Step != "":
+			reasons = append(reasons, fmt.Sprintf("step %s: %s", failed.Step, failed.Reason))
+		case failed.Node != "":
+			reasons = append(reasons, fmt.Sprintf("node %s: %s", failed.Node, failed.Reason))
+		default:
+			reasons = append(reasons, failed.Reason)
+		}
+	}
+
+	return fmt.Errorf("expectations failed: %s", strings.Join(reasons, "; "))
+}
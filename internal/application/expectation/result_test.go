@@ -0,0 +1,74 @@
+package expectation
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+)
+
+func TestEvaluate(t *testing.T) {
+	expectations := []threatestergithubiov1alpha1.Expectation{
+		{Datadog: &threatestergithubiov1alpha1.DatadogExpectation{Monitor: &threatestergithubiov1alpha1.DatadogMonitor{ID: "1", Status: "Alert"}}},
+		{Datadog: &threatestergithubiov1alpha1.DatadogExpectation{Monitor: &threatestergithubiov1alpha1.DatadogMonitor{ID: "2", Status: "Alert"}}},
+	}
+
+	// the service fails the monitors of failedMonitors, each with its own reason
+	newService := func(failedMonitors ...string) *ExpectationServiceMock {
+		var current []threatestergithubiov1alpha1.Expectation
+		return &ExpectationServiceMock{
+			RunExpectationFunc: func(ctx context.Context) (bool, error) {
+				for _, failed := range failedMonitors {
+					if current[0].Datadog.Monitor.ID == failed {
+						return false, fmt.Errorf("monitor %s is OK", failed)
+					}
+				}
+				return true, nil
+			},
+			SetExpectationsFunc: func(expectations []threatestergithubiov1alpha1.Expectation) { current = expectations },
+			SetStartTimeFunc:    func(startTime time.Time) {},
+			SetEndTimeFunc:      func(endTime time.Time) {},
+		}
+	}
+
+	result := threatestergithubiov1alpha1.ExpectationResult{Passed: true}
+	MergeResult(&result, Evaluate(context.Background(), newService(), "", expectations, time.Now(), time.Time{}))
+	if !result.Passed || len(result.SucceededExpectations) != 2 || FailureError(result) != nil {
+		t.Errorf("expected the expectations to pass, got %+v", result)
+	}
+
+	MergeResult(&result, Evaluate(context.Background(), newService("2"), "exec", expectations, time.Now(), time.Time{}))
+	if result.Passed || len(result.SucceededExpectations) != 3 || len(result.FailedExpectations) != 1 {
+		t.Fatalf("expected only the second expectation of the step to fail, got %+v", result)
+	}
+	if failed := result.FailedExpectations[0]; failed.Step != "exec" || failed.Expectation.Datadog.Monitor.ID != "2" {
+		t.Errorf("expected the failure of monitor 2 in step exec, got %+v", failed)
+	}
+
+	if err := FailureError(result); err == nil || err.Error() != "expectations failed: step exec: monitor 2 is OK" {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	t.Run("each expectation fails with its own reason", func(t *testing.T) {
+		result := Evaluate(context.Background(), newService("1", "2"), "", expectations, time.Now(), time.Time{})
+		if len(result.FailedExpectations) != 2 || result.FailedExpectations[0].Reason != "monitor 1 is OK" || result.FailedExpectations[1].Reason != "monitor 2 is OK" {
+			t.Errorf("expected each expectation to fail with its own reason, got %+v", result.FailedExpectations)
+		}
+	})
+
+	t.Run("the window of the expectations", func(t *testing.T) {
+		service := newService()
+		startTime := time.Now().Add(-time.Minute)
+		endTime := startTime.Add(30 * time.Second)
+		Evaluate(context.Background(), service, "exec", expectations, startTime, endTime)
+
+		if calls := service.SetStartTimeCalls(); len(calls) == 0 || !calls[0].StartTime.Equal(startTime) {
+			t.Errorf("expected the window to start at %s, got %+v", startTime, calls)
+		}
+		if calls := service.SetEndTimeCalls(); len(calls) == 0 || !calls[0].EndTime.Equal(endTime) {
+			t.Errorf("expected the window to end at %s, got %+v", endTime, calls)
+		}
+	})
+}
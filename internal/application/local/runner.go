@@ -0,0 +1,380 @@
+// Package local runs a scenario end to end without the controller, e.g. from the command line while authoring it.
+// It builds, executes and evaluates the scenario with the same builders, executors and expectations as the controller,
+// but keeps its status in memory instead of the Scenario resource.
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/mrtc0/threatester/internal/application/cleanup"
+	"github.com/mrtc0/threatester/internal/application/expectation"
+	"github.com/mrtc0/threatester/internal/application/plan"
+	scenarioApplication "github.com/mrtc0/threatester/internal/application/scenario"
+	"github.com/mrtc0/threatester/internal/application/sigma"
+	batchv1 "k8s.io/api/batch/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Statuses of a scenario run locally, the same as the ones set by the controller.
+const (
+	StatusSucceeded = "Succeeded"
+	StatusFailed    = "Failed"
+	StatusDegraded  = "Degraded"
+	StatusTimedOut  = "TimedOut"
+	StatusCancelled = "Cancelled"
+	StatusPlanned   = "Planned"
+
+	// CleanupTimeout bounds the collection of the results, the cleanup and the deletion of the jobs after a run.
+	CleanupTimeout = 5 * time.Minute
+)
+
+type Runner struct {
+	Client              client.Client
+	ScenarioJobExecutor scenarioApplication.ScenarioJobExecutor
+	ExpectationService  expectation.ExpectationService
+	CleanupService      cleanup.CleanupService
+	// SigmaRulesDir is the directory the paths of the Sigma rules referenced by the expectations are relative to.
+	SigmaRulesDir string
+	// Out receives the progress of the run.
+	Out io.Writer
+}
+
+// Supported returns an error when the scenario uses a feature that needs the controller.
+func Supported(scenario threatestergithubiov1alpha1.Scenario) error {
+	if scenario.Spec.Target != nil {
+		return errors.New("target is not supported in local mode")
+	}
+
+	// the suppressions are lifted by the controller, so a local run could leave the notifications muted
+	if scenario.Spec.Suppression != nil {
+		return errors.New("suppression is not supported in local mode")
+	}
+
+	for _, template := range scenario.Spec.Templates {
+		switch {
+		case template.Workload != nil:
+			return fmt.Errorf("template %s: workload is not supported in local mode", template.Name)
+		case template.Stratus != nil:
+			return fmt.Errorf("template %s: stratus is not supported in local mode", template.Name)
+		}
+	}
+
+	return nil
+}
+
+// Run runs the scenario and returns it with the status of the run.
+// An error is returned when the scenario cannot be run, while the failures of the run are reported in the status.
+// A dry run only renders the plan of the scenario into its status.
+func (r *Runner) Run(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario) (*threatestergithubiov1alpha1.Scenario, error) {
+	if err := scenario.ValidateCreate(); err != nil {
+		return nil, err
+	}
+
+	parameterized, err := scenarioApplication.ApplyParameters(scenario)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, templateRevisions, err := scenarioApplication.ResolveTemplates(ctx, r.Client, *parameterized)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := Supported(*resolved); err != nil {
+		return nil, err
+	}
+
+	resolved, sigmaRules, err := sigma.ResolveRules(ctx, r.Client, r.SigmaRulesDir, *resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	scenarioJobs, err := scenarioApplication.BuildScenarioJobs(*resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	if scenario.Spec.DryRun {
+		return r.plan(ctx, scenario, *resolved, scenarioJobs, templateRevisions)
+	}
+
+	deadline, err := scenarioApplication.ParseTimeout(resolved.Spec.Deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	runCtx := ctx
+	if deadline > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, deadline)
+		defer cancel()
+	}
+
+	// the results are collected and the jobs are cleaned up even when the run is interrupted
+	afterCtx, cancelAfter := context.WithTimeout(context.Background(), CleanupTimeout)
+	defer cancelAfter()
+
+	result := scenario.DeepCopy()
+	startTime := metav1.Now()
+	result.Status.StartTime = &startTime
+	result.Status.TemplateRevisions = templateRevisions
+
+	// the jobs are not owned by a Scenario resource, so nothing else would delete them.
+	// Only the jobs created by this run are deleted, leaving alone the jobs that already existed.
+	created := &createdJobs{names: map[string]bool{}}
+	defer func() {
+		for _, scenarioJob := range created.filter(scenarioJobs) {
+			if err := r.ScenarioJobExecutor.DeleteScenarioJob(afterCtx, scenarioJob); err != nil {
+				r.printf("failed to delete job %s: %s", scenarioJob.Name, err)
+			}
+		}
+	}()
+
+	err = r.execute(runCtx, resolved, scenarioJobs, created, &result.Status)
+	for _, scenarioJob := range created.filter(scenarioJobs) {
+		containers, collectErr := r.ScenarioJobExecutor.CollectResults(afterCtx, scenarioJob)
+		if collectErr != nil {
+			r.printf("failed to collect results of job %s: %s", scenarioJob.Name, collectErr)
+		}
+		result.Status.Containers = append(result.Status.Containers, containers...)
+	}
+
+	if err != nil {
+		message := err.Error()
+		if summary := scenarioApplication.SummarizeFailedContainers(result.Status.Containers); summary != "" {
+			message = fmt.Sprintf("%s: %s", message, summary)
+		}
+
+		status := interruptedStatus(ctx, runCtx)
+		if status == "" {
+			status = StatusFailed
+			if scenarioApplication.FindStuckPodError(err) != nil {
+				status = StatusDegraded
+			}
+		}
+
+		r.cleanup(afterCtx, result, *resolved)
+		setStatus(result, status, message)
+
+		return result, nil
+	}
+
+	r.printf("evaluating expectations")
+	result.Status.Result = r.runExpectations(runCtx, resolved, result.Status.Steps, startTime.Time)
+	result.Status.SigmaRules = sigma.RuleStatuses(sigmaRules, result.Status.Result)
+	r.cleanup(afterCtx, result, *resolved)
+
+	if err := expectation.FailureError(result.Status.Result); err != nil {
+		status := interruptedStatus(ctx, runCtx)
+		if status == "" {
+			status = StatusFailed
+		}
+		setStatus(result, status, err.Error())
+
+		return result, nil
+	}
+
+	setStatus(result, StatusSucceeded, "Successfully run scenario expectations")
+
+	return result, nil
+}
+
+// plan renders the plan of the scenario into its status. The scenario is Planned, or Failed when one of its
+// expectations is not valid.
+func (r *Runner) plan(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario, resolved threatestergithubiov1alpha1.Scenario, scenarioJobs []batchv1.Job, templateRevisions []threatestergithubiov1alpha1.TemplateRevision) (*threatestergithubiov1alpha1.Scenario, error) {
+	r.printf("validating expectations")
+	scenarioPlan, err := plan.Build(ctx, r.ExpectationService, scenario, resolved, scenarioJobs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := scenario.DeepCopy()
+	result.Status.Plan = scenarioPlan
+	result.Status.TemplateRevisions = templateRevisions
+	if err := plan.InvalidError(*scenarioPlan); err != nil {
+		setStatus(result, StatusFailed, err.Error())
+		return result, nil
+	}
+
+	setStatus(result, StatusPlanned, fmt.Sprintf("Planned %d resources and %d expectations", len(scenarioPlan.Resources), len(scenarioPlan.Expectations)))
+
+	return result, nil
+}
+
+// execute runs the scenario job, or the jobs of the steps with the same scheduler as the controller.
+func (r *Runner) execute(ctx context.Context, scenario *threatestergithubiov1alpha1.Scenario, scenarioJobs []batchv1.Job, created *createdJobs, status *threatestergithubiov1alpha1.ScenarioStatus) error {
+	if len(scenario.Spec.Steps) == 0 {
+		errs := []error{}
+		for _, scenarioJob := range scenarioJobs {
+			r.printf("running job %s", scenarioJob.Name)
+			if err := r.executeJob(ctx, scenarioJob, created); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		return utilerrors.NewAggregate(errs)
+	}
+
+	jobs := map[string]batchv1.Job{}
+	for _, scenarioJob := range scenarioJobs {
+		jobs[scenarioJob.Labels[scenarioApplication.StepLabel]] = scenarioJob
+	}
+
+	return scenarioApplication.ExecuteSteps(ctx, *scenario,
+		func(ctx context.Context, step threatestergithubiov1alpha1.Step) error {
+			return r.executeJob(ctx, jobs[step.Name], created)
+		},
+		func(stepStatus threatestergithubiov1alpha1.StepStatus) error {
+			switch stepStatus.Phase {
+			case threatestergithubiov1alpha1.StepPhaseRunning:
+				r.printf("running step %s", stepStatus.Name)
+			case threatestergithubiov1alpha1.StepPhaseFailed:
+				r.printf("step %s failed: %s", stepStatus.Name, stepStatus.Message)
+			case threatestergithubiov1alpha1.StepPhaseSkipped:
+				r.printf("skipped step %s: %s", stepStatus.Name, stepStatus.Message)
+			}
+
+			setStepStatus(status, stepStatus)
+			return nil
+		},
+	)
+}
+
+// executeJob runs the job and records it as created unless it already existed.
+func (r *Runner) executeJob(ctx context.Context, scenarioJob batchv1.Job, created *createdJobs) error {
+	err := r.ScenarioJobExecutor.Execute(ctx, scenarioJob)
+	if !apierrors.IsAlreadyExists(err) {
+		created.add(scenarioJob.Name)
+	}
+
+	return err
+}
+
+// runExpectations evaluates the expectations of each step between the time the step started and the time it completed,
+// then the expectations of the scenario, as the controller does.
+func (r *Runner) runExpectations(ctx context.Context, scenario *threatestergithubiov1alpha1.Scenario, steps []threatestergithubiov1alpha1.StepStatus, startTime time.Time) threatestergithubiov1alpha1.ExpectationResult {
+	result := threatestergithubiov1alpha1.ExpectationResult{
+		Passed:                true,
+		SucceededExpectations: []threatestergithubiov1alpha1.Expectation{},
+		FailedExpectations:    []threatestergithubiov1alpha1.FailedExpectation{},
+	}
+
+	stepStartTimes := map[string]time.Time{}
+	stepEndTimes := map[string]time.Time{}
+	for _, step := range steps {
+		if step.StartTime != nil {
+			stepStartTimes[step.Name] = step.StartTime.Time
+		}
+		if step.CompletionTime != nil {
+			stepEndTimes[step.Name] = step.CompletionTime.Time
+		}
+	}
+
+	hasStepExpectations := false
+	for _, step := range scenario.Spec.Steps {
+		if len(step.Expectations) == 0 {
+			continue
+		}
+		hasStepExpectations = true
+
+		stepStartTime, ok := stepStartTimes[step.Name]
+		if !ok {
+			stepStartTime = startTime
+		}
+
+		expectation.MergeResult(&result, expectation.Evaluate(ctx, r.ExpectationService, step.Name, step.Expectations, stepStartTime, stepEndTimes[step.Name]))
+	}
+
+	// a scenario without any expectation is evaluated as by the controller, which fails since there is nothing to expect
+	if len(scenario.Spec.Expectations) > 0 || !hasStepExpectations {
+		expectation.MergeResult(&result, expectation.Evaluate(ctx, r.ExpectationService, "", scenario.Spec.Expectations, startTime, time.Time{}))
+	}
+
+	result.Duration = time.Since(startTime)
+
+	return result
+}
+
+// cleanup runs the cleanup phase of the scenario and records when it completed.
+func (r *Runner) cleanup(ctx context.Context, result *threatestergithubiov1alpha1.Scenario, scenario threatestergithubiov1alpha1.Scenario) {
+	if err := r.CleanupService.Cleanup(ctx, scenario); err != nil {
+		r.printf("failed to clean up: %s", err)
+		return
+	}
+
+	cleanupTime := metav1.Now()
+	result.Status.CleanupTime = &cleanupTime
+}
+
+func (r *Runner) printf(format string, args ...interface{}) {
+	if r.Out != nil {
+		fmt.Fprintf(r.Out, format+"\n", args...)
+	}
+}
+
+// interruptedStatus returns the status of a run interrupted by its caller or by its deadline, or an empty status.
+func interruptedStatus(ctx context.Context, runCtx context.Context) string {
+	switch {
+	case ctx.Err() != nil:
+		return StatusCancelled
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		return StatusTimedOut
+	default:
+		return ""
+	}
+}
+
+// createdJobs is the set of the jobs created by a run, which may run the jobs of its steps in parallel.
+type createdJobs struct {
+	mu    sync.Mutex
+	names map[string]bool
+}
+
+func (c *createdJobs) add(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.names[name] = true
+}
+
+// filter returns the created jobs among the given jobs, in their order.
+func (c *createdJobs) filter(scenarioJobs []batchv1.Job) []batchv1.Job {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	jobs := []batchv1.Job{}
+	for _, scenarioJob := range scenarioJobs {
+		if c.names[scenarioJob.Name] {
+			jobs = append(jobs, scenarioJob)
+		}
+	}
+
+	return jobs
+}
+
+// setStepStatus records the status of a step, replacing the previous status of the step.
+func setStepStatus(status *threatestergithubiov1alpha1.ScenarioStatus, stepStatus threatestergithubiov1alpha1.StepStatus) {
+	for i := range status.Steps {
+		if status.Steps[i].Name == stepStatus.Name {
+			status.Steps[i] = stepStatus
+			return
+		}
+	}
+
+	status.Steps = append(status.Steps, stepStatus)
+}
+
+func setStatus(scenario *threatestergithubiov1alpha1.Scenario, status string, message string) {
+	scenario.Status.Status = status
+	meta.SetStatusCondition(&scenario.Status.Conditions, metav1.Condition{Type: status, Status: metav1.ConditionTrue, Reason: status, Message: message})
+}
@@ -0,0 +1,245 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/mrtc0/threatester/internal/application/cleanup"
+	"github.com/mrtc0/threatester/internal/application/expectation"
+	scenarioApplication "github.com/mrtc0/threatester/internal/application/scenario"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newScenario(steps []threatestergithubiov1alpha1.Step) threatestergithubiov1alpha1.Scenario {
+	monitor := threatestergithubiov1alpha1.Expectation{
+		Datadog: &threatestergithubiov1alpha1.DatadogExpectation{Monitor: &threatestergithubiov1alpha1.DatadogMonitor{ID: "1", Status: "Alert"}},
+	}
+
+	return threatestergithubiov1alpha1.Scenario{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-scenario", Namespace: "default"},
+		Spec: threatestergithubiov1alpha1.ScenarioSpec{
+			Templates: []threatestergithubiov1alpha1.Template{
+				{Name: "recon", Container: &corev1.Container{Name: "recon", Image: "alpine"}},
+				{Name: "credential-access", Container: &corev1.Container{Name: "credential-access", Image: "alpine"}},
+			},
+			Steps:        steps,
+			Expectations: []threatestergithubiov1alpha1.Expectation{monitor},
+		},
+	}
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name          string
+		steps         []threatestergithubiov1alpha1.Step
+		failedJob     string
+		existingJob   string
+		passed        bool
+		expectedJobs  []string
+		expected      string
+		expectedSteps map[string]threatestergithubiov1alpha1.StepPhase
+	}{
+		{
+			name:         "scenario detected",
+			passed:       true,
+			expectedJobs: []string{scenarioApplication.ScenarioJobName("test-scenario")},
+			expected:     StatusSucceeded,
+		},
+		{
+			name:         "scenario missed",
+			passed:       false,
+			expectedJobs: []string{scenarioApplication.ScenarioJobName("test-scenario")},
+			expected:     StatusFailed,
+		},
+		{
+			name:         "existing job",
+			existingJob:  scenarioApplication.ScenarioJobName("test-scenario"),
+			passed:       true,
+			expectedJobs: []string{scenarioApplication.ScenarioJobName("test-scenario")},
+			expected:     StatusFailed,
+		},
+		{
+			name: "failed step",
+			steps: []threatestergithubiov1alpha1.Step{
+				{Name: "recon", Template: "recon"},
+				{Name: "credential-access", Template: "credential-access"},
+			},
+			failedJob:    "test-scenario-recon",
+			passed:       true,
+			expectedJobs: []string{"test-scenario-recon"},
+			expected:     StatusFailed,
+			expectedSteps: map[string]threatestergithubiov1alpha1.StepPhase{
+				"recon":             threatestergithubiov1alpha1.StepPhaseFailed,
+				"credential-access": threatestergithubiov1alpha1.StepPhaseSkipped,
+			},
+		},
+		{
+			name: "steps in the order of their dependencies",
+			steps: []threatestergithubiov1alpha1.Step{
+				{Name: "credential-access", Template: "credential-access", DependsOn: []string{"recon"}},
+				{Name: "recon", Template: "recon"},
+			},
+			passed:       true,
+			expectedJobs: []string{"test-scenario-recon", "test-scenario-credential-access"},
+			expected:     StatusSucceeded,
+			expectedSteps: map[string]threatestergithubiov1alpha1.StepPhase{
+				"recon":             threatestergithubiov1alpha1.StepPhaseSucceeded,
+				"credential-access": threatestergithubiov1alpha1.StepPhaseSucceeded,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executed := []string{}
+			deleted := []string{}
+			cleanedUp := false
+			runner := &Runner{
+				Client: fake.NewClientBuilder().Build(),
+				ScenarioJobExecutor: &scenarioApplication.ScenarioJobExecutorMock{
+					ExecuteFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						executed = append(executed, scenarioJob.Name)
+						if scenarioJob.Name == tt.failedJob {
+							return errors.New("job failed")
+						}
+						if scenarioJob.Name == tt.existingJob {
+							return apierrors.NewAlreadyExists(batchv1.Resource("jobs"), scenarioJob.Name)
+						}
+						return nil
+					},
+					CollectResultsFunc: func(ctx context.Context, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.ContainerResult, error) {
+						return []threatestergithubiov1alpha1.ContainerResult{{Job: scenarioJob.Name}}, nil
+					},
+					DeleteScenarioJobFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						deleted = append(deleted, scenarioJob.Name)
+						return nil
+					},
+				},
+				ExpectationService: &expectation.ExpectationServiceMock{
+					RunExpectationFunc: func(ctx context.Context) (bool, error) {
+						return tt.passed, nil
+					},
+					SetExpectationsFunc: func(expectations []threatestergithubiov1alpha1.Expectation) {},
+					SetStartTimeFunc:    func(startTime time.Time) {},
+					SetEndTimeFunc:      func(endTime time.Time) {},
+				},
+				CleanupService: &cleanup.CleanupServiceMock{
+					CleanupFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario) error {
+						cleanedUp = true
+						return nil
+					},
+				},
+			}
+
+			result, err := runner.Run(context.Background(), newScenario(tt.steps))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.Status.Status != tt.expected {
+				t.Errorf("expected status %s, got %s", tt.expected, result.Status.Status)
+			}
+			if len(executed) != len(tt.expectedJobs) {
+				t.Fatalf("expected jobs %v to run, got %v", tt.expectedJobs, executed)
+			}
+			for i := range executed {
+				if executed[i] != tt.expectedJobs[i] {
+					t.Errorf("expected jobs %v to run, got %v", tt.expectedJobs, executed)
+				}
+			}
+			created := []string{}
+			for _, name := range executed {
+				if name != tt.existingJob {
+					created = append(created, name)
+				}
+			}
+			sort.Strings(created)
+			sort.Strings(deleted)
+			if !reflect.DeepEqual(deleted, created) || len(result.Status.Containers) != len(created) {
+				t.Errorf("expected the results of the created jobs %v to be collected before they are deleted, got %d results of %v", created, len(result.Status.Containers), deleted)
+			}
+			if !cleanedUp || result.Status.CleanupTime == nil {
+				t.Error("expected the scenario to be cleaned up")
+			}
+
+			for _, step := range result.Status.Steps {
+				if step.Phase != tt.expectedSteps[step.Name] {
+					t.Errorf("expected step %s to be %s, got %s", step.Name, tt.expectedSteps[step.Name], step.Phase)
+				}
+			}
+		})
+	}
+}
+
+func TestRunUnsupported(t *testing.T) {
+	stratus := newScenario(nil)
+	stratus.Spec.Templates[0].Container = nil
+	stratus.Spec.Templates[0].Stratus = &threatestergithubiov1alpha1.StratusTemplate{Technique: "k8s.credential-access.steal-serviceaccount-token"}
+
+	suppression := newScenario(nil)
+	suppression.Spec.Suppression = &threatestergithubiov1alpha1.Suppression{Datadog: &threatestergithubiov1alpha1.DatadogSuppression{}}
+
+	tests := []struct {
+		name     string
+		scenario threatestergithubiov1alpha1.Scenario
+	}{
+		{name: "Stratus Red Team templates", scenario: stratus},
+		{name: "suppression", scenario: suppression},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &Runner{Client: fake.NewClientBuilder().Build()}
+			if _, err := runner.Run(context.Background(), tt.scenario); err == nil {
+				t.Errorf("expected %s to be unsupported", tt.name)
+			}
+			if err := Supported(tt.scenario); err == nil {
+				t.Errorf("expected %s to be unsupported", tt.name)
+			}
+		})
+	}
+}
+
+func TestRunDryRun(t *testing.T) {
+	scenario := newScenario(nil)
+	scenario.Spec.DryRun = true
+
+	runner := &Runner{
+		Client: fake.NewClientBuilder().Build(),
+		ScenarioJobExecutor: &scenarioApplication.ScenarioJobExecutorMock{
+			ExecuteFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+				t.Errorf("expected job %s not to run", scenarioJob.Name)
+				return nil
+			},
+		},
+		ExpectationService: &expectation.ExpectationServiceMock{
+			ValidateExpectationFunc: func(ctx context.Context, expectation threatestergithubiov1alpha1.Expectation) error {
+				return errors.New("monitor 1 is not found")
+			},
+		},
+	}
+
+	result, err := runner.Run(context.Background(), scenario)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Status.Status != StatusFailed {
+		t.Errorf("expected status %s, got %s", StatusFailed, result.Status.Status)
+	}
+	if result.Status.Plan == nil || len(result.Status.Plan.Resources) != 1 || result.Status.Plan.Resources[0].Name != scenarioApplication.ScenarioJobName("test-scenario") {
+		t.Errorf("expected the plan of the scenario job, got %+v", result.Status.Plan)
+	}
+	if result.Status.StartTime != nil {
+		t.Error("expected the scenario not to start")
+	}
+}
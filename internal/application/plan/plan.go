@@ -0,0 +1,202 @@
+// Package plan renders what a scenario would create and evaluate, so that it can be reviewed before it runs.
+package plan
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/mrtc0/threatester/internal/application/expectation"
+	scenarioApplication "github.com/mrtc0/threatester/internal/application/scenario"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	KindJob                   = "Job"
+	KindPersistentVolumeClaim = "PersistentVolumeClaim"
+	// KindWorkload is the kind of the templates running inside an existing pod, which create no resource.
+	KindWorkload = "Workload"
+)
+
+// Build renders the plan of the scenario.
+// The parameters are taken from the scenario as written, while the resources and the expectations are taken from the
+// resolved scenario, i.e. with its parameters substituted and its templates and Sigma rules resolved, along with the jobs built for it.
+// Each expectation is validated against its detection backend.
+func Build(ctx context.Context, service expectation.ExpectationService, scenario threatestergithubiov1alpha1.Scenario, resolved threatestergithubiov1alpha1.Scenario, scenarioJobs []batchv1.Job) (*threatestergithubiov1alpha1.ScenarioPlan, error) {
+	plan := &threatestergithubiov1alpha1.ScenarioPlan{Generation: scenario.Generation}
+
+	parameters, err := parameterValues(scenario)
+	if err != nil {
+		return nil, err
+	}
+	plan.Parameters = parameters
+
+	for _, scenarioJob := range scenarioJobs {
+		resource, err := jobResource(scenarioJob)
+		if err != nil {
+			return nil, err
+		}
+		plan.Resources = append(plan.Resources, resource)
+	}
+
+	if scenarioApplication.HasStratusTemplates(resolved.Spec.Templates) {
+		resources, err := stratusResources(resolved)
+		if err != nil {
+			return nil, err
+		}
+		plan.Resources = append(plan.Resources, resources...)
+	}
+
+	workloads, err := workloadResources(resolved)
+	if err != nil {
+		return nil, err
+	}
+	plan.Resources = append(plan.Resources, workloads...)
+
+	for _, step := range resolved.Spec.Steps {
+		plan.Expectations = append(plan.Expectations, validateExpectations(ctx, service, step.Name, step.Expectations)...)
+	}
+	plan.Expectations = append(plan.Expectations, validateExpectations(ctx, service, "", resolved.Spec.Expectations)...)
+
+	return plan, nil
+}
+
+// InvalidError returns an error describing the expectations of the plan that are not valid, or nil when all of them are.
+func InvalidError(plan threatestergithubiov1alpha1.ScenarioPlan) error {
+	reasons := []string{}
+	for _, planned := range plan.Expectations {
+		if planned.Valid {
+			continue
+		}
+
+		if planned.Step != "" {
+			reasons = append(reasons, fmt.Sprintf("step %s: %s", planned.Step, planned.Message))
+		} else {
+			reasons = append(reasons, planned.Message)
+		}
+	}
+
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("expectations are not valid: %s", strings.Join(reasons, "; "))
+}
+
+// parameterValues returns the values of the parameters of the scenario, with the defaults of the missing arguments.
+func parameterValues(scenario threatestergithubiov1alpha1.Scenario) (map[string]string, error) {
+	if len(scenario.Spec.Parameters) == 0 && len(scenario.Spec.Arguments) == 0 {
+		return nil, nil
+	}
+
+	specPath := field.NewPath("spec")
+	values, errs := threatestergithubiov1alpha1.ParameterValues(scenario.Spec.Parameters, scenario.Spec.Arguments, specPath.Child("parameters"), specPath.Child("arguments"))
+	if len(errs) > 0 {
+		return nil, errs.ToAggregate()
+	}
+
+	parameters := map[string]string{}
+	for name, value := range values {
+		parameters[name] = fmt.Sprint(value)
+	}
+
+	return parameters, nil
+}
+
+func jobResource(scenarioJob batchv1.Job) (threatestergithubiov1alpha1.PlannedResource, error) {
+	job := scenarioJob.DeepCopy()
+	job.SetGroupVersionKind(batchv1.SchemeGroupVersion.WithKind(KindJob))
+
+	manifest, err := yaml.Marshal(job)
+	if err != nil {
+		return threatestergithubiov1alpha1.PlannedResource{}, fmt.Errorf("failed to render job %s: %w", job.Name, err)
+	}
+
+	return threatestergithubiov1alpha1.PlannedResource{
+		Kind:               KindJob,
+		Name:               job.Name,
+		Step:               job.Labels[scenarioApplication.StepLabel],
+		Node:               job.Annotations[scenarioApplication.NodeAnnotation],
+		ServiceAccountName: job.Spec.Template.Spec.ServiceAccountName,
+		Manifest:           string(manifest),
+	}, nil
+}
+
+// stratusResources returns the volume keeping the state of the Stratus Red Team techniques and the job reverting them.
+func stratusResources(scenario threatestergithubiov1alpha1.Scenario) ([]threatestergithubiov1alpha1.PlannedResource, error) {
+	claim := scenarioApplication.BuildStratusStateClaim(scenario)
+	claim.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind(KindPersistentVolumeClaim))
+
+	manifest, err := yaml.Marshal(claim)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render persistent volume claim %s: %w", claim.Name, err)
+	}
+
+	cleanupJob, err := scenarioApplication.BuildStratusCleanupJob(scenario)
+	if err != nil {
+		return nil, err
+	}
+
+	cleanupResource, err := jobResource(*cleanupJob)
+	if err != nil {
+		return nil, err
+	}
+
+	return []threatestergithubiov1alpha1.PlannedResource{
+		{Kind: KindPersistentVolumeClaim, Name: claim.Name, Manifest: string(manifest)},
+		cleanupResource,
+	}, nil
+}
+
+// workloadResources returns the templates running inside existing pods, with the steps running them.
+func workloadResources(scenario threatestergithubiov1alpha1.Scenario) ([]threatestergithubiov1alpha1.PlannedResource, error) {
+	steps := map[string][]string{}
+	for _, step := range scenario.Spec.Steps {
+		steps[step.Template] = append(steps[step.Template], step.Name)
+	}
+
+	resources := []threatestergithubiov1alpha1.PlannedResource{}
+	for _, template := range scenario.Spec.Templates {
+		if template.Workload == nil {
+			continue
+		}
+
+		manifest, err := yaml.Marshal(template)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render template %s: %w", template.Name, err)
+		}
+
+		// a scenario without steps runs all of its templates
+		templateSteps := steps[template.Name]
+		if len(scenario.Spec.Steps) == 0 {
+			templateSteps = []string{""}
+		}
+		sort.Strings(templateSteps)
+
+		for _, step := range templateSteps {
+			resources = append(resources, threatestergithubiov1alpha1.PlannedResource{Kind: KindWorkload, Name: template.Name, Step: step, Manifest: string(manifest)})
+		}
+	}
+
+	return resources, nil
+}
+
+func validateExpectations(ctx context.Context, service expectation.ExpectationService, step string, expectations []threatestergithubiov1alpha1.Expectation) []threatestergithubiov1alpha1.PlannedExpectation {
+	planned := []threatestergithubiov1alpha1.PlannedExpectation{}
+	for _, expect := range expectations {
+		p := threatestergithubiov1alpha1.PlannedExpectation{Step: step, Expectation: expect, Valid: true}
+		if err := service.ValidateExpectation(ctx, expect); err != nil {
+			p.Valid = false
+			p.Message = err.Error()
+		}
+
+		planned = append(planned, p)
+	}
+
+	return planned
+}
@@ -0,0 +1,164 @@
+package plan
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/mrtc0/threatester/internal/application/expectation"
+	scenarioApplication "github.com/mrtc0/threatester/internal/application/scenario"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func newValidator(invalid map[string]error) expectation.ExpectationService {
+	return &expectation.ExpectationServiceMock{
+		ValidateExpectationFunc: func(ctx context.Context, expectation threatestergithubiov1alpha1.Expectation) error {
+			return invalid[expectation.Datadog.Monitor.ID]
+		},
+		RunExpectationFunc: func(ctx context.Context) (bool, error) {
+			return false, errors.New("no expectation is expected to run")
+		},
+		SetExpectationsFunc: func(expectations []threatestergithubiov1alpha1.Expectation) {},
+		SetStartTimeFunc:    func(startTime time.Time) {},
+		SetEndTimeFunc:      func(endTime time.Time) {},
+	}
+}
+
+func monitorExpectation(id string) threatestergithubiov1alpha1.Expectation {
+	return threatestergithubiov1alpha1.Expectation{
+		Datadog: &threatestergithubiov1alpha1.DatadogExpectation{Monitor: &threatestergithubiov1alpha1.DatadogMonitor{ID: id, Status: "Alert"}},
+	}
+}
+
+func TestBuild(t *testing.T) {
+	scenario := threatestergithubiov1alpha1.Scenario{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-scenario", Namespace: "default", Generation: 3},
+		Spec: threatestergithubiov1alpha1.ScenarioSpec{
+			Parameters: []threatestergithubiov1alpha1.Parameter{
+				{Name: "image", Type: threatestergithubiov1alpha1.ParameterTypeString, Default: pointer.String("alpine")},
+				{Name: "monitor", Type: threatestergithubiov1alpha1.ParameterTypeString},
+			},
+			Arguments: map[string]string{"monitor": "1"},
+			Templates: []threatestergithubiov1alpha1.Template{
+				{Name: "recon", Container: &corev1.Container{Name: "recon", Image: "{{ .Params.image }}"}},
+				{
+					Name:      "exec",
+					Container: &corev1.Container{Name: "exec", Image: "alpine", Command: []string{"cat", "/etc/shadow"}},
+					Workload:  &threatestergithubiov1alpha1.Workload{Selector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+				},
+			},
+			PodTemplate: &threatestergithubiov1alpha1.PodTemplate{ServiceAccountName: "attacker"},
+			Steps: []threatestergithubiov1alpha1.Step{
+				{Name: "recon", Template: "recon", Expectations: []threatestergithubiov1alpha1.Expectation{monitorExpectation("2")}},
+				{Name: "exec", Template: "exec"},
+			},
+			Expectations: []threatestergithubiov1alpha1.Expectation{monitorExpectation("{{ .Params.monitor }}")},
+		},
+	}
+
+	resolved, err := scenarioApplication.ApplyParameters(scenario)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	scenarioJobs, err := scenarioApplication.BuildScenarioJobs(*resolved)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	plan, err := Build(context.Background(), newValidator(map[string]error{"2": errors.New("monitor 2 is not found")}), scenario, *resolved, scenarioJobs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if plan.Generation != 3 {
+		t.Errorf("expected the plan of generation 3, got %d", plan.Generation)
+	}
+
+	expectedParameters := map[string]string{"image": "alpine", "monitor": "1"}
+	if !reflect.DeepEqual(plan.Parameters, expectedParameters) {
+		t.Errorf("expected parameters %v, got %v", expectedParameters, plan.Parameters)
+	}
+
+	if len(plan.Resources) != 2 {
+		t.Fatalf("expected a job and a workload, got %+v", plan.Resources)
+	}
+
+	job := plan.Resources[0]
+	if job.Kind != KindJob || job.Name != "test-scenario-recon" || job.Step != "recon" || job.ServiceAccountName != "attacker" {
+		t.Errorf("unexpected job %+v", job)
+	}
+	for _, expected := range []string{"kind: Job", "apiVersion: batch/v1", "image: alpine", "serviceAccountName: attacker"} {
+		if !strings.Contains(job.Manifest, expected) {
+			t.Errorf("expected the manifest of the job to contain %q, got\n%s", expected, job.Manifest)
+		}
+	}
+
+	workload := plan.Resources[1]
+	if workload.Kind != KindWorkload || workload.Name != "exec" || workload.Step != "exec" || !strings.Contains(workload.Manifest, "app: web") {
+		t.Errorf("unexpected workload %+v", workload)
+	}
+
+	if len(plan.Expectations) != 2 {
+		t.Fatalf("expected 2 expectations, got %+v", plan.Expectations)
+	}
+	if step := plan.Expectations[0]; step.Step != "recon" || step.Valid || step.Message != "monitor 2 is not found" {
+		t.Errorf("expected the expectation of step recon not to be valid, got %+v", step)
+	}
+	if scenarioExpectation := plan.Expectations[1]; !scenarioExpectation.Valid || scenarioExpectation.Expectation.Datadog.Monitor.ID != "1" {
+		t.Errorf("expected the expectation of the scenario to be valid with its parameters substituted, got %+v", scenarioExpectation)
+	}
+
+	if err := InvalidError(*plan); err == nil || err.Error() != "expectations are not valid: step recon: monitor 2 is not found" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestBuildWithStratus(t *testing.T) {
+	scenario := threatestergithubiov1alpha1.Scenario{
+		ObjectMeta: metav1.ObjectMeta{Name: "stratus", Namespace: "default"},
+		Spec: threatestergithubiov1alpha1.ScenarioSpec{
+			Templates: []threatestergithubiov1alpha1.Template{
+				{Name: "steal-token", Stratus: &threatestergithubiov1alpha1.StratusTemplate{Technique: "k8s.credential-access.steal-serviceaccount-token"}},
+			},
+			Expectations: []threatestergithubiov1alpha1.Expectation{monitorExpectation("1")},
+		},
+	}
+
+	scenarioJobs, err := scenarioApplication.BuildScenarioJobs(scenario)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	plan, err := Build(context.Background(), newValidator(nil), scenario, scenario, scenarioJobs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	kinds := []string{}
+	for _, resource := range plan.Resources {
+		kinds = append(kinds, resource.Kind+"/"+resource.Name)
+	}
+	expected := []string{
+		"Job/" + scenarioApplication.ScenarioJobName("stratus"),
+		"PersistentVolumeClaim/" + scenarioApplication.StratusStateClaimName("stratus"),
+		"Job/" + scenarioApplication.StratusCleanupJobName("stratus"),
+	}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Errorf("expected resources %v, got %v", expected, kinds)
+	}
+
+	if plan.Parameters != nil {
+		t.Errorf("expected no parameters, got %v", plan.Parameters)
+	}
+
+	if err := InvalidError(*plan); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package report
+
+import (
+	"bytes"
+	"net/http"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Paths of the manager metrics server serving the reports.
+const (
+	JUnitPath = "/reports/junit"
+	SARIFPath = "/reports/sarif"
+)
+
+var contentTypes = map[Format]string{
+	FormatJUnit: "application/xml",
+	FormatSARIF: "application/sarif+json",
+}
+
+// NewHandler serves the report of the scenarios in the format.
+// The scenarios are filtered by the namespace and labelSelector query parameters.
+func NewHandler(reader client.Reader, format Format) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		selector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		scenarios, err := Load(r.Context(), reader, r.URL.Query().Get("namespace"), selector)
+		if err != nil {
+			log.FromContext(r.Context()).Error(err, "failed to load scenarios")
+			http.Error(w, "failed to load scenarios", http.StatusInternalServerError)
+			return
+		}
+
+		body := &bytes.Buffer{}
+		if err := Write(body, format, scenarios); err != nil {
+			log.FromContext(r.Context()).Error(err, "failed to write report", "format", format)
+			http.Error(w, "failed to write report", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", contentTypes[format])
+		if _, err := body.WriteTo(w); err != nil {
+			log.FromContext(r.Context()).Error(err, "failed to write report", "format", format)
+		}
+	})
+}
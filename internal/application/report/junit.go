@@ -0,0 +1,108 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// JUnitTestSuites is the root of a JUnit XML report, with a test suite per scenario.
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+type JUnitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []JUnitTestCase `xml:"testcase"`
+}
+
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *JUnitMessage `xml:"failure,omitempty"`
+	Skipped   *JUnitMessage `xml:"skipped,omitempty"`
+}
+
+type JUnitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnit builds the JUnit XML report of the scenarios, with a test case per expectation.
+func JUnit(scenarios []threatestergithubiov1alpha1.Scenario) JUnitTestSuites {
+	report := JUnitTestSuites{Name: "threatester", Suites: []JUnitTestSuite{}}
+
+	var total time.Duration
+	for _, scenario := range scenarios {
+		duration := scenario.Status.Result.Duration
+		total += duration
+
+		suite := JUnitTestSuite{
+			Name:  client.ObjectKeyFromObject(&scenario).String(),
+			Time:  seconds(duration),
+			Cases: []JUnitTestCase{},
+		}
+		if scenario.Status.StartTime != nil {
+			suite.Timestamp = scenario.Status.StartTime.UTC().Format(time.RFC3339)
+		}
+
+		for _, c := range Cases(scenario) {
+			testCase := JUnitTestCase{Name: c.Name, ClassName: c.Scenario}
+			switch c.Status {
+			case CaseFailed:
+				testCase.Failure = &JUnitMessage{Message: c.Message, Type: "ExpectationFailed", Text: c.Message}
+				suite.Failures++
+			case CaseSkipped:
+				testCase.Skipped = &JUnitMessage{Message: c.Message}
+				suite.Skipped++
+			}
+
+			suite.Cases = append(suite.Cases, testCase)
+			suite.Tests++
+		}
+
+		report.Suites = append(report.Suites, suite)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+	}
+	report.Time = seconds(total)
+
+	return report
+}
+
+// WriteJUnit writes the JUnit XML report of the scenarios.
+func WriteJUnit(w io.Writer, scenarios []threatestergithubiov1alpha1.Scenario) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(JUnit(scenarios)); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(duration time.Duration) string {
+	return fmt.Sprintf("%.3f", duration.Seconds())
+}
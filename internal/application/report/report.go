@@ -0,0 +1,180 @@
+// Package report exports the results of the scenarios as test reports for CI, such as JUnit XML and SARIF.
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type Format string
+
+const (
+	FormatJUnit Format = "junit"
+	FormatSARIF Format = "sarif"
+)
+
+// Formats are the supported formats of the reports.
+var Formats = []Format{FormatJUnit, FormatSARIF}
+
+type CaseStatus string
+
+const (
+	CasePassed  CaseStatus = "passed"
+	CaseFailed  CaseStatus = "failed"
+	CaseSkipped CaseStatus = "skipped"
+)
+
+// failedScenarioStatuses are the statuses of a scenario whose run ended without passing.
+var failedScenarioStatuses = []string{"Failed", "Degraded", "TimedOut", "Cancelled"}
+
+// Case is the outcome of an expectation of a scenario, or of the scenario itself when its expectations were not evaluated.
+type Case struct {
+	// Scenario is the namespaced name of the scenario.
+	Scenario string
+	// Name describes the expectation, prefixed with its step.
+	Name string
+	Step string
+	Node string
+	// Expectation is nil for the case of the scenario itself.
+	Expectation *threatestergithubiov1alpha1.Expectation
+	Status      CaseStatus
+	// Message is the reason of a failed or skipped case.
+	Message string
+}
+
+// Cases returns the cases of the expectations evaluated by the latest run of the scenario.
+// A scenario without evaluated expectations has a single case, which fails when the run failed
+// and is skipped when the scenario has not run yet.
+func Cases(scenario threatestergithubiov1alpha1.Scenario) []Case {
+	name := client.ObjectKeyFromObject(&scenario).String()
+	result := scenario.Status.Result
+
+	cases := []Case{}
+	for i := range result.SucceededExpectations {
+		expectation := result.SucceededExpectations[i]
+		cases = append(cases, Case{Scenario: name, Name: Describe(expectation), Expectation: &expectation, Status: CasePassed})
+	}
+
+	for _, failed := range result.FailedExpectations {
+		expectation := failed.Expectation
+		caseName := Describe(expectation)
+		if failed.Node != "" {
+			caseName = fmt.Sprintf("%s on %s", caseName, failed.Node)
+		}
+		if failed.Step != "" {
+			caseName = fmt.Sprintf("%s: %s", failed.Step, caseName)
+		}
+
+		cases = append(cases, Case{
+			Scenario:    name,
+			Name:        caseName,
+			Step:        failed.Step,
+			Node:        failed.Node,
+			Expectation: &expectation,
+			Status:      CaseFailed,
+			Message:     failed.Reason,
+		})
+	}
+
+	if len(cases) > 0 {
+		return cases
+	}
+
+	scenarioCase := Case{Scenario: name, Name: "scenario", Status: CaseSkipped, Message: "the expectations have not been evaluated yet"}
+	for _, status := range failedScenarioStatuses {
+		if scenario.Status.Status != status {
+			continue
+		}
+
+		scenarioCase.Status = CaseFailed
+		scenarioCase.Message = fmt.Sprintf("the scenario is %s", status)
+		if condition := meta.FindStatusCondition(scenario.Status.Conditions, status); condition != nil && condition.Message != "" {
+			scenarioCase.Message = fmt.Sprintf("%s: %s", scenarioCase.Message, condition.Message)
+		}
+	}
+
+	return []Case{scenarioCase}
+}
+
+// Describe returns a one-line description of the expectation.
+func Describe(expectation threatestergithubiov1alpha1.Expectation) string {
+	description := "expectation"
+	switch {
+	case expectation.Datadog != nil && expectation.Datadog.Monitor != nil:
+		description = fmt.Sprintf("Datadog monitor %s is %s", expectation.Datadog.Monitor.ID, expectation.Datadog.Monitor.Status)
+	case expectation.Datadog != nil && expectation.Datadog.Logs != nil:
+		description = fmt.Sprintf("Datadog logs match %s", expectation.Datadog.Logs.Query)
+	case expectation.Elastic != nil:
+		description = fmt.Sprintf("Elasticsearch %s matches %s", expectation.Elastic.Index, expectation.Elastic.Query)
+	case expectation.Loki != nil:
+		description = fmt.Sprintf("Loki matches %s", expectation.Loki.Query)
+	}
+
+	if expectation.Sigma != nil {
+		description = fmt.Sprintf("Sigma rule %s: %s", expectation.Sigma.ID, description)
+	}
+
+	return description
+}
+
+// Write writes the report of the scenarios in the format.
+func Write(w io.Writer, format Format, scenarios []threatestergithubiov1alpha1.Scenario) error {
+	switch format {
+	case FormatJUnit:
+		return WriteJUnit(w, scenarios)
+	case FormatSARIF:
+		return WriteSARIF(w, scenarios)
+	default:
+		return fmt.Errorf("unknown report format %q, expected one of %s", format, strings.Join(formatNames(), ", "))
+	}
+}
+
+// Failed reports whether a case of the scenarios failed.
+func Failed(scenarios []threatestergithubiov1alpha1.Scenario) bool {
+	for _, scenario := range scenarios {
+		for _, c := range Cases(scenario) {
+			if c.Status == CaseFailed {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Load lists the scenarios of the namespace, or of all namespaces when it is empty, matching the selector.
+// The scenarios are sorted by namespaced name.
+func Load(ctx context.Context, reader client.Reader, namespace string, selector labels.Selector) ([]threatestergithubiov1alpha1.Scenario, error) {
+	if selector == nil {
+		selector = labels.Everything()
+	}
+
+	scenarios := &threatestergithubiov1alpha1.ScenarioList{}
+	if err := reader.List(ctx, scenarios, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+
+	items := scenarios.Items
+	sort.Slice(items, func(i, j int) bool {
+		return client.ObjectKeyFromObject(&items[i]).String() < client.ObjectKeyFromObject(&items[j]).String()
+	})
+
+	return items, nil
+}
+
+func formatNames() []string {
+	names := []string{}
+	for _, format := range Formats {
+		names = append(names, string(format))
+	}
+
+	return names
+}
@@ -0,0 +1,171 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newScenarios() []threatestergithubiov1alpha1.Scenario {
+	monitor := threatestergithubiov1alpha1.Expectation{
+		Datadog: &threatestergithubiov1alpha1.DatadogExpectation{Monitor: &threatestergithubiov1alpha1.DatadogMonitor{ID: "12345", Status: "Alert"}},
+	}
+	sigmaRule := threatestergithubiov1alpha1.Expectation{
+		Loki:  &threatestergithubiov1alpha1.LokiExpectation{URL: "http://loki:3100", Query: `{job="audit"} |= "exec"`},
+		Sigma: &threatestergithubiov1alpha1.SigmaRuleRef{ID: "0c3e1f4b-7a2d-4c5e-8f9a-b1c2d3e4f5a6", ConfigMapName: "sigma-rules"},
+	}
+
+	return []threatestergithubiov1alpha1.Scenario{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "credential-access", Namespace: "default"},
+			Status: threatestergithubiov1alpha1.ScenarioStatus{
+				Status: "Failed",
+				Result: threatestergithubiov1alpha1.ExpectationResult{
+					Duration:              90 * time.Second,
+					SucceededExpectations: []threatestergithubiov1alpha1.Expectation{monitor},
+					FailedExpectations: []threatestergithubiov1alpha1.FailedExpectation{
+						{Step: "exec", Expectation: sigmaRule, Reason: "no log lines matched"},
+					},
+				},
+				SigmaRules: []threatestergithubiov1alpha1.SigmaRuleStatus{{ID: "0c3e1f4b-7a2d-4c5e-8f9a-b1c2d3e4f5a6", Title: "Exec into pod"}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "attack-failed", Namespace: "default"},
+			Status: threatestergithubiov1alpha1.ScenarioStatus{
+				Status:     "Degraded",
+				Conditions: []metav1.Condition{{Type: "Degraded", Status: metav1.ConditionTrue, Reason: "ImagePullBackOff", Message: "image not found"}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "not-run", Namespace: "default"},
+		},
+	}
+}
+
+func TestCases(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario threatestergithubiov1alpha1.Scenario
+		expected []Case
+	}{
+		{
+			name:     "evaluated expectations",
+			scenario: newScenarios()[0],
+			expected: []Case{
+				{Name: "Datadog monitor 12345 is Alert", Status: CasePassed},
+				{Name: `exec: Sigma rule 0c3e1f4b-7a2d-4c5e-8f9a-b1c2d3e4f5a6: Loki matches {job="audit"} |= "exec"`, Step: "exec", Status: CaseFailed, Message: "no log lines matched"},
+			},
+		},
+		{
+			name:     "failed attack",
+			scenario: newScenarios()[1],
+			expected: []Case{{Name: "scenario", Status: CaseFailed, Message: "the scenario is Degraded: image not found"}},
+		},
+		{
+			name:     "not run",
+			scenario: newScenarios()[2],
+			expected: []Case{{Name: "scenario", Status: CaseSkipped, Message: "the expectations have not been evaluated yet"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cases := Cases(tt.scenario)
+			if len(cases) != len(tt.expected) {
+				t.Fatalf("expected %d cases, got %d", len(tt.expected), len(cases))
+			}
+
+			for i, c := range cases {
+				expected := tt.expected[i]
+				if c.Scenario != "default/"+tt.scenario.Name || c.Name != expected.Name || c.Step != expected.Step || c.Status != expected.Status || c.Message != expected.Message {
+					t.Errorf("expected case %+v, got %+v", expected, c)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := Write(buf, FormatJUnit, newScenarios()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.HasPrefix(buf.String(), xml.Header) {
+		t.Error("expected an XML header")
+	}
+
+	report := JUnitTestSuites{}
+	if err := xml.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("failed to parse the report: %v", err)
+	}
+
+	if report.Tests != 4 || report.Failures != 2 || report.Skipped != 1 || report.Time != "90.000" {
+		t.Errorf("unexpected totals %d tests, %d failures, %d skipped in %s", report.Tests, report.Failures, report.Skipped, report.Time)
+	}
+
+	suite := report.Suites[0]
+	if suite.Name != "default/credential-access" || suite.Tests != 2 || suite.Failures != 1 {
+		t.Errorf("unexpected suite %+v", suite)
+	}
+	if failure := suite.Cases[1].Failure; failure == nil || failure.Message != "no log lines matched" {
+		t.Errorf("expected the failure of the expectation, got %+v", failure)
+	}
+	if report.Suites[2].Cases[0].Skipped == nil {
+		t.Error("expected the scenario that has not run to be skipped")
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := Write(buf, FormatSARIF, newScenarios()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	log := SARIFLog{}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("failed to parse the report: %v", err)
+	}
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected log %+v", log)
+	}
+
+	run := log.Runs[0]
+	ruleIDs := []string{}
+	for _, rule := range run.Tool.Driver.Rules {
+		ruleIDs = append(ruleIDs, rule.ID)
+	}
+	if expected := "datadog/monitor/12345,0c3e1f4b-7a2d-4c5e-8f9a-b1c2d3e4f5a6,threatester/scenario"; strings.Join(ruleIDs, ",") != expected {
+		t.Errorf("expected rules %s, got %v", expected, ruleIDs)
+	}
+	if run.Tool.Driver.Rules[1].ShortDescription.Text != "Exec into pod" {
+		t.Errorf("expected the title of the Sigma rule, got %s", run.Tool.Driver.Rules[1].ShortDescription.Text)
+	}
+
+	levels := []string{}
+	for _, result := range run.Results {
+		levels = append(levels, result.Kind+"/"+result.Level)
+	}
+	if expected := "pass/none,fail/error,fail/error,notApplicable/none"; strings.Join(levels, ",") != expected {
+		t.Errorf("expected results %s, got %v", expected, levels)
+	}
+
+	location := run.Results[1].Locations[0].LogicalLocations[0]
+	if location.FullyQualifiedName != "default/credential-access/exec" || run.Results[1].RuleIndex != 1 {
+		t.Errorf("unexpected result %+v", run.Results[1])
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, Format("html"), newScenarios()); err == nil {
+		t.Error("expected an error")
+	}
+}
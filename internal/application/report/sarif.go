@@ -0,0 +1,168 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// SARIFLog is a SARIF log with a single run of threatester, see https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html.
+type SARIFLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+// SARIFRule is the detection proven by the expectations: a Sigma rule, or the backend of the expectations.
+type SARIFRule struct {
+	ID               string       `json:"id"`
+	ShortDescription SARIFMessage `json:"shortDescription"`
+}
+
+type SARIFResult struct {
+	RuleID     string            `json:"ruleId"`
+	RuleIndex  int               `json:"ruleIndex"`
+	Kind       string            `json:"kind"`
+	Level      string            `json:"level"`
+	Message    SARIFMessage      `json:"message"`
+	Locations  []SARIFLocation   `json:"locations"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+type SARIFLocation struct {
+	LogicalLocations []SARIFLogicalLocation `json:"logicalLocations"`
+}
+
+type SARIFLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// SARIF builds the SARIF log of the scenarios, with a result per expectation.
+// Failed cases are errors, so that code scanning fails on the detections that were missed.
+func SARIF(scenarios []threatestergithubiov1alpha1.Scenario) SARIFLog {
+	driver := SARIFDriver{Name: "threatester", InformationURI: "https://github.com/mrtc0/threatester", Rules: []SARIFRule{}}
+	ruleIndexes := map[string]int{}
+	results := []SARIFResult{}
+
+	for _, scenario := range scenarios {
+		sigmaTitles := map[string]string{}
+		for _, rule := range scenario.Status.SigmaRules {
+			sigmaTitles[rule.ID] = rule.Title
+		}
+
+		for _, c := range Cases(scenario) {
+			id, description := sarifRule(c, sigmaTitles)
+			index, ok := ruleIndexes[id]
+			if !ok {
+				index = len(driver.Rules)
+				ruleIndexes[id] = index
+				driver.Rules = append(driver.Rules, SARIFRule{ID: id, ShortDescription: SARIFMessage{Text: description}})
+			}
+
+			result := SARIFResult{
+				RuleID:     id,
+				RuleIndex:  index,
+				Kind:       "pass",
+				Level:      "none",
+				Message:    SARIFMessage{Text: fmt.Sprintf("%s passed", c.Name)},
+				Locations:  []SARIFLocation{{LogicalLocations: []SARIFLogicalLocation{sarifLocation(scenario, c)}}},
+				Properties: map[string]string{"scenario": c.Scenario},
+			}
+			switch c.Status {
+			case CaseFailed:
+				result.Kind = "fail"
+				result.Level = "error"
+				result.Message.Text = fmt.Sprintf("%s failed: %s", c.Name, c.Message)
+			case CaseSkipped:
+				result.Kind = "notApplicable"
+				result.Message.Text = fmt.Sprintf("%s skipped: %s", c.Name, c.Message)
+			}
+			if c.Step != "" {
+				result.Properties["step"] = c.Step
+			}
+			if c.Node != "" {
+				result.Properties["node"] = c.Node
+			}
+
+			results = append(results, result)
+		}
+	}
+
+	return SARIFLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs:    []SARIFRun{{Tool: SARIFTool{Driver: driver}, Results: results}},
+	}
+}
+
+// WriteSARIF writes the SARIF log of the scenarios.
+func WriteSARIF(w io.Writer, scenarios []threatestergithubiov1alpha1.Scenario) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(SARIF(scenarios))
+}
+
+// sarifRule returns the ID and the description of the rule of the case.
+func sarifRule(c Case, sigmaTitles map[string]string) (string, string) {
+	expectation := c.Expectation
+	switch {
+	case expectation == nil:
+		return "threatester/scenario", "Run of a scenario"
+	case expectation.Sigma != nil:
+		if title := sigmaTitles[expectation.Sigma.ID]; title != "" {
+			return expectation.Sigma.ID, title
+		}
+		return expectation.Sigma.ID, fmt.Sprintf("Sigma rule %s", expectation.Sigma.ID)
+	case expectation.Datadog != nil && expectation.Datadog.Monitor != nil:
+		return fmt.Sprintf("datadog/monitor/%s", expectation.Datadog.Monitor.ID), fmt.Sprintf("Datadog monitor %s", expectation.Datadog.Monitor.ID)
+	case expectation.Datadog != nil:
+		return "datadog/logs", "Datadog logs query"
+	case expectation.Elastic != nil:
+		return "elastic", "Elasticsearch query"
+	case expectation.Loki != nil:
+		return "loki", "Loki query"
+	default:
+		return "threatester/expectation", "Expectation of a scenario"
+	}
+}
+
+// sarifLocation locates the case in its scenario, or in the step of the scenario.
+func sarifLocation(scenario threatestergithubiov1alpha1.Scenario, c Case) SARIFLogicalLocation {
+	location := SARIFLogicalLocation{Name: scenario.Name, FullyQualifiedName: c.Scenario, Kind: "object"}
+	if c.Step != "" {
+		location.Name = c.Step
+		location.FullyQualifiedName = strings.Join([]string{c.Scenario, c.Step}, "/")
+	}
+
+	return location
+}
@@ -1,48 +1,203 @@
 package scenario
 
 import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"time"
+
 	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/utils/pointer"
 )
 
+const (
+	// ScenarioLabel is the label holding the name of the scenario that created the job.
+	ScenarioLabel = "threatester.github.io/scenario"
+	// StepLabel is the label holding the name of the step that the job runs.
+	StepLabel = "threatester.github.io/step"
+	// CancelAnnotation aborts the run in progress of the scenario when set to "true".
+	CancelAnnotation = "threatester.github.io/cancel"
+	// RerunAnnotation runs the scenario again, whatever the status of its previous run, when set to a new value.
+	RerunAnnotation = "threatester.github.io/rerun"
+)
+
 type ScenarioBuilder struct {
-	namespace string
-	podSpec   corev1.PodSpec
+	name           string
+	namespace      string
+	labels         map[string]string
+	podLabels      map[string]string
+	podAnnotations map[string]string
+	podSpec        corev1.PodSpec
+	jobTemplate    threatestergithubiov1alpha1.JobTemplate
+	timeout        time.Duration
+	errs           []error
 }
 
 func NewScenarioJobBuilder() *ScenarioBuilder {
 	return &ScenarioBuilder{
-		podSpec: corev1.PodSpec{Containers: []corev1.Container{}},
+		labels:         map[string]string{},
+		podLabels:      map[string]string{},
+		podAnnotations: map[string]string{},
+		podSpec:        corev1.PodSpec{Containers: []corev1.Container{}},
 	}
 }
 
+// WithScenarioJobs adds the containers of the templates to the pod.
+// A Stratus Red Team technique is warmed up in an init container and detonated in a container.
+// A template without a container fails the build.
 func (b *ScenarioBuilder) WithScenarioJobs(templates []threatestergithubiov1alpha1.Template) *ScenarioBuilder {
 	for _, template := range templates {
+		if err := checkTemplateContainer(template); err != nil {
+			b.errs = append(b.errs, err)
+			continue
+		}
+
+		if template.Stratus != nil {
+			b.podSpec.InitContainers = append(b.podSpec.InitContainers, stratusContainer(fmt.Sprintf("%s-warmup", template.Name), *template.Stratus, "warmup"))
+			b.podSpec.Containers = append(b.podSpec.Containers, stratusContainer(template.Name, *template.Stratus, "detonate"))
+			continue
+		}
+
 		b.podSpec.Containers = append(b.podSpec.Containers, *template.Container)
 	}
+	b.withStratusKubeconfigs(templates)
 
 	b.podSpec.RestartPolicy = corev1.RestartPolicyNever
 	return b
 }
 
+func (b *ScenarioBuilder) WithName(name string) *ScenarioBuilder {
+	b.name = name
+	return b
+}
+
 func (b *ScenarioBuilder) WithNamespace(namespace string) *ScenarioBuilder {
 	b.namespace = namespace
 	return b
 }
 
+func (b *ScenarioBuilder) WithLabels(labels map[string]string) *ScenarioBuilder {
+	for k, v := range labels {
+		b.labels[k] = v
+	}
+	return b
+}
+
+// WithPodTemplate merges the pod template into the pod spec of the job.
+// Scalar fields override the ones set before, lists are appended and maps are merged.
+func (b *ScenarioBuilder) WithPodTemplate(podTemplate *threatestergithubiov1alpha1.PodTemplate) *ScenarioBuilder {
+	if podTemplate == nil {
+		return b
+	}
+
+	for k, v := range podTemplate.Labels {
+		b.podLabels[k] = v
+	}
+	for k, v := range podTemplate.Annotations {
+		b.podAnnotations[k] = v
+	}
+
+	if podTemplate.ServiceAccountName != "" {
+		b.podSpec.ServiceAccountName = podTemplate.ServiceAccountName
+	}
+	if podTemplate.AutomountServiceAccountToken != nil {
+		b.podSpec.AutomountServiceAccountToken = podTemplate.AutomountServiceAccountToken
+	}
+	b.podSpec.HostPID = b.podSpec.HostPID || podTemplate.HostPID
+	b.podSpec.HostIPC = b.podSpec.HostIPC || podTemplate.HostIPC
+	b.podSpec.HostNetwork = b.podSpec.HostNetwork || podTemplate.HostNetwork
+	if podTemplate.SecurityContext != nil {
+		b.podSpec.SecurityContext = podTemplate.SecurityContext
+	}
+	b.podSpec.Volumes = append(b.podSpec.Volumes, podTemplate.Volumes...)
+	if len(podTemplate.NodeSelector) > 0 && b.podSpec.NodeSelector == nil {
+		b.podSpec.NodeSelector = map[string]string{}
+	}
+	for k, v := range podTemplate.NodeSelector {
+		b.podSpec.NodeSelector[k] = v
+	}
+	b.podSpec.Tolerations = append(b.podSpec.Tolerations, podTemplate.Tolerations...)
+	if podTemplate.Affinity != nil {
+		b.podSpec.Affinity = podTemplate.Affinity
+	}
+	b.podSpec.ImagePullSecrets = append(b.podSpec.ImagePullSecrets, podTemplate.ImagePullSecrets...)
+	if podTemplate.PriorityClassName != "" {
+		b.podSpec.PriorityClassName = podTemplate.PriorityClassName
+	}
+
+	return b
+}
+
+func (b *ScenarioBuilder) WithJobTemplate(jobTemplate *threatestergithubiov1alpha1.JobTemplate) *ScenarioBuilder {
+	if jobTemplate == nil {
+		return b
+	}
+
+	b.jobTemplate = *jobTemplate
+	return b
+}
+
+// WithTimeout sets the active deadline of the job, unless the job template already sets one.
+func (b *ScenarioBuilder) WithTimeout(timeout time.Duration) *ScenarioBuilder {
+	if timeout <= 0 {
+		return b
+	}
+
+	b.timeout = timeout
+	return b
+}
+
 func (b *ScenarioBuilder) Build() (*batchv1.Job, error) {
+	if len(b.errs) > 0 {
+		return nil, utilerrors.NewAggregate(b.errs)
+	}
+
+	podLabels := map[string]string{}
+	for k, v := range b.podLabels {
+		podLabels[k] = v
+	}
+	// the labels of the job identify the scenario and must not be overridden
+	for k, v := range b.labels {
+		podLabels[k] = v
+	}
+
+	backoffLimit := pointer.Int32(0)
+	if b.jobTemplate.BackoffLimit != nil {
+		backoffLimit = b.jobTemplate.BackoffLimit
+	}
+
+	activeDeadlineSeconds := b.jobTemplate.ActiveDeadlineSeconds
+	if activeDeadlineSeconds == nil && b.timeout > 0 {
+		activeDeadlineSeconds = pointer.Int64(int64(math.Ceil(b.timeout.Seconds())))
+	}
+
+	var podAnnotations map[string]string
+	if len(b.podAnnotations) > 0 {
+		podAnnotations = b.podAnnotations
+	}
+
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "threatester-scenario",
+			Name:      b.name,
 			Namespace: b.namespace,
+			Labels:    b.labels,
 		},
 		Spec: batchv1.JobSpec{
-			BackoffLimit: pointer.Int32(0),
-			Completions:  pointer.Int32(1),
+			BackoffLimit:            backoffLimit,
+			Completions:             pointer.Int32(1),
+			ActiveDeadlineSeconds:   activeDeadlineSeconds,
+			TTLSecondsAfterFinished: b.jobTemplate.TTLSecondsAfterFinished,
 			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      podLabels,
+					Annotations: podAnnotations,
+				},
 				Spec: b.podSpec,
 			},
 		},
@@ -50,3 +205,161 @@ func (b *ScenarioBuilder) Build() (*batchv1.Job, error) {
 
 	return job, nil
 }
+
+// BuildScenarioJobs builds the jobs to run for the scenario.
+// A scenario without steps runs all templates in a single job, otherwise each step runs in its own job.
+// Templates running inside an existing workload have no job.
+func BuildScenarioJobs(scenario threatestergithubiov1alpha1.Scenario) ([]batchv1.Job, error) {
+	labels := map[string]string{ScenarioLabel: scenario.Name}
+
+	timeout, err := ParseTimeout(scenario.Spec.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(scenario.Spec.Steps) == 0 {
+		templates := []threatestergithubiov1alpha1.Template{}
+		for _, template := range scenario.Spec.Templates {
+			if template.Workload != nil {
+				continue
+			}
+			templates = append(templates, template)
+		}
+
+		if len(templates) == 0 {
+			return []batchv1.Job{}, nil
+		}
+
+		builder := NewScenarioJobBuilder().
+			WithName(ScenarioJobName(scenario.Name)).
+			WithNamespace(scenario.Namespace).
+			WithLabels(labels).
+			WithScenarioJobs(templates).
+			WithJobTemplate(scenario.Spec.JobTemplate).
+			WithTimeout(timeout).
+			WithPodTemplate(scenario.Spec.PodTemplate)
+		for _, template := range templates {
+			builder.WithPodTemplate(template.PodTemplate)
+		}
+		if HasStratusTemplates(templates) {
+			builder.WithStratusState(StratusStateClaimName(scenario.Name))
+		}
+
+		job, err := builder.Build()
+		if err != nil {
+			return nil, err
+		}
+
+		return []batchv1.Job{*job}, nil
+	}
+
+	if HasStratusTemplates(scenario.Spec.Templates) {
+		if err := checkStratusSteps(scenario); err != nil {
+			return nil, err
+		}
+	}
+
+	jobs := []batchv1.Job{}
+	for _, step := range scenario.Spec.Steps {
+		template, err := FindTemplate(scenario.Spec.Templates, step.Template)
+		if err != nil {
+			return nil, fmt.Errorf("step %s: %w", step.Name, err)
+		}
+
+		if template.Workload != nil {
+			continue
+		}
+
+		builder := NewScenarioJobBuilder().
+			WithName(StepJobName(scenario.Name, step.Name)).
+			WithNamespace(scenario.Namespace).
+			WithLabels(labels).
+			WithLabels(map[string]string{StepLabel: step.Name}).
+			WithScenarioJobs([]threatestergithubiov1alpha1.Template{*template}).
+			WithJobTemplate(scenario.Spec.JobTemplate).
+			WithTimeout(timeout).
+			WithPodTemplate(scenario.Spec.PodTemplate).
+			WithPodTemplate(template.PodTemplate)
+		if template.Stratus != nil {
+			builder.WithStratusState(StratusStateClaimName(scenario.Name))
+		}
+
+		job, err := builder.Build()
+		if err != nil {
+			return nil, fmt.Errorf("step %s: %w", step.Name, err)
+		}
+
+		jobs = append(jobs, *job)
+	}
+
+	return jobs, nil
+}
+
+// checkTemplateContainer returns an error when a template run in a job has nothing to run, e.g. a template
+// resolved from a ScenarioTemplate without a container.
+func checkTemplateContainer(template threatestergithubiov1alpha1.Template) error {
+	if template.Container == nil && template.Stratus == nil {
+		return fmt.Errorf("template %s has no container", template.Name)
+	}
+
+	return nil
+}
+
+// ScenarioJobName returns the name of the job that runs all templates of a scenario without steps.
+func ScenarioJobName(scenarioName string) string {
+	return boundJobName(fmt.Sprintf("%s-job", scenarioName))
+}
+
+// StepJobName returns the name of the job that runs a step of the scenario.
+func StepJobName(scenarioName string, stepName string) string {
+	return boundJobName(fmt.Sprintf("%s-%s", scenarioName, stepName))
+}
+
+// boundJobName keeps the name of a job within the 63 characters of the job-name label set on its pods.
+// A longer name is truncated and suffixed with a hash of the whole name, so that it stays unique.
+func boundJobName(name string) string {
+	if len(name) <= validation.DNS1123LabelMaxLength {
+		return name
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	suffix := fmt.Sprintf("-%08x", h.Sum32())
+
+	return strings.TrimRight(name[:validation.DNS1123LabelMaxLength-len(suffix)], "-.") + suffix
+}
+
+// IsCancelRequested reports whether the scenario is annotated to cancel its run.
+func IsCancelRequested(scenario threatestergithubiov1alpha1.Scenario) bool {
+	return scenario.Annotations[CancelAnnotation] == "true"
+}
+
+// IsRerunRequested reports whether the scenario is annotated with a rerun request that it has not run for yet.
+func IsRerunRequested(scenario threatestergithubiov1alpha1.Scenario) bool {
+	request := scenario.Annotations[RerunAnnotation]
+	return request != "" && request != scenario.Status.RerunRequest
+}
+
+// ParseTimeout parses a duration of the scenario spec. An empty duration means no timeout.
+func ParseTimeout(timeout string) (time.Duration, error) {
+	if timeout == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q: %w", timeout, err)
+	}
+
+	return d, nil
+}
+
+func FindTemplate(templates []threatestergithubiov1alpha1.Template, name string) (*threatestergithubiov1alpha1.Template, error) {
+	for i := range templates {
+		if templates[i].Name == name {
+			return &templates[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("template %q not found", name)
+}
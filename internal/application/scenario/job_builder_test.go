@@ -0,0 +1,257 @@
+package scenario
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/pointer"
+)
+
+func newTestScenario() threatestergithubiov1alpha1.Scenario {
+	return threatestergithubiov1alpha1.Scenario{
+		ObjectMeta: metav1.ObjectMeta{Name: "chain", Namespace: "default"},
+		Spec: threatestergithubiov1alpha1.ScenarioSpec{
+			Templates: []threatestergithubiov1alpha1.Template{
+				{Name: "recon", Container: &corev1.Container{Name: "recon", Image: "alpine"}},
+				{Name: "credential-access", Container: &corev1.Container{Name: "credential-access", Image: "alpine"}},
+			},
+		},
+	}
+}
+
+func TestBuildScenarioJobs(t *testing.T) {
+	t.Run("without steps all templates run in a single job", func(t *testing.T) {
+		jobs, err := BuildScenarioJobs(newTestScenario())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(jobs) != 1 {
+			t.Fatalf("expected 1 job, got %d", len(jobs))
+		}
+
+		if jobs[0].Name != "chain-job" {
+			t.Errorf("expected job name chain-job, got %s", jobs[0].Name)
+		}
+
+		if got := len(jobs[0].Spec.Template.Spec.Containers); got != 2 {
+			t.Errorf("expected 2 containers, got %d", got)
+		}
+	})
+
+	t.Run("each step runs in its own job", func(t *testing.T) {
+		scenario := newTestScenario()
+		scenario.Spec.Steps = []threatestergithubiov1alpha1.Step{
+			{Name: "first", Template: "recon"},
+			{Name: "second", Template: "credential-access"},
+		}
+
+		jobs, err := BuildScenarioJobs(scenario)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(jobs) != 2 {
+			t.Fatalf("expected 2 jobs, got %d", len(jobs))
+		}
+
+		for i, step := range scenario.Spec.Steps {
+			if jobs[i].Name != StepJobName(scenario.Name, step.Name) {
+				t.Errorf("expected job name %s, got %s", StepJobName(scenario.Name, step.Name), jobs[i].Name)
+			}
+
+			if jobs[i].Labels[StepLabel] != step.Name {
+				t.Errorf("expected step label %s, got %s", step.Name, jobs[i].Labels[StepLabel])
+			}
+		}
+
+		if jobs[1].Spec.Template.Spec.Containers[0].Name != "credential-access" {
+			t.Errorf("expected second job to run credential-access, got %s", jobs[1].Spec.Template.Spec.Containers[0].Name)
+		}
+	})
+
+	t.Run("templates running in an existing workload have no job", func(t *testing.T) {
+		scenario := newTestScenario()
+		scenario.Spec.Templates[1].Workload = &threatestergithubiov1alpha1.Workload{
+			Selector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		}
+
+		jobs, err := BuildScenarioJobs(scenario)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(jobs) != 1 || len(jobs[0].Spec.Template.Spec.Containers) != 1 {
+			t.Fatalf("expected a single job running recon only, got %d jobs", len(jobs))
+		}
+
+		scenario.Spec.Steps = []threatestergithubiov1alpha1.Step{
+			{Name: "first", Template: "recon"},
+			{Name: "second", Template: "credential-access"},
+		}
+
+		jobs, err = BuildScenarioJobs(scenario)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(jobs) != 1 || jobs[0].Labels[StepLabel] != "first" {
+			t.Errorf("expected a single job for the first step, got %d jobs", len(jobs))
+		}
+	})
+
+	t.Run("step referencing an unknown template", func(t *testing.T) {
+		scenario := newTestScenario()
+		scenario.Spec.Steps = []threatestergithubiov1alpha1.Step{{Name: "first", Template: "unknown"}}
+
+		if _, err := BuildScenarioJobs(scenario); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("template without container", func(t *testing.T) {
+		scenario := newTestScenario()
+		scenario.Spec.Templates[1].Container = nil
+
+		if _, err := BuildScenarioJobs(scenario); err == nil {
+			t.Error("expected an error for the scenario job")
+		}
+
+		scenario.Spec.Steps = []threatestergithubiov1alpha1.Step{
+			{Name: "first", Template: "recon"},
+			{Name: "second", Template: "credential-access"},
+		}
+
+		if _, err := BuildScenarioJobs(scenario); err == nil {
+			t.Error("expected an error for the step job")
+		}
+
+		if _, err := NewScenarioJobBuilder().WithName("scenario-job").WithScenarioJobs(scenario.Spec.Templates).Build(); err == nil {
+			t.Error("expected an error from the builder")
+		}
+	})
+}
+
+func TestBuildScenarioJobsWithTemplates(t *testing.T) {
+	scenario := newTestScenario()
+	scenario.Spec.PodTemplate = &threatestergithubiov1alpha1.PodTemplate{
+		Labels:             map[string]string{"team": "security", ScenarioLabel: "overridden"},
+		ServiceAccountName: "threatester",
+		NodeSelector:       map[string]string{"kubernetes.io/os": "linux"},
+		Tolerations:        []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}},
+	}
+	scenario.Spec.JobTemplate = &threatestergithubiov1alpha1.JobTemplate{
+		BackoffLimit:          pointer.Int32(2),
+		ActiveDeadlineSeconds: pointer.Int64(600),
+	}
+	scenario.Spec.Templates[1].PodTemplate = &threatestergithubiov1alpha1.PodTemplate{
+		ServiceAccountName: "privileged",
+		HostPID:            true,
+		Tolerations:        []corev1.Toleration{{Key: "node-role.kubernetes.io/control-plane", Operator: corev1.TolerationOpExists}},
+	}
+	scenario.Spec.Steps = []threatestergithubiov1alpha1.Step{
+		{Name: "first", Template: "recon"},
+		{Name: "second", Template: "credential-access"},
+	}
+
+	jobs, err := BuildScenarioJobs(scenario)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	first, second := jobs[0], jobs[1]
+
+	if *first.Spec.BackoffLimit != 2 || *first.Spec.ActiveDeadlineSeconds != 600 {
+		t.Errorf("expected the job template to be applied, got backoffLimit %d activeDeadlineSeconds %d", *first.Spec.BackoffLimit, *first.Spec.ActiveDeadlineSeconds)
+	}
+
+	if first.Spec.Template.Labels["team"] != "security" {
+		t.Errorf("expected pod label team=security, got %v", first.Spec.Template.Labels)
+	}
+
+	if first.Spec.Template.Labels[ScenarioLabel] != scenario.Name {
+		t.Errorf("expected scenario label %s not to be overridden, got %s", scenario.Name, first.Spec.Template.Labels[ScenarioLabel])
+	}
+
+	if first.Spec.Template.Spec.ServiceAccountName != "threatester" || first.Spec.Template.Spec.HostPID {
+		t.Errorf("expected the scenario pod template only, got serviceAccountName %s hostPID %t", first.Spec.Template.Spec.ServiceAccountName, first.Spec.Template.Spec.HostPID)
+	}
+
+	if second.Spec.Template.Spec.ServiceAccountName != "privileged" || !second.Spec.Template.Spec.HostPID {
+		t.Errorf("expected the template pod template to override, got serviceAccountName %s hostPID %t", second.Spec.Template.Spec.ServiceAccountName, second.Spec.Template.Spec.HostPID)
+	}
+
+	if !reflect.DeepEqual(second.Spec.Template.Spec.NodeSelector, map[string]string{"kubernetes.io/os": "linux"}) {
+		t.Errorf("expected the node selector of the scenario, got %v", second.Spec.Template.Spec.NodeSelector)
+	}
+
+	if got := len(second.Spec.Template.Spec.Tolerations); got != 2 {
+		t.Errorf("expected 2 tolerations, got %d", got)
+	}
+}
+
+func TestBuildScenarioJobsWithTimeout(t *testing.T) {
+	scenario := newTestScenario()
+	scenario.Spec.Timeout = "90s"
+
+	jobs, err := BuildScenarioJobs(scenario)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := *jobs[0].Spec.ActiveDeadlineSeconds; got != 90 {
+		t.Errorf("expected activeDeadlineSeconds 90, got %d", got)
+	}
+
+	scenario.Spec.JobTemplate = &threatestergithubiov1alpha1.JobTemplate{ActiveDeadlineSeconds: pointer.Int64(600)}
+	jobs, err = BuildScenarioJobs(scenario)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := *jobs[0].Spec.ActiveDeadlineSeconds; got != 600 {
+		t.Errorf("expected the job template to take precedence, got %d", got)
+	}
+
+	scenario.Spec.Timeout = "ten minutes"
+	if _, err := BuildScenarioJobs(scenario); err == nil {
+		t.Error("expected an error for an invalid timeout")
+	}
+}
+
+func TestJobNames(t *testing.T) {
+	longScenarioName := strings.Repeat("credential-access-", 4) + "scenario"
+
+	if got := StepJobName("chain", "recon"); got != "chain-recon" {
+		t.Errorf("expected a short name to be kept, got %s", got)
+	}
+
+	names := map[string]string{
+		"scenario job":        ScenarioJobName(longScenarioName),
+		"first step job":      StepJobName(longScenarioName, "dump-secrets-from-namespace-a"),
+		"second step job":     StepJobName(longScenarioName, "dump-secrets-from-namespace-b"),
+		"node job":            NodeJobName(ScenarioJobName(longScenarioName), "ip-10-0-0-1.ec2.internal"),
+		"stratus cleanup job": StratusCleanupJobName(longScenarioName),
+	}
+
+	seen := map[string]string{}
+	for kind, name := range names {
+		if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+			t.Errorf("expected the %s name %s to be a valid label: %v", kind, name, errs)
+		}
+
+		if other, ok := seen[name]; ok {
+			t.Errorf("expected the %s and the %s to have different names, got %s", kind, other, name)
+		}
+		seen[name] = kind
+	}
+
+	if StepJobName(longScenarioName, "dump-secrets-from-namespace-a") != names["first step job"] {
+		t.Error("expected the truncated names to be stable")
+	}
+}
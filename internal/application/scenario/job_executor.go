@@ -4,35 +4,88 @@ package scenario
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
 	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
-const RetryInterval = 5 * time.Second
+const (
+	RetryInterval = 5 * time.Second
+
+	// LogTailLines is the number of lines of logs collected from each container of the scenario jobs.
+	LogTailLines = 500
+	// MaxLogLength bounds the logs collected from each container, keeping the end of the logs.
+	MaxLogLength = 256 * 1024
+	// MaxTotalLogLength bounds the logs of all the containers of a scenario, which are stored in a ConfigMap limited to 1MiB.
+	MaxTotalLogLength = 768 * 1024
+	// UnschedulableGracePeriod is how long a pod of a scenario job may stay unschedulable, e.g. while the cluster scales up.
+	UnschedulableGracePeriod = 2 * time.Minute
+)
 
 var (
 	defaultDeletePropagation = metav1.DeletePropagationBackground
+
+	// ErrScenarioJobDeadlineExceeded is returned when a scenario job was terminated by its active deadline.
+	ErrScenarioJobDeadlineExceeded = errors.New("scenario job has reached its deadline")
+
+	// stuckContainerReasons are the waiting reasons of containers which will not start without a change to the scenario or the cluster.
+	stuckContainerReasons = map[string]struct{}{
+		"ImagePullBackOff":           {},
+		"InvalidImageName":           {},
+		"CreateContainerConfigError": {},
+		"CreateContainerError":       {},
+		"CrashLoopBackOff":           {},
+	}
 )
 
+// StuckPodError is returned when a pod of a scenario job cannot make progress, e.g. when its image cannot be pulled.
+type StuckPodError struct {
+	Pod       string
+	Container string
+	Reason    string
+	Message   string
+}
+
+func (e *StuckPodError) Error() string {
+	target := e.Pod
+	if e.Container != "" {
+		target = fmt.Sprintf("%s/%s", e.Pod, e.Container)
+	}
+
+	if e.Message == "" {
+		return fmt.Sprintf("scenario pod %s is stuck: %s", target, e.Reason)
+	}
+
+	return fmt.Sprintf("scenario pod %s is stuck: %s: %s", target, e.Reason, e.Message)
+}
+
 type ScenarioJobExecutor interface {
 	Execute(ctx context.Context, scenarioJob batchv1.Job) error
+	CollectResults(ctx context.Context, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.ContainerResult, error)
 	DeleteScenarioJob(ctx context.Context, scenarioJob batchv1.Job) error
 }
 
 type scenarioJobExecutor struct {
 	client.Client
+	clientset kubernetes.Interface
 }
 
-func NewScenarioJobExecutor(client client.Client) ScenarioJobExecutor {
+func NewScenarioJobExecutor(client client.Client, clientset kubernetes.Interface) ScenarioJobExecutor {
 	return &scenarioJobExecutor{
-		Client: client,
+		Client:    client,
+		clientset: clientset,
 	}
 }
 
@@ -44,30 +97,42 @@ func (e *scenarioJobExecutor) Execute(ctx context.Context, scenarioJob batchv1.J
 
 	// TODO: refactor
 	err = func() error {
+		found := false
 		for {
 			job := &batchv1.Job{}
 			err := e.Get(ctx, types.NamespacedName{Name: scenarioJob.Name, Namespace: scenarioJob.Namespace}, job)
 			if err != nil {
 				if apierrors.IsNotFound(err) {
-					time.Sleep(RetryInterval)
+					// the job may have been removed by its TTL before its completion was observed
+					if found {
+						return fmt.Errorf("scenario job was deleted before its completion was observed")
+					}
+
+					if err := sleep(ctx, RetryInterval); err != nil {
+						return err
+					}
 					continue
 				}
 
 				return fmt.Errorf("failed to get scenario job: %w", err)
 			}
+			found = true
 
-			if len(job.Status.Conditions) == 0 {
-				time.Sleep(RetryInterval)
-				continue
+			if finished, err := JobResult(*job); finished {
+				return err
+			}
+
+			pods, err := e.listScenarioJobPods(ctx, *job)
+			if err != nil {
+				return err
 			}
 
-			switch jobCondition := job.Status.Conditions[0].Type; jobCondition {
-			case batchv1.JobComplete:
-				return nil
-			case batchv1.JobFailed:
-				return fmt.Errorf("scenario job is failed")
-			default:
-				time.Sleep(RetryInterval)
+			if stuck := FindStuckPod(pods, time.Now()); stuck != nil {
+				return stuck
+			}
+
+			if err := sleep(ctx, RetryInterval); err != nil {
+				return err
 			}
 		}
 	}()
@@ -75,10 +140,180 @@ func (e *scenarioJobExecutor) Execute(ctx context.Context, scenarioJob batchv1.J
 	return err
 }
 
+// CollectResults returns the exit code, termination reason and tail of the logs of each container of the job pods, init containers included.
+// It must be called before the job is deleted, since the pods are deleted along with it.
+func (e *scenarioJobExecutor) CollectResults(ctx context.Context, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.ContainerResult, error) {
+	job := &batchv1.Job{}
+	if err := e.Get(ctx, types.NamespacedName{Name: scenarioJob.Name, Namespace: scenarioJob.Namespace}, job); err != nil {
+		return nil, fmt.Errorf("failed to get scenario job: %w", err)
+	}
+
+	pods, err := e.listScenarioJobPods(ctx, *job)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []threatestergithubiov1alpha1.ContainerResult{}
+	errs := []error{}
+	for _, pod := range pods {
+		// init containers run parts of the attack as well, e.g. the warmup of a Stratus Red Team technique
+		statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		for _, status := range statuses {
+			result := threatestergithubiov1alpha1.ContainerResult{Job: job.Name, Pod: pod.Name, Container: status.Name}
+			if terminated := status.State.Terminated; terminated != nil {
+				exitCode := terminated.ExitCode
+				result.ExitCode = &exitCode
+				result.Reason = terminated.Reason
+			}
+
+			logs, err := e.clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+				Container: status.Name,
+				TailLines: pointer.Int64(LogTailLines),
+			}).Do(ctx).Raw()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to get logs of %s/%s: %w", pod.Name, status.Name, err))
+			}
+			result.Logs = TailLogs(string(logs), MaxLogLength)
+
+			results = append(results, result)
+		}
+	}
+
+	return results, utilerrors.NewAggregate(errs)
+}
+
+func (e *scenarioJobExecutor) listScenarioJobPods(ctx context.Context, job batchv1.Job) ([]corev1.Pod, error) {
+	if job.Spec.Selector == nil {
+		return nil, fmt.Errorf("scenario job %s/%s has no selector", job.Namespace, job.Name)
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(job.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := e.clientset.CoreV1().Pods(job.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods of scenario job: %w", err)
+	}
+
+	return pods.Items, nil
+}
+
+// JobResult reports whether the job has finished, and the error it failed with.
+// All the conditions are evaluated, since a job may have other conditions such as Suspended before the one it finished with.
+func JobResult(job batchv1.Job) (bool, error) {
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return true, nil
+		// FailureTarget is added before the pods are terminated, the job will be failed
+		case batchv1.JobFailed, batchv1.JobFailureTarget:
+			if condition.Reason == "DeadlineExceeded" {
+				return true, ErrScenarioJobDeadlineExceeded
+			}
+
+			if condition.Reason == "" {
+				return true, fmt.Errorf("scenario job is failed")
+			}
+
+			return true, fmt.Errorf("scenario job is failed: %s: %s", condition.Reason, condition.Message)
+		}
+	}
+
+	return false, nil
+}
+
+// FindStuckPod returns why one of the pods cannot make progress, or nil when none of them is stuck.
+func FindStuckPod(pods []corev1.Pod, now time.Time) *StuckPodError {
+	for _, pod := range pods {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type != corev1.PodScheduled || condition.Status != corev1.ConditionFalse || condition.Reason != corev1.PodReasonUnschedulable {
+				continue
+			}
+
+			if now.Sub(condition.LastTransitionTime.Time) >= UnschedulableGracePeriod {
+				return &StuckPodError{Pod: pod.Name, Reason: condition.Reason, Message: condition.Message}
+			}
+		}
+
+		statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+		if stuck := findStuckContainer(pod.Name, statuses); stuck != nil {
+			return stuck
+		}
+	}
+
+	return nil
+}
+
+// findStuckContainer returns why one of the containers of the pod will not start, or nil when none of them is stuck.
+func findStuckContainer(pod string, statuses []corev1.ContainerStatus) *StuckPodError {
+	for _, status := range statuses {
+		waiting := status.State.Waiting
+		if waiting == nil {
+			continue
+		}
+
+		if _, ok := stuckContainerReasons[waiting.Reason]; ok {
+			return &StuckPodError{Pod: pod, Container: status.Name, Reason: waiting.Reason, Message: waiting.Message}
+		}
+	}
+
+	return nil
+}
+
+// FindStuckPodError returns the StuckPodError in err, including in aggregated errors, or nil when there is none.
+func FindStuckPodError(err error) *StuckPodError {
+	var stuck *StuckPodError
+	if errors.As(err, &stuck) {
+		return stuck
+	}
+
+	var aggregate utilerrors.Aggregate
+	if errors.As(err, &aggregate) {
+		for _, err := range aggregate.Errors() {
+			if stuck := FindStuckPodError(err); stuck != nil {
+				return stuck
+			}
+		}
+	}
+
+	return nil
+}
+
+// sleep waits for the duration, or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// ShouldDeleteScenarioJobs reports whether the scenario jobs are deleted once the scenario has run.
+func ShouldDeleteScenarioJobs(policy threatestergithubiov1alpha1.JobCleanupPolicy, succeeded bool) bool {
+	switch policy {
+	case threatestergithubiov1alpha1.JobCleanupPolicyNever:
+		return false
+	case threatestergithubiov1alpha1.JobCleanupPolicyOnSuccess:
+		return succeeded
+	default:
+		return true
+	}
+}
+
 func (e *scenarioJobExecutor) DeleteScenarioJob(ctx context.Context, job batchv1.Job) error {
 	log := log.FromContext(ctx)
 
 	err := e.Client.Delete(ctx, &job, &client.DeleteOptions{PropagationPolicy: &defaultDeletePropagation})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
 	if err != nil {
 		log.Error(err, fmt.Sprintf("failed to delete scenario job %s/%s", job.Namespace, job.Name))
 		return fmt.Errorf("failed to delete scenario job: %w", err)
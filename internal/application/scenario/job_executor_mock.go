@@ -5,6 +5,7 @@ package scenario
 
 import (
 	"context"
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
 	batchv1 "k8s.io/api/batch/v1"
 	"sync"
 )
@@ -19,6 +20,9 @@ var _ ScenarioJobExecutor = &ScenarioJobExecutorMock{}
 //
 //		// make and configure a mocked ScenarioJobExecutor
 //		mockedScenarioJobExecutor := &ScenarioJobExecutorMock{
+//			CollectResultsFunc: func(ctx context.Context, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.ContainerResult, error) {
+//				panic("mock out the CollectResults method")
+//			},
 //			DeleteScenarioJobFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
 //				panic("mock out the DeleteScenarioJob method")
 //			},
@@ -32,6 +36,9 @@ var _ ScenarioJobExecutor = &ScenarioJobExecutorMock{}
 //
 //	}
 type ScenarioJobExecutorMock struct {
+	// CollectResultsFunc mocks the CollectResults method.
+	CollectResultsFunc func(ctx context.Context, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.ContainerResult, error)
+
 	// DeleteScenarioJobFunc mocks the DeleteScenarioJob method.
 	DeleteScenarioJobFunc func(ctx context.Context, scenarioJob batchv1.Job) error
 
@@ -40,6 +47,13 @@ type ScenarioJobExecutorMock struct {
 
 	// calls tracks calls to the methods.
 	calls struct {
+		// CollectResults holds details about calls to the CollectResults method.
+		CollectResults []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ScenarioJob is the scenarioJob argument value.
+			ScenarioJob batchv1.Job
+		}
 		// DeleteScenarioJob holds details about calls to the DeleteScenarioJob method.
 		DeleteScenarioJob []struct {
 			// Ctx is the ctx argument value.
@@ -55,10 +69,47 @@ type ScenarioJobExecutorMock struct {
 			ScenarioJob batchv1.Job
 		}
 	}
+	lockCollectResults    sync.RWMutex
 	lockDeleteScenarioJob sync.RWMutex
 	lockExecute           sync.RWMutex
 }
 
+// CollectResults calls CollectResultsFunc.
+func (mock *ScenarioJobExecutorMock) CollectResults(ctx context.Context, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.ContainerResult, error) {
+	if mock.CollectResultsFunc == nil {
+		panic("ScenarioJobExecutorMock.CollectResultsFunc: method is nil but ScenarioJobExecutor.CollectResults was just called")
+	}
+	callInfo := struct {
+		Ctx         context.Context
+		ScenarioJob batchv1.Job
+	}{
+		Ctx:         ctx,
+		ScenarioJob: scenarioJob,
+	}
+	mock.lockCollectResults.Lock()
+	mock.calls.CollectResults = append(mock.calls.CollectResults, callInfo)
+	mock.lockCollectResults.Unlock()
+	return mock.CollectResultsFunc(ctx, scenarioJob)
+}
+
+// CollectResultsCalls gets all the calls that were made to CollectResults.
+// Check the length with:
+//
+//	len(mockedScenarioJobExecutor.CollectResultsCalls())
+func (mock *ScenarioJobExecutorMock) CollectResultsCalls() []struct {
+	Ctx         context.Context
+	ScenarioJob batchv1.Job
+} {
+	var calls []struct {
+		Ctx         context.Context
+		ScenarioJob batchv1.Job
+	}
+	mock.lockCollectResults.RLock()
+	calls = mock.calls.CollectResults
+	mock.lockCollectResults.RUnlock()
+	return calls
+}
+
 // DeleteScenarioJob calls DeleteScenarioJobFunc.
 func (mock *ScenarioJobExecutorMock) DeleteScenarioJob(ctx context.Context, scenarioJob batchv1.Job) error {
 	if mock.DeleteScenarioJobFunc == nil {
@@ -0,0 +1,194 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestShouldDeleteScenarioJobs(t *testing.T) {
+	testCases := []struct {
+		policy    threatestergithubiov1alpha1.JobCleanupPolicy
+		succeeded bool
+		expected  bool
+	}{
+		{policy: "", succeeded: false, expected: true},
+		{policy: threatestergithubiov1alpha1.JobCleanupPolicyAlways, succeeded: false, expected: true},
+		{policy: threatestergithubiov1alpha1.JobCleanupPolicyOnSuccess, succeeded: true, expected: true},
+		{policy: threatestergithubiov1alpha1.JobCleanupPolicyOnSuccess, succeeded: false, expected: false},
+		{policy: threatestergithubiov1alpha1.JobCleanupPolicyNever, succeeded: true, expected: false},
+	}
+
+	for _, tc := range testCases {
+		if got := ShouldDeleteScenarioJobs(tc.policy, tc.succeeded); got != tc.expected {
+			t.Errorf("policy %q succeeded %t: expected %t, got %t", tc.policy, tc.succeeded, tc.expected, got)
+		}
+	}
+}
+
+func TestJobResult(t *testing.T) {
+	testCases := []struct {
+		name       string
+		conditions []batchv1.JobCondition
+		finished   bool
+		err        error
+	}{
+		{name: "no condition", finished: false},
+		{
+			name:       "suspended",
+			conditions: []batchv1.JobCondition{{Type: batchv1.JobSuspended, Status: corev1.ConditionTrue}},
+			finished:   false,
+		},
+		{
+			name: "complete after suspended",
+			conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobSuspended, Status: corev1.ConditionFalse},
+				{Type: batchv1.JobComplete, Status: corev1.ConditionTrue},
+			},
+			finished: true,
+		},
+		{
+			name: "failure target before failed",
+			conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobFailureTarget, Status: corev1.ConditionTrue, Reason: "PodFailurePolicy", Message: "container attack exited with 1"},
+			},
+			finished: true,
+			err:      fmt.Errorf("scenario job is failed: PodFailurePolicy: container attack exited with 1"),
+		},
+		{
+			name:       "deadline exceeded",
+			conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "DeadlineExceeded"}},
+			finished:   true,
+			err:        ErrScenarioJobDeadlineExceeded,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			finished, err := JobResult(batchv1.Job{Status: batchv1.JobStatus{Conditions: tc.conditions}})
+			if finished != tc.finished {
+				t.Errorf("expected finished %t, got %t", tc.finished, finished)
+			}
+
+			if fmt.Sprint(err) != fmt.Sprint(tc.err) {
+				t.Errorf("expected error %v, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestFindStuckPod(t *testing.T) {
+	now := time.Now()
+
+	waiting := func(reason string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "scenario-abcde"},
+			Status: corev1.PodStatus{
+				ContainerStatuses: []corev1.ContainerStatus{
+					{Name: "attack", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason, Message: "message"}}},
+				},
+			},
+		}
+	}
+
+	unschedulable := func(since time.Duration) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "scenario-abcde"},
+			Status: corev1.PodStatus{
+				Conditions: []corev1.PodCondition{
+					{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: corev1.PodReasonUnschedulable, LastTransitionTime: metav1.NewTime(now.Add(-since))},
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		pod    corev1.Pod
+		reason string
+	}{
+		{name: "image pull back-off", pod: waiting("ImagePullBackOff"), reason: "ImagePullBackOff"},
+		{name: "crash loop", pod: waiting("CrashLoopBackOff"), reason: "CrashLoopBackOff"},
+		{name: "container creating", pod: waiting("ContainerCreating"), reason: ""},
+		{name: "unschedulable for a while", pod: unschedulable(5 * time.Minute), reason: corev1.PodReasonUnschedulable},
+		{name: "just unschedulable", pod: unschedulable(10 * time.Second), reason: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stuck := FindStuckPod([]corev1.Pod{tc.pod}, now)
+			if tc.reason == "" {
+				if stuck != nil {
+					t.Errorf("expected no stuck pod, got %v", stuck)
+				}
+				return
+			}
+
+			if stuck == nil || stuck.Reason != tc.reason {
+				t.Errorf("expected reason %s, got %v", tc.reason, stuck)
+			}
+		})
+	}
+}
+
+func TestFindStuckPodError(t *testing.T) {
+	stuck := &StuckPodError{Pod: "scenario-abcde", Container: "attack", Reason: "ImagePullBackOff"}
+
+	err := fmt.Errorf("scenario steps failed: first: %w", utilerrors.NewAggregate([]error{errors.New("failed"), stuck}))
+	if got := FindStuckPodError(err); got != stuck {
+		t.Errorf("expected %v, got %v", stuck, got)
+	}
+
+	if got := FindStuckPodError(errors.New("failed")); got != nil {
+		t.Errorf("expected no stuck pod, got %v", got)
+	}
+}
+
+func TestCollectResults(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := batchv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: "scenario-job", Namespace: "default"},
+		Spec:       batchv1.JobSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"job-name": "scenario-job"}}},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "scenario-job-abcde", Namespace: "default", Labels: map[string]string{"job-name": "scenario-job"}},
+		Status: corev1.PodStatus{
+			InitContainerStatuses: []corev1.ContainerStatus{
+				{Name: "warmup", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"}}},
+			},
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "detonate", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "PodInitializing"}}},
+			},
+		},
+	}
+
+	executor := NewScenarioJobExecutor(fake.NewClientBuilder().WithScheme(scheme).WithObjects(job).Build(), kubefake.NewSimpleClientset(pod))
+
+	results, err := executor.CollectResults(context.Background(), *job)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 2 || results[0].Container != "warmup" || results[1].Container != "detonate" {
+		t.Fatalf("expected the results of the init container and the container, got %v", results)
+	}
+
+	if results[0].ExitCode == nil || *results[0].ExitCode != 1 || results[0].Reason != "Error" || results[0].Logs == "" {
+		t.Errorf("expected the exit code, reason and logs of the init container, got %v", results[0])
+	}
+}
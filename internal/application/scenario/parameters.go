@@ -0,0 +1,27 @@
+package scenario
+
+import (
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ApplyParameters returns a copy of the scenario with its parameters substituted.
+// Scenarios without parameters are left as they are, so that their commands may contain "{{" as is.
+func ApplyParameters(scenario threatestergithubiov1alpha1.Scenario) (*threatestergithubiov1alpha1.Scenario, error) {
+	applied := scenario.DeepCopy()
+	if len(scenario.Spec.Parameters) == 0 && len(scenario.Spec.Arguments) == 0 {
+		return applied, nil
+	}
+
+	specPath := field.NewPath("spec")
+	values, errs := threatestergithubiov1alpha1.ParameterValues(scenario.Spec.Parameters, scenario.Spec.Arguments, specPath.Child("parameters"), specPath.Child("arguments"))
+	if len(errs) > 0 {
+		return nil, errs.ToAggregate()
+	}
+
+	if errs := applied.Spec.SubstituteParameters(values, specPath); len(errs) > 0 {
+		return nil, errs.ToAggregate()
+	}
+
+	return applied, nil
+}
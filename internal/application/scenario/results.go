@@ -0,0 +1,64 @@
+package scenario
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+)
+
+// SummarizeFailedContainers describes the containers that exited with a non-zero code, with the last line of their logs.
+func SummarizeFailedContainers(results []threatestergithubiov1alpha1.ContainerResult) string {
+	summaries := []string{}
+	for _, result := range results {
+		if result.ExitCode == nil || *result.ExitCode == 0 {
+			continue
+		}
+
+		summary := fmt.Sprintf("container %s of pod %s exited with code %d", result.Container, result.Pod, *result.ExitCode)
+		if result.Reason != "" {
+			summary = fmt.Sprintf("%s (%s)", summary, result.Reason)
+		}
+		if line := lastLine(result.Logs); line != "" {
+			summary = fmt.Sprintf("%s: %s", summary, line)
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	return strings.Join(summaries, "; ")
+}
+
+// BoundLogs keeps the end of the logs of the containers so that they total at most maxLength bytes.
+// Each container gets an equal share of the length, and the share left unused by shorter logs goes to the longer ones.
+func BoundLogs(results []threatestergithubiov1alpha1.ContainerResult, maxLength int) {
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return len(results[order[i]].Logs) < len(results[order[j]].Logs) })
+
+	remaining := maxLength
+	for n, i := range order {
+		share := remaining / (len(order) - n)
+		results[i].Logs = TailLogs(results[i].Logs, share)
+		remaining -= len(results[i].Logs)
+	}
+}
+
+// LogsConfigMapKey returns the key holding the logs of a container in the logs ConfigMap of a scenario.
+func LogsConfigMapKey(result threatestergithubiov1alpha1.ContainerResult) string {
+	return fmt.Sprintf("%s.%s.log", result.Pod, result.Container)
+}
+
+// LogsConfigMapName returns the name of the ConfigMap holding the logs that do not fit in the status of a scenario.
+func LogsConfigMapName(scenarioName string) string {
+	return fmt.Sprintf("%s-logs", scenarioName)
+}
+
+func lastLine(logs string) string {
+	lines := strings.Split(strings.TrimSpace(logs), "\n")
+
+	return strings.TrimSpace(lines[len(lines)-1])
+}
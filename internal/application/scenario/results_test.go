@@ -0,0 +1,53 @@
+package scenario
+
+import (
+	"strings"
+	"testing"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"k8s.io/utils/pointer"
+)
+
+func TestSummarizeFailedContainers(t *testing.T) {
+	results := []threatestergithubiov1alpha1.ContainerResult{
+		{Job: "scenario", Pod: "scenario-abcde", Container: "recon", ExitCode: pointer.Int32(0), Reason: "Completed", Logs: "uid=0(root)\n"},
+		{Job: "scenario", Pod: "scenario-abcde", Container: "credential-access", ExitCode: pointer.Int32(1), Reason: "Error", Logs: "reading...\ncat: can't open '/etc/shadow': Permission denied\n"},
+		{Job: "scenario", Pod: "scenario-abcde", Container: "lateral-movement"},
+	}
+
+	expected := "container credential-access of pod scenario-abcde exited with code 1 (Error): cat: can't open '/etc/shadow': Permission denied"
+	if got := SummarizeFailedContainers(results); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestTailLogs(t *testing.T) {
+	if got := TailLogs("0123456789", 4); got != "6789" {
+		t.Errorf("expected 6789, got %s", got)
+	}
+
+	if got := TailLogs("0123", 4); got != "0123" {
+		t.Errorf("expected 0123, got %s", got)
+	}
+}
+
+func TestBoundLogs(t *testing.T) {
+	results := []threatestergithubiov1alpha1.ContainerResult{
+		{Container: "warmup", Logs: strings.Repeat("w", 100)},
+		{Container: "recon", Logs: strings.Repeat("r", 10)},
+		{Container: "detonate", Logs: strings.Repeat("d", 100)},
+	}
+
+	BoundLogs(results, 110)
+
+	lengths := []int{len(results[0].Logs), len(results[1].Logs), len(results[2].Logs)}
+	if lengths[0] != 50 || lengths[1] != 10 || lengths[2] != 50 {
+		t.Errorf("expected the short logs to be kept and the long ones to share the rest, got lengths %v", lengths)
+	}
+
+	results = []threatestergithubiov1alpha1.ContainerResult{{Container: "recon", Logs: "uid=0(root)\n"}}
+	BoundLogs(results, 110)
+	if results[0].Logs != "uid=0(root)\n" {
+		t.Errorf("expected logs within the bound to be kept, got %q", results[0].Logs)
+	}
+}
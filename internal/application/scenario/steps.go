@@ -0,0 +1,159 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/mrtc0/threatester/internal/dag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// StepGraph returns the dependency graph of the steps.
+// When no step declares dependsOn, each step depends on the previous one so that the steps run in order.
+func StepGraph(steps []threatestergithubiov1alpha1.Step) (*dag.Graph, error) {
+	names := []string{}
+	dependencies := map[string][]string{}
+
+	for _, step := range steps {
+		names = append(names, step.Name)
+		dependencies[step.Name] = step.DependsOn
+	}
+
+	if !HasStepDependencies(steps) {
+		for i := 1; i < len(steps); i++ {
+			dependencies[steps[i].Name] = []string{steps[i-1].Name}
+		}
+	}
+
+	return dag.New(names, dependencies)
+}
+
+// HasStepDependencies reports whether the steps are scheduled as a DAG.
+func HasStepDependencies(steps []threatestergithubiov1alpha1.Step) bool {
+	for _, step := range steps {
+		if len(step.DependsOn) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ExecuteSteps runs the steps following their dependencies, running independent steps in parallel.
+// A step starts once all of its dependencies have completed.
+// When a step fails, the steps that have not started yet are skipped unless the failure policy is Continue.
+// The status of each step is recorded each time it changes; the run stops when a status cannot be recorded
+// before a step starts, while a failure to record the completion of a step is only logged.
+func ExecuteSteps(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario, execute func(ctx context.Context, step threatestergithubiov1alpha1.Step) error, record func(stepStatus threatestergithubiov1alpha1.StepStatus) error) error {
+	log := log.FromContext(ctx)
+
+	graph, err := StepGraph(scenario.Spec.Steps)
+	if err != nil {
+		return err
+	}
+
+	steps := map[string]threatestergithubiov1alpha1.Step{}
+	for _, step := range scenario.Spec.Steps {
+		steps[step.Name] = step
+	}
+
+	stepStatuses := map[string]*threatestergithubiov1alpha1.StepStatus{}
+	for _, name := range graph.Nodes() {
+		stepStatuses[name] = &threatestergithubiov1alpha1.StepStatus{Name: name, Phase: threatestergithubiov1alpha1.StepPhasePending}
+		if err := record(*stepStatuses[name]); err != nil {
+			return err
+		}
+	}
+
+	type stepResult struct {
+		name string
+		err  error
+	}
+	// buffered so that running steps do not block when the scheduler returns early
+	results := make(chan stepResult, len(scenario.Spec.Steps))
+
+	running := 0
+	failedSteps := []string{}
+	errs := []error{}
+	for {
+		for _, name := range graph.Nodes() {
+			stepStatus := stepStatuses[name]
+			if stepStatus.Phase != threatestergithubiov1alpha1.StepPhasePending {
+				continue
+			}
+
+			if len(failedSteps) > 0 && scenario.Spec.FailurePolicy != threatestergithubiov1alpha1.FailurePolicyContinue {
+				log.Info(fmt.Sprintf("skip step %s since step %s failed", name, failedSteps[0]))
+				stepStatus.Phase = threatestergithubiov1alpha1.StepPhaseSkipped
+				stepStatus.Message = fmt.Sprintf("step %s failed", failedSteps[0])
+				if err := record(*stepStatus); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if !stepDependenciesCompleted(graph.Dependencies(name), stepStatuses) {
+				continue
+			}
+
+			now := metav1.Now()
+			stepStatus.Phase = threatestergithubiov1alpha1.StepPhaseRunning
+			stepStatus.StartTime = &now
+			if err := record(*stepStatus); err != nil {
+				return err
+			}
+
+			running++
+			go func(step threatestergithubiov1alpha1.Step) {
+				results <- stepResult{name: step.Name, err: execute(ctx, step)}
+			}(steps[name])
+		}
+
+		if running == 0 {
+			break
+		}
+
+		result := <-results
+		running--
+
+		stepStatus := stepStatuses[result.name]
+		now := metav1.Now()
+		stepStatus.CompletionTime = &now
+		if result.err != nil {
+			log.Error(result.err, fmt.Sprintf("step %s failed", result.name))
+			failedSteps = append(failedSteps, result.name)
+			errs = append(errs, result.err)
+			stepStatus.Phase = threatestergithubiov1alpha1.StepPhaseFailed
+			stepStatus.Message = result.err.Error()
+		} else {
+			stepStatus.Phase = threatestergithubiov1alpha1.StepPhaseSucceeded
+		}
+
+		if err := record(*stepStatus); err != nil {
+			log.Error(err, fmt.Sprintf("failed to update status of step %s", result.name))
+		}
+	}
+
+	if len(failedSteps) > 0 {
+		return fmt.Errorf("scenario steps failed: %s: %w", strings.Join(failedSteps, ", "), utilerrors.NewAggregate(errs))
+	}
+
+	return nil
+}
+
+// stepDependenciesCompleted reports whether all dependencies of a step have completed, successfully or not.
+func stepDependenciesCompleted(dependencies []string, stepStatuses map[string]*threatestergithubiov1alpha1.StepStatus) bool {
+	for _, dependency := range dependencies {
+		switch stepStatuses[dependency].Phase {
+		case threatestergithubiov1alpha1.StepPhaseSucceeded, threatestergithubiov1alpha1.StepPhaseFailed, threatestergithubiov1alpha1.StepPhaseSkipped:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,181 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+)
+
+func TestStepGraph(t *testing.T) {
+	t.Run("steps without dependsOn run in order", func(t *testing.T) {
+		graph, err := StepGraph([]threatestergithubiov1alpha1.Step{
+			{Name: "first", Template: "recon"},
+			{Name: "second", Template: "credential-access"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := graph.Dependencies("second"); !reflect.DeepEqual(got, []string{"first"}) {
+			t.Errorf("expected second to depend on first, got %v", got)
+		}
+	})
+
+	t.Run("steps with dependsOn keep their dependencies", func(t *testing.T) {
+		graph, err := StepGraph([]threatestergithubiov1alpha1.Step{
+			{Name: "first", Template: "recon"},
+			{Name: "second", Template: "credential-access"},
+			{Name: "third", Template: "recon", DependsOn: []string{"first"}},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := graph.Dependencies("second"); len(got) != 0 {
+			t.Errorf("expected second to have no dependencies, got %v", got)
+		}
+	})
+
+	t.Run("cyclic dependencies", func(t *testing.T) {
+		_, err := StepGraph([]threatestergithubiov1alpha1.Step{
+			{Name: "first", Template: "recon", DependsOn: []string{"second"}},
+			{Name: "second", Template: "credential-access", DependsOn: []string{"first"}},
+		})
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestExecuteSteps(t *testing.T) {
+	steps := []threatestergithubiov1alpha1.Step{
+		{Name: "spray-a", Template: "spray"},
+		{Name: "spray-b", Template: "spray"},
+		{Name: "exfiltrate", Template: "exfiltrate", DependsOn: []string{"spray-a", "spray-b"}},
+	}
+
+	tests := []struct {
+		name          string
+		failurePolicy threatestergithubiov1alpha1.FailurePolicy
+		failedStep    string
+		expected      map[string]threatestergithubiov1alpha1.StepPhase
+		wantErr       bool
+	}{
+		{
+			name: "independent steps run in parallel",
+			expected: map[string]threatestergithubiov1alpha1.StepPhase{
+				"spray-a":    threatestergithubiov1alpha1.StepPhaseSucceeded,
+				"spray-b":    threatestergithubiov1alpha1.StepPhaseSucceeded,
+				"exfiltrate": threatestergithubiov1alpha1.StepPhaseSucceeded,
+			},
+		},
+		{
+			name:       "pending steps are skipped when a step fails",
+			failedStep: "spray-a",
+			expected: map[string]threatestergithubiov1alpha1.StepPhase{
+				"spray-a":    threatestergithubiov1alpha1.StepPhaseFailed,
+				"spray-b":    threatestergithubiov1alpha1.StepPhaseSucceeded,
+				"exfiltrate": threatestergithubiov1alpha1.StepPhaseSkipped,
+			},
+			wantErr: true,
+		},
+		{
+			name:          "pending steps run when the failure policy is Continue",
+			failurePolicy: threatestergithubiov1alpha1.FailurePolicyContinue,
+			failedStep:    "spray-a",
+			expected: map[string]threatestergithubiov1alpha1.StepPhase{
+				"spray-a":    threatestergithubiov1alpha1.StepPhaseFailed,
+				"spray-b":    threatestergithubiov1alpha1.StepPhaseSucceeded,
+				"exfiltrate": threatestergithubiov1alpha1.StepPhaseSucceeded,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scenario := threatestergithubiov1alpha1.Scenario{
+				Spec: threatestergithubiov1alpha1.ScenarioSpec{Steps: steps, FailurePolicy: tt.failurePolicy},
+			}
+
+			// both spray steps wait for each other, which only completes when they run in parallel
+			sprays := sync.WaitGroup{}
+			sprays.Add(2)
+
+			phases := map[string]threatestergithubiov1alpha1.StepPhase{}
+			err := ExecuteSteps(context.Background(), scenario,
+				func(ctx context.Context, step threatestergithubiov1alpha1.Step) error {
+					if step.Template == "spray" {
+						sprays.Done()
+						if !waitTimeout(&sprays, 5*time.Second) {
+							return errors.New("spray steps did not run in parallel")
+						}
+					}
+
+					if step.Name == tt.failedStep {
+						return errors.New("failed")
+					}
+					return nil
+				},
+				func(stepStatus threatestergithubiov1alpha1.StepStatus) error {
+					if stepStatus.Phase == threatestergithubiov1alpha1.StepPhaseSucceeded || stepStatus.Phase == threatestergithubiov1alpha1.StepPhaseFailed {
+						if stepStatus.StartTime == nil || stepStatus.CompletionTime == nil {
+							t.Errorf("expected step %s to record its start and completion time", stepStatus.Name)
+						}
+					}
+
+					phases[stepStatus.Name] = stepStatus.Phase
+					return nil
+				},
+			)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %t, got %v", tt.wantErr, err)
+			}
+
+			if !reflect.DeepEqual(phases, tt.expected) {
+				t.Errorf("expected phases %v, got %v", tt.expected, phases)
+			}
+		})
+	}
+
+	t.Run("a status that cannot be recorded stops the run", func(t *testing.T) {
+		scenario := threatestergithubiov1alpha1.Scenario{Spec: threatestergithubiov1alpha1.ScenarioSpec{Steps: steps}}
+
+		executed := false
+		err := ExecuteSteps(context.Background(), scenario,
+			func(ctx context.Context, step threatestergithubiov1alpha1.Step) error {
+				executed = true
+				return nil
+			},
+			func(stepStatus threatestergithubiov1alpha1.StepStatus) error {
+				return errors.New("conflict")
+			},
+		)
+		if err == nil {
+			t.Error("expected an error")
+		}
+		if executed {
+			t.Error("expected no step to run")
+		}
+	})
+}
+
+func waitTimeout(wg *sync.WaitGroup, timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
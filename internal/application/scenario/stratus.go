@@ -0,0 +1,214 @@
+package scenario
+
+import (
+	"fmt"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	DefaultStratusImage = "ghcr.io/datadog/stratus-red-team:v2.11.1"
+
+	// StratusStateSize is the size of the volume keeping the state of Stratus Red Team between the phases.
+	StratusStateSize = "1Gi"
+
+	stratusStateVolume      = "stratus-state"
+	stratusStatePath        = "/root/.stratus-red-team"
+	stratusKubeconfigVolume = "stratus-kubeconfig"
+	stratusKubeconfigPath   = "/root/.kube"
+)
+
+// HasStratusTemplates reports whether one of the templates runs a Stratus Red Team technique.
+func HasStratusTemplates(templates []threatestergithubiov1alpha1.Template) bool {
+	for _, template := range templates {
+		if template.Stratus != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// StratusStateClaimName returns the name of the PVC keeping the state of the Stratus Red Team techniques of the scenario.
+func StratusStateClaimName(scenarioName string) string {
+	return fmt.Sprintf("%s-stratus", scenarioName)
+}
+
+// StratusCleanupJobName returns the name of the job reverting the Stratus Red Team techniques of the scenario.
+func StratusCleanupJobName(scenarioName string) string {
+	return boundJobName(fmt.Sprintf("%s-stratus-cleanup", scenarioName))
+}
+
+// BuildStratusStateClaim builds the PVC shared by the warmup, detonate and cleanup phases of the Stratus Red Team
+// techniques, since the cleanup runs in another pod than the detonation.
+func BuildStratusStateClaim(scenario threatestergithubiov1alpha1.Scenario) *corev1.PersistentVolumeClaim {
+	var storageClassName *string
+	for _, template := range scenario.Spec.Templates {
+		if template.Stratus != nil && template.Stratus.StorageClassName != nil {
+			storageClassName = template.Stratus.StorageClassName
+			break
+		}
+	}
+
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      StratusStateClaimName(scenario.Name),
+			Namespace: scenario.Namespace,
+			Labels:    map[string]string{ScenarioLabel: scenario.Name},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: storageClassName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(StratusStateSize)},
+			},
+		},
+	}
+}
+
+// BuildStratusCleanupJob builds the job reverting the Stratus Red Team techniques of the scenario.
+// The techniques are reverted one after another, in the reverse order of the templates, with the pod templates of the scenario and of their templates.
+func BuildStratusCleanupJob(scenario threatestergithubiov1alpha1.Scenario) (*batchv1.Job, error) {
+	timeout, err := ParseTimeout(scenario.Spec.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	containers := []corev1.Container{}
+	for i := len(scenario.Spec.Templates) - 1; i >= 0; i-- {
+		template := scenario.Spec.Templates[i]
+		if template.Stratus == nil {
+			continue
+		}
+
+		containers = append(containers, stratusContainer(fmt.Sprintf("%s-cleanup", template.Name), *template.Stratus, "cleanup"))
+	}
+
+	if len(containers) == 0 {
+		return nil, fmt.Errorf("scenario has no Stratus Red Team technique")
+	}
+
+	builder := NewScenarioJobBuilder().
+		WithName(StratusCleanupJobName(scenario.Name)).
+		WithNamespace(scenario.Namespace).
+		WithLabels(map[string]string{ScenarioLabel: scenario.Name}).
+		WithJobTemplate(scenario.Spec.JobTemplate).
+		WithTimeout(timeout).
+		WithPodTemplate(scenario.Spec.PodTemplate).
+		WithStratusState(StratusStateClaimName(scenario.Name))
+	// the techniques are reverted with the identity and on the nodes they were detonated with
+	for _, template := range scenario.Spec.Templates {
+		if template.Stratus != nil {
+			builder.WithPodTemplate(template.PodTemplate)
+		}
+	}
+	builder.podSpec.InitContainers = containers[:len(containers)-1]
+	builder.podSpec.Containers = containers[len(containers)-1:]
+	builder.podSpec.RestartPolicy = corev1.RestartPolicyNever
+	builder.withStratusKubeconfigs(scenario.Spec.Templates)
+
+	return builder.Build()
+}
+
+// checkStratusSteps checks that the steps running Stratus Red Team techniques run one after another.
+// They share the volume keeping the state of the techniques, which can only be mounted on a single node at a time.
+func checkStratusSteps(scenario threatestergithubiov1alpha1.Scenario) error {
+	graph, err := StepGraph(scenario.Spec.Steps)
+	if err != nil {
+		return err
+	}
+
+	stratusSteps := []string{}
+	for _, step := range scenario.Spec.Steps {
+		template, err := FindTemplate(scenario.Spec.Templates, step.Template)
+		if err != nil {
+			return fmt.Errorf("step %s: %w", step.Name, err)
+		}
+
+		if template.Stratus != nil {
+			stratusSteps = append(stratusSteps, step.Name)
+		}
+	}
+
+	for i := range stratusSteps {
+		for _, other := range stratusSteps[:i] {
+			if !graph.DependsOn(stratusSteps[i], other) && !graph.DependsOn(other, stratusSteps[i]) {
+				return fmt.Errorf("steps %s and %s run Stratus Red Team techniques in parallel, one of them must depend on the other", other, stratusSteps[i])
+			}
+		}
+	}
+
+	return nil
+}
+
+// WithStratusState mounts the PVC keeping the state of Stratus Red Team into the pod.
+func (b *ScenarioBuilder) WithStratusState(claimName string) *ScenarioBuilder {
+	b.podSpec.Volumes = append(b.podSpec.Volumes, corev1.Volume{
+		Name: stratusStateVolume,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+		},
+	})
+
+	return b
+}
+
+// withStratusKubeconfigs adds the volumes of the kubeconfig secrets mounted by the Stratus Red Team containers.
+func (b *ScenarioBuilder) withStratusKubeconfigs(templates []threatestergithubiov1alpha1.Template) {
+	secrets := map[string]struct{}{}
+	for _, template := range templates {
+		if template.Stratus == nil || template.Stratus.KubeconfigSecretName == "" {
+			continue
+		}
+
+		name := template.Stratus.KubeconfigSecretName
+		if _, ok := secrets[name]; ok {
+			continue
+		}
+		secrets[name] = struct{}{}
+
+		b.podSpec.Volumes = append(b.podSpec.Volumes, corev1.Volume{
+			Name: stratusKubeconfigVolumeName(name),
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: name,
+					Items:      []corev1.KeyToPath{{Key: "config", Path: "config"}},
+				},
+			},
+		})
+	}
+}
+
+// stratusContainer runs a phase of the Stratus Red Team technique, e.g. "warmup", "detonate" or "cleanup".
+func stratusContainer(name string, stratus threatestergithubiov1alpha1.StratusTemplate, phase string) corev1.Container {
+	image := stratus.Image
+	if image == "" {
+		image = DefaultStratusImage
+	}
+
+	container := corev1.Container{
+		Name:         name,
+		Image:        image,
+		Args:         []string{phase, stratus.Technique},
+		Env:          stratus.Env,
+		VolumeMounts: []corev1.VolumeMount{{Name: stratusStateVolume, MountPath: stratusStatePath}},
+	}
+
+	if stratus.KubeconfigSecretName != "" {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      stratusKubeconfigVolumeName(stratus.KubeconfigSecretName),
+			MountPath: stratusKubeconfigPath,
+			ReadOnly:  true,
+		})
+	}
+
+	return container
+}
+
+func stratusKubeconfigVolumeName(secretName string) string {
+	return fmt.Sprintf("%s-%s", stratusKubeconfigVolume, secretName)
+}
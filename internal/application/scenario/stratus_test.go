@@ -0,0 +1,199 @@
+package scenario
+
+import (
+	"reflect"
+	"testing"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+)
+
+func newStratusTestScenario() threatestergithubiov1alpha1.Scenario {
+	return threatestergithubiov1alpha1.Scenario{
+		ObjectMeta: metav1.ObjectMeta{Name: "stratus", Namespace: "default"},
+		Spec: threatestergithubiov1alpha1.ScenarioSpec{
+			Templates: []threatestergithubiov1alpha1.Template{
+				{
+					Name: "steal-token",
+					Stratus: &threatestergithubiov1alpha1.StratusTemplate{
+						Technique:            "k8s.credential-access.steal-serviceaccount-token",
+						KubeconfigSecretName: "kubeconfig",
+					},
+				},
+				{
+					Name: "dump-secrets",
+					Stratus: &threatestergithubiov1alpha1.StratusTemplate{
+						Technique:        "k8s.credential-access.dump-secrets",
+						Image:            "stratus:v2",
+						StorageClassName: pointer.String("standard"),
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestBuildScenarioJobsWithStratus(t *testing.T) {
+	jobs, err := BuildScenarioJobs(newStratusTestScenario())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(jobs) != 1 {
+		t.Fatalf("expected 1 job, got %d", len(jobs))
+	}
+
+	podSpec := jobs[0].Spec.Template.Spec
+
+	initContainers := map[string][]string{}
+	for _, container := range podSpec.InitContainers {
+		initContainers[container.Name] = container.Args
+	}
+	expectedInitContainers := map[string][]string{
+		"steal-token-warmup":  {"warmup", "k8s.credential-access.steal-serviceaccount-token"},
+		"dump-secrets-warmup": {"warmup", "k8s.credential-access.dump-secrets"},
+	}
+	if !reflect.DeepEqual(initContainers, expectedInitContainers) {
+		t.Errorf("expected init containers %v, got %v", expectedInitContainers, initContainers)
+	}
+
+	containers := map[string][]string{}
+	images := map[string]string{}
+	for _, container := range podSpec.Containers {
+		containers[container.Name] = container.Args
+		images[container.Name] = container.Image
+	}
+	expectedContainers := map[string][]string{
+		"steal-token":  {"detonate", "k8s.credential-access.steal-serviceaccount-token"},
+		"dump-secrets": {"detonate", "k8s.credential-access.dump-secrets"},
+	}
+	if !reflect.DeepEqual(containers, expectedContainers) {
+		t.Errorf("expected containers %v, got %v", expectedContainers, containers)
+	}
+	if images["steal-token"] != DefaultStratusImage || images["dump-secrets"] != "stratus:v2" {
+		t.Errorf("unexpected images %v", images)
+	}
+
+	volumes := map[string]corev1.VolumeSource{}
+	for _, volume := range podSpec.Volumes {
+		volumes[volume.Name] = volume.VolumeSource
+	}
+	if claim := volumes[stratusStateVolume].PersistentVolumeClaim; claim == nil || claim.ClaimName != "stratus-stratus" {
+		t.Errorf("expected the state volume to mount the claim stratus-stratus, got %v", volumes[stratusStateVolume])
+	}
+	if secret := volumes["stratus-kubeconfig-kubeconfig"].Secret; secret == nil || secret.SecretName != "kubeconfig" {
+		t.Errorf("expected the kubeconfig volume to mount the secret kubeconfig, got %v", volumes)
+	}
+}
+
+func TestBuildStratusCleanupJob(t *testing.T) {
+	scenario := newStratusTestScenario()
+	scenario.Spec.Templates = append(scenario.Spec.Templates, threatestergithubiov1alpha1.Template{
+		Name: "recon", Container: &corev1.Container{Name: "recon", Image: "alpine"},
+	})
+
+	job, err := BuildStratusCleanupJob(scenario)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if job.Name != "stratus-stratus-cleanup" || job.Labels[ScenarioLabel] != "stratus" {
+		t.Errorf("unexpected job metadata %v", job.ObjectMeta)
+	}
+
+	podSpec := job.Spec.Template.Spec
+	order := []string{}
+	for _, container := range append(podSpec.InitContainers, podSpec.Containers...) {
+		if container.Args[0] != "cleanup" {
+			t.Errorf("expected container %s to clean up, got %v", container.Name, container.Args)
+		}
+		order = append(order, container.Name)
+	}
+
+	expected := []string{"dump-secrets-cleanup", "steal-token-cleanup"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Errorf("expected the techniques to be reverted in order %v, got %v", expected, order)
+	}
+
+	scenario.Spec.PodTemplate = &threatestergithubiov1alpha1.PodTemplate{ServiceAccountName: "threatester"}
+	scenario.Spec.Templates[0].PodTemplate = &threatestergithubiov1alpha1.PodTemplate{
+		ServiceAccountName: "attacker",
+		NodeSelector:       map[string]string{"pool": "attack"},
+		Tolerations:        []corev1.Toleration{{Key: "attack", Operator: corev1.TolerationOpExists}},
+	}
+	job, err = BuildStratusCleanupJob(scenario)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	podSpec = job.Spec.Template.Spec
+	if podSpec.ServiceAccountName != "attacker" || podSpec.NodeSelector["pool"] != "attack" || len(podSpec.Tolerations) != 1 {
+		t.Errorf("expected the cleanup job to run with the pod template of the technique, got %+v", podSpec)
+	}
+
+	scenario.Spec.Templates = scenario.Spec.Templates[2:]
+	if _, err := BuildStratusCleanupJob(scenario); err == nil {
+		t.Error("expected an error for a scenario without Stratus Red Team technique")
+	}
+}
+
+func TestBuildStratusStateClaim(t *testing.T) {
+	claim := BuildStratusStateClaim(newStratusTestScenario())
+
+	if claim.Name != "stratus-stratus" || claim.Namespace != "default" {
+		t.Errorf("unexpected claim %s/%s", claim.Namespace, claim.Name)
+	}
+	if claim.Spec.StorageClassName == nil || *claim.Spec.StorageClassName != "standard" {
+		t.Errorf("expected the storage class of the templates, got %v", claim.Spec.StorageClassName)
+	}
+}
+
+func TestBuildScenarioJobsWithStratusSteps(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   []threatestergithubiov1alpha1.Step
+		wantErr bool
+	}{
+		{
+			name: "steps in order",
+			steps: []threatestergithubiov1alpha1.Step{
+				{Name: "steal-token", Template: "steal-token"},
+				{Name: "dump-secrets", Template: "dump-secrets"},
+			},
+		},
+		{
+			name: "steps depending on each other through another step",
+			steps: []threatestergithubiov1alpha1.Step{
+				{Name: "steal-token", Template: "steal-token"},
+				{Name: "recon", Template: "recon", DependsOn: []string{"steal-token"}},
+				{Name: "dump-secrets", Template: "dump-secrets", DependsOn: []string{"recon"}},
+			},
+		},
+		{
+			name: "steps in parallel",
+			steps: []threatestergithubiov1alpha1.Step{
+				{Name: "recon", Template: "recon"},
+				{Name: "steal-token", Template: "steal-token", DependsOn: []string{"recon"}},
+				{Name: "dump-secrets", Template: "dump-secrets", DependsOn: []string{"recon"}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scenario := newStratusTestScenario()
+			scenario.Spec.Templates = append(scenario.Spec.Templates, threatestergithubiov1alpha1.Template{
+				Name: "recon", Container: &corev1.Container{Name: "recon", Image: "alpine"},
+			})
+			scenario.Spec.Steps = tt.steps
+
+			_, err := BuildScenarioJobs(scenario)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %t, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package scenario
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// NodeAnnotation is the annotation holding the name of the node that the job is pinned to.
+	NodeAnnotation = "threatester.github.io/node"
+
+	DefaultNodeTag = "host"
+)
+
+// SelectNodes returns the names of the nodes the scenario runs on, sorted by name.
+// Nodes that are not ready are never selected, since the pinned pods would not run.
+func SelectNodes(nodes []corev1.Node, target threatestergithubiov1alpha1.Target) ([]string, error) {
+	if target.NodeName != "" {
+		for _, node := range nodes {
+			if node.Name != target.NodeName {
+				continue
+			}
+
+			if !isNodeReady(node) {
+				return nil, fmt.Errorf("node %s is not ready", node.Name)
+			}
+
+			return []string{node.Name}, nil
+		}
+
+		return nil, fmt.Errorf("node %s not found", target.NodeName)
+	}
+
+	selector := labels.Everything()
+	if target.NodeSelector != nil {
+		s, err := metav1.LabelSelectorAsSelector(target.NodeSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node selector: %w", err)
+		}
+		selector = s
+	}
+
+	candidates := []corev1.Node{}
+	for _, node := range nodes {
+		if isNodeReady(node) && selector.Matches(labels.Set(node.Labels)) {
+			candidates = append(candidates, node)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Name < candidates[j].Name })
+
+	selected := []string{}
+	pools := map[string]struct{}{}
+	for _, node := range candidates {
+		if target.NodePoolLabel != "" {
+			pool, ok := node.Labels[target.NodePoolLabel]
+			if !ok {
+				continue
+			}
+
+			if _, ok := pools[pool]; ok {
+				continue
+			}
+			pools[pool] = struct{}{}
+		}
+
+		selected = append(selected, node.Name)
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no ready node matches the target")
+	}
+
+	return selected, nil
+}
+
+// PinScenarioJobs returns a copy of each job pinned to each of the nodes.
+// The jobs are returned as is when no node is given.
+func PinScenarioJobs(jobs []batchv1.Job, nodes []string) []batchv1.Job {
+	if len(nodes) == 0 {
+		return jobs
+	}
+
+	pinned := []batchv1.Job{}
+	for _, node := range nodes {
+		for _, job := range jobs {
+			job := *job.DeepCopy()
+			job.Name = NodeJobName(job.Name, node)
+			if job.Annotations == nil {
+				job.Annotations = map[string]string{}
+			}
+			job.Annotations[NodeAnnotation] = node
+			job.Spec.Template.Spec.NodeName = node
+
+			pinned = append(pinned, job)
+		}
+	}
+
+	return pinned
+}
+
+// NodeJobName returns the name of the job pinned to a node.
+// Node names may be up to 253 characters long, so the job is named after a hash of the node name instead.
+func NodeJobName(jobName string, nodeName string) string {
+	h := fnv.New32a()
+	h.Write([]byte(nodeName))
+
+	return boundJobName(fmt.Sprintf("%s-%08x", jobName, h.Sum32()))
+}
+
+func isNodeReady(node corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,117 @@
+package scenario
+
+import (
+	"reflect"
+	"testing"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestNode(name string, pool string, ready bool) corev1.Node {
+	status := corev1.ConditionTrue
+	if !ready {
+		status = corev1.ConditionFalse
+	}
+
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"pool": pool}},
+		Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}}},
+	}
+}
+
+func TestSelectNodes(t *testing.T) {
+	nodes := []corev1.Node{
+		newTestNode("node-c", "cws", true),
+		newTestNode("node-a", "default", true),
+		newTestNode("node-b", "cws", true),
+		newTestNode("node-d", "cws", false),
+	}
+
+	testCases := []struct {
+		name     string
+		target   threatestergithubiov1alpha1.Target
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name:     "node name",
+			target:   threatestergithubiov1alpha1.Target{NodeName: "node-a"},
+			expected: []string{"node-a"},
+		},
+		{
+			name:    "node name of a node that is not ready",
+			target:  threatestergithubiov1alpha1.Target{NodeName: "node-d"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown node name",
+			target:  threatestergithubiov1alpha1.Target{NodeName: "node-z"},
+			wantErr: true,
+		},
+		{
+			name:     "node selector",
+			target:   threatestergithubiov1alpha1.Target{NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "cws"}}},
+			expected: []string{"node-b", "node-c"},
+		},
+		{
+			name:     "one node of each node pool",
+			target:   threatestergithubiov1alpha1.Target{NodePoolLabel: "pool"},
+			expected: []string{"node-a", "node-b"},
+		},
+		{
+			name:    "no matching node",
+			target:  threatestergithubiov1alpha1.Target{NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "gpu"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := SelectNodes(nodes, tc.target)
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %v", got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestPinScenarioJobs(t *testing.T) {
+	jobs := []batchv1.Job{{ObjectMeta: metav1.ObjectMeta{Name: "scenario"}}}
+
+	pinned := PinScenarioJobs(jobs, []string{"node-a", "node-b"})
+	if len(pinned) != 2 {
+		t.Fatalf("expected 2 jobs, got %d", len(pinned))
+	}
+
+	for i, node := range []string{"node-a", "node-b"} {
+		if pinned[i].Spec.Template.Spec.NodeName != node {
+			t.Errorf("expected job to be pinned to %s, got %s", node, pinned[i].Spec.Template.Spec.NodeName)
+		}
+
+		if pinned[i].Annotations[NodeAnnotation] != node {
+			t.Errorf("expected node annotation %s, got %s", node, pinned[i].Annotations[NodeAnnotation])
+		}
+	}
+
+	if pinned[0].Name == pinned[1].Name {
+		t.Errorf("expected distinct job names, got %s", pinned[0].Name)
+	}
+
+	if jobs[0].Spec.Template.Spec.NodeName != "" {
+		t.Error("expected the original job not to be modified")
+	}
+}
@@ -0,0 +1,151 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ResolveTemplates returns a copy of the scenario where the templates referencing a ScenarioTemplate or ClusterScenarioTemplate
+// hold the container of the referenced template, with its parameters substituted.
+// It also returns the revisions of the referenced templates, sorted by kind and name.
+func ResolveTemplates(ctx context.Context, c client.Reader, scenario threatestergithubiov1alpha1.Scenario) (*threatestergithubiov1alpha1.Scenario, []threatestergithubiov1alpha1.TemplateRevision, error) {
+	resolved := scenario.DeepCopy()
+	revisions := map[string]threatestergithubiov1alpha1.TemplateRevision{}
+
+	errs := []error{}
+	for i, template := range resolved.Spec.Templates {
+		if template.TemplateRef == nil {
+			continue
+		}
+
+		spec, revision, err := getTemplateSpec(ctx, c, scenario.Namespace, *template.TemplateRef)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to resolve template %s: %w", template.Name, err))
+			continue
+		}
+		revisions[TemplateRefKey(revision.Kind, revision.Name)] = revision
+
+		container, err := RenderTemplateContainer(*spec, template.TemplateRef.Parameters)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to render template %s: %w", template.Name, err))
+			continue
+		}
+
+		if container.Name == "" {
+			container.Name = template.Name
+		}
+		resolved.Spec.Templates[i].Container = &container
+
+		if template.PodTemplate == nil && spec.PodTemplate != nil {
+			resolved.Spec.Templates[i].PodTemplate = spec.PodTemplate.DeepCopy()
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, nil, utilerrors.NewAggregate(errs)
+	}
+
+	keys := make([]string, 0, len(revisions))
+	for key := range revisions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	sorted := make([]threatestergithubiov1alpha1.TemplateRevision, 0, len(keys))
+	for _, key := range keys {
+		sorted = append(sorted, revisions[key])
+	}
+
+	return resolved, sorted, nil
+}
+
+func getTemplateSpec(ctx context.Context, c client.Reader, namespace string, ref threatestergithubiov1alpha1.TemplateRef) (*threatestergithubiov1alpha1.ScenarioTemplateSpec, threatestergithubiov1alpha1.TemplateRevision, error) {
+	kind := TemplateRefKind(ref)
+	revision := threatestergithubiov1alpha1.TemplateRevision{Kind: kind, Name: ref.Name}
+
+	switch kind {
+	case threatestergithubiov1alpha1.TemplateKindClusterScenarioTemplate:
+		template := &threatestergithubiov1alpha1.ClusterScenarioTemplate{}
+		if err := c.Get(ctx, types.NamespacedName{Name: ref.Name}, template); err != nil {
+			return nil, revision, err
+		}
+
+		revision.Generation = template.Generation
+		return &template.Spec, revision, nil
+	default:
+		template := &threatestergithubiov1alpha1.ScenarioTemplate{}
+		if err := c.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, template); err != nil {
+			return nil, revision, err
+		}
+
+		revision.Generation = template.Generation
+		return &template.Spec, revision, nil
+	}
+}
+
+// TemplateRefKind returns the kind of the referenced template, which defaults to ScenarioTemplate.
+func TemplateRefKind(ref threatestergithubiov1alpha1.TemplateRef) threatestergithubiov1alpha1.TemplateKind {
+	if ref.Kind == "" {
+		return threatestergithubiov1alpha1.TemplateKindScenarioTemplate
+	}
+
+	return ref.Kind
+}
+
+// TemplateRefKey identifies a referenced template, e.g. "ClusterScenarioTemplate/reverse-shell".
+func TemplateRefKey(kind threatestergithubiov1alpha1.TemplateKind, name string) string {
+	return fmt.Sprintf("%s/%s", kind, name)
+}
+
+// TemplateRefKeys returns the keys of the templates referenced by the scenario.
+func TemplateRefKeys(scenario threatestergithubiov1alpha1.Scenario) []string {
+	keys := []string{}
+	for _, template := range scenario.Spec.Templates {
+		if template.TemplateRef == nil {
+			continue
+		}
+
+		keys = append(keys, TemplateRefKey(TemplateRefKind(*template.TemplateRef), template.TemplateRef.Name))
+	}
+
+	return keys
+}
+
+// TemplateRevisionsChanged reports whether the referenced templates differ from the ones the scenario last ran with.
+func TemplateRevisionsChanged(last []threatestergithubiov1alpha1.TemplateRevision, current []threatestergithubiov1alpha1.TemplateRevision) bool {
+	if len(last) != len(current) {
+		return true
+	}
+
+	for i := range last {
+		if last[i] != current[i] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RenderTemplateContainer returns the container of the template, with the parameters substituted into its image, command, args and env.
+// Values of parameters that the template does not declare, and missing values of parameters without a default, are rejected.
+func RenderTemplateContainer(spec threatestergithubiov1alpha1.ScenarioTemplateSpec, values map[string]string) (corev1.Container, error) {
+	params, errs := threatestergithubiov1alpha1.ParameterValues(spec.Parameters, values, field.NewPath("spec", "parameters"), field.NewPath("templateRef", "parameters"))
+	if len(errs) > 0 {
+		return corev1.Container{}, errs.ToAggregate()
+	}
+
+	container := *spec.Container.DeepCopy()
+	if errs := threatestergithubiov1alpha1.RenderContainerParameters(&container, params, field.NewPath("container")); len(errs) > 0 {
+		return corev1.Container{}, errs.ToAggregate()
+	}
+
+	return container, nil
+}
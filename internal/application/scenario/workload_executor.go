@@ -0,0 +1,276 @@
+package scenario
+
+//go:generate moq -rm -out workload_executor_mock.go . WorkloadExecutor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+	"k8s.io/utils/pointer"
+)
+
+// MaxOutputLength is the maximum length of the command output recorded in the execution status.
+const MaxOutputLength = 4096
+
+type WorkloadExecutor interface {
+	Execute(ctx context.Context, namespace string, template threatestergithubiov1alpha1.Template) (threatestergithubiov1alpha1.ExecutionStatus, error)
+}
+
+type workloadExecutor struct {
+	clientset kubernetes.Interface
+	config    *rest.Config
+}
+
+func NewWorkloadExecutor(clientset kubernetes.Interface, config *rest.Config) WorkloadExecutor {
+	return &workloadExecutor{
+		clientset: clientset,
+		config:    config,
+	}
+}
+
+// Execute runs the template inside the pod selected by its workload and waits for the command to complete.
+// A command exiting with a non-zero code is reported as an error, like a failed scenario job.
+func (e *workloadExecutor) Execute(ctx context.Context, namespace string, template threatestergithubiov1alpha1.Template) (threatestergithubiov1alpha1.ExecutionStatus, error) {
+	now := metav1.Now()
+	status := threatestergithubiov1alpha1.ExecutionStatus{Template: template.Name, StartTime: &now}
+
+	if template.Workload == nil || template.Container == nil {
+		return status, fmt.Errorf("template %s has no workload or container", template.Name)
+	}
+
+	if template.Workload.Namespace != "" {
+		namespace = template.Workload.Namespace
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&template.Workload.Selector)
+	if err != nil {
+		return status, fmt.Errorf("invalid workload selector: %w", err)
+	}
+
+	pods, err := e.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return status, fmt.Errorf("failed to list workload pods: %w", err)
+	}
+
+	pod, container, err := selectWorkloadPod(pods.Items, template.Workload.Container)
+	if err != nil {
+		return status, err
+	}
+	status.Pod = pod.Name
+	status.Container = container
+
+	var output string
+	var exitCode *int32
+	switch template.Workload.Mode {
+	case threatestergithubiov1alpha1.WorkloadModeEphemeralContainer:
+		output, exitCode, err = e.runEphemeralContainer(ctx, pod, container, template)
+	default:
+		output, exitCode, err = e.exec(ctx, pod, container, template)
+	}
+
+	completionTime := metav1.Now()
+	status.CompletionTime = &completionTime
+	status.Output = TailLogs(output, MaxOutputLength)
+	status.ExitCode = exitCode
+
+	if err != nil {
+		return status, err
+	}
+
+	if exitCode != nil && *exitCode != 0 {
+		return status, fmt.Errorf("command exited with code %d", *exitCode)
+	}
+
+	return status, nil
+}
+
+// exec runs the command of the template container in the target container through the pods/exec subresource.
+func (e *workloadExecutor) exec(ctx context.Context, pod *corev1.Pod, container string, template threatestergithubiov1alpha1.Template) (string, *int32, error) {
+	command := append(append([]string{}, template.Container.Command...), template.Container.Args...)
+	if len(command) == 0 {
+		return "", nil, fmt.Errorf("template %s has no command to exec", template.Name)
+	}
+
+	req := e.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(e.config, "POST", req.URL())
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to exec in pod %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+
+	output := &syncBuffer{}
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{Stdout: output, Stderr: output})
+
+	var exitErr utilexec.ExitError
+	if errors.As(err, &exitErr) {
+		return output.String(), pointer.Int32(int32(exitErr.ExitStatus())), nil
+	}
+	if err != nil {
+		return output.String(), nil, fmt.Errorf("failed to exec in pod %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+
+	return output.String(), pointer.Int32(0), nil
+}
+
+// runEphemeralContainer injects the template container into the pod and waits for it to terminate.
+// Ephemeral containers cannot be removed from a pod, so each run adds a new one with a unique name.
+func (e *workloadExecutor) runEphemeralContainer(ctx context.Context, pod *corev1.Pod, container string, template threatestergithubiov1alpha1.Template) (string, *int32, error) {
+	ephemeralContainer := newEphemeralContainer(template, container)
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, ephemeralContainer)
+
+	if _, err := e.clientset.CoreV1().Pods(pod.Namespace).UpdateEphemeralContainers(ctx, pod.Name, pod, metav1.UpdateOptions{}); err != nil {
+		return "", nil, fmt.Errorf("failed to add ephemeral container to pod %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+
+	var exitCode int32
+	err := func() error {
+		for {
+			current, err := e.clientset.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get pod %s/%s: %w", pod.Namespace, pod.Name, err)
+			}
+
+			status := findEphemeralContainerStatus(current, ephemeralContainer.Name)
+			if status != nil && status.State.Terminated != nil {
+				exitCode = status.State.Terminated.ExitCode
+				return nil
+			}
+
+			// an ephemeral container whose image cannot be pulled would otherwise be waited for until the scenario times out
+			if status != nil {
+				if stuck := findStuckContainer(current.Name, []corev1.ContainerStatus{*status}); stuck != nil {
+					return stuck
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(RetryInterval):
+			}
+		}
+	}()
+	if err != nil {
+		return "", nil, err
+	}
+
+	logs, err := e.clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{Container: ephemeralContainer.Name}).Do(ctx).Raw()
+	if err != nil {
+		return "", &exitCode, fmt.Errorf("failed to get logs of ephemeral container %s: %w", ephemeralContainer.Name, err)
+	}
+
+	return string(logs), &exitCode, nil
+}
+
+// findEphemeralContainerStatus returns the status of the ephemeral container of the pod, or nil when it has none yet.
+func findEphemeralContainerStatus(pod *corev1.Pod, name string) *corev1.ContainerStatus {
+	for i := range pod.Status.EphemeralContainerStatuses {
+		if pod.Status.EphemeralContainerStatuses[i].Name == name {
+			return &pod.Status.EphemeralContainerStatuses[i]
+		}
+	}
+
+	return nil
+}
+
+// selectWorkloadPod returns the first running pod by name and the name of the target container.
+func selectWorkloadPod(pods []corev1.Pod, container string) (*corev1.Pod, string, error) {
+	running := []corev1.Pod{}
+	for _, pod := range pods {
+		if pod.Status.Phase == corev1.PodRunning && pod.DeletionTimestamp == nil {
+			running = append(running, pod)
+		}
+	}
+
+	if len(running) == 0 {
+		return nil, "", fmt.Errorf("no running pod matches the workload selector")
+	}
+
+	sort.Slice(running, func(i, j int) bool { return running[i].Name < running[j].Name })
+	pod := running[0]
+
+	if container == "" {
+		return &pod, pod.Spec.Containers[0].Name, nil
+	}
+
+	for _, c := range pod.Spec.Containers {
+		if c.Name == container {
+			return &pod, container, nil
+		}
+	}
+
+	return nil, "", fmt.Errorf("container %s not found in pod %s/%s", container, pod.Namespace, pod.Name)
+}
+
+func newEphemeralContainer(template threatestergithubiov1alpha1.Template, targetContainer string) corev1.EphemeralContainer {
+	c := template.Container
+
+	return corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:            fmt.Sprintf("threatester-%s-%s", template.Name, utilrand.String(5)),
+			Image:           c.Image,
+			Command:         c.Command,
+			Args:            c.Args,
+			WorkingDir:      c.WorkingDir,
+			Env:             c.Env,
+			EnvFrom:         c.EnvFrom,
+			VolumeMounts:    c.VolumeMounts,
+			ImagePullPolicy: c.ImagePullPolicy,
+			SecurityContext: c.SecurityContext,
+		},
+		TargetContainerName: targetContainer,
+	}
+}
+
+// TailLogs returns the last maxLength bytes of the logs.
+func TailLogs(logs string, maxLength int) string {
+	if len(logs) <= maxLength {
+		return logs
+	}
+
+	return logs[len(logs)-maxLength:]
+}
+
+// syncBuffer is a buffer safe for the concurrent writes of stdout and stderr.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
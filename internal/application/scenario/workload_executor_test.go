@@ -0,0 +1,128 @@
+package scenario
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newTestPod(name string, phase corev1.PodPhase) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{
+			{Name: "app", Image: "nginx"},
+			{Name: "sidecar", Image: "envoy"},
+		}},
+		Status: corev1.PodStatus{Phase: phase},
+	}
+}
+
+func TestSelectWorkloadPod(t *testing.T) {
+	pods := []corev1.Pod{
+		newTestPod("web-c", corev1.PodRunning),
+		newTestPod("web-a", corev1.PodPending),
+		newTestPod("web-b", corev1.PodRunning),
+	}
+
+	t.Run("first running pod and its first container", func(t *testing.T) {
+		pod, container, err := selectWorkloadPod(pods, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if pod.Name != "web-b" || container != "app" {
+			t.Errorf("expected web-b/app, got %s/%s", pod.Name, container)
+		}
+	})
+
+	t.Run("named container", func(t *testing.T) {
+		_, container, err := selectWorkloadPod(pods, "sidecar")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if container != "sidecar" {
+			t.Errorf("expected sidecar, got %s", container)
+		}
+	})
+
+	t.Run("unknown container", func(t *testing.T) {
+		if _, _, err := selectWorkloadPod(pods, "unknown"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("no running pod", func(t *testing.T) {
+		if _, _, err := selectWorkloadPod(pods[1:2], ""); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestNewEphemeralContainer(t *testing.T) {
+	template := threatestergithubiov1alpha1.Template{
+		Name:      "read-token",
+		Container: &corev1.Container{Name: "read-token", Image: "alpine", Command: []string{"cat", "/var/run/secrets/kubernetes.io/serviceaccount/token"}},
+	}
+
+	container := newEphemeralContainer(template, "app")
+
+	if !strings.HasPrefix(container.Name, "threatester-read-token-") {
+		t.Errorf("expected a unique name for the template, got %s", container.Name)
+	}
+
+	if container.TargetContainerName != "app" || container.Image != "alpine" {
+		t.Errorf("expected alpine targeting app, got %s targeting %s", container.Image, container.TargetContainerName)
+	}
+}
+
+func TestRunEphemeralContainerStuck(t *testing.T) {
+	pod := newTestPod("app-0", corev1.PodRunning)
+	clientset := fake.NewSimpleClientset(&pod)
+
+	// the kubelet cannot pull the image of the ephemeral container
+	clientset.PrependReactor("get", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		obj, err := clientset.Tracker().Get(corev1.SchemeGroupVersion.WithResource("pods"), "default", "app-0")
+		if err != nil {
+			return true, nil, err
+		}
+
+		current := obj.(*corev1.Pod)
+		for _, c := range current.Spec.EphemeralContainers {
+			current.Status.EphemeralContainerStatuses = append(current.Status.EphemeralContainerStatuses, corev1.ContainerStatus{
+				Name:  c.Name,
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "Back-off pulling image"}},
+			})
+		}
+
+		return true, current, nil
+	})
+
+	template := threatestergithubiov1alpha1.Template{
+		Name:      "read-token",
+		Container: &corev1.Container{Name: "read-token", Image: "missing", Command: []string{"true"}},
+	}
+
+	executor := &workloadExecutor{clientset: clientset}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _, err := executor.runEphemeralContainer(ctx, &pod, "app", template)
+
+	stuck := FindStuckPodError(err)
+	if stuck == nil {
+		t.Fatalf("expected a stuck pod error, got %v", err)
+	}
+
+	if stuck.Pod != "app-0" || stuck.Reason != "ImagePullBackOff" || !strings.HasPrefix(stuck.Container, "threatester-read-token-") {
+		t.Errorf("expected the ephemeral container of app-0 to be stuck pulling its image, got %v", stuck)
+	}
+}
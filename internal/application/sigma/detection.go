@@ -0,0 +1,438 @@
+package sigma
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// expression is a node of a parsed detection: and, or, a field match or a keyword.
+type expression interface{}
+
+type and []expression
+
+type or []expression
+
+// match compares a field with a pattern, e.g. "Image|endswith: /bin/sh".
+type match struct {
+	Field   string
+	Pattern pattern
+	// Regex is set by the re modifier. The value is a regular expression instead of a pattern.
+	Regex   string
+	Negated bool
+}
+
+// keyword matches a pattern anywhere in the log.
+type keyword struct {
+	Pattern pattern
+	Negated bool
+}
+
+// pattern is a Sigma value, where * matches any characters and ? matches a single character.
+type pattern []patternPart
+
+type patternPart struct {
+	Literal string
+	// Wildcard is '*' or '?', in which case Literal is empty.
+	Wildcard rune
+}
+
+// parseDetection parses the detection of the rule into an expression with the negations on the matches.
+// Aggregations, the near operator and the modifiers other than contains, startswith, endswith, all and re
+// are not supported.
+func parseDetection(detection map[string]interface{}) (expression, error) {
+	searches := map[string]expression{}
+	for name, value := range detection {
+		if name == "condition" || name == "timeframe" {
+			continue
+		}
+
+		search, err := parseSearch(value)
+		if err != nil {
+			return nil, fmt.Errorf("search %s: %w", name, err)
+		}
+		searches[name] = search
+	}
+
+	var conditions []string
+	switch condition := detection["condition"].(type) {
+	case string:
+		conditions = []string{condition}
+	case []interface{}:
+		for _, c := range condition {
+			s, ok := c.(string)
+			if !ok {
+				return nil, fmt.Errorf("condition must be a string, got %T", c)
+			}
+			conditions = append(conditions, s)
+		}
+	default:
+		return nil, fmt.Errorf("condition is missing")
+	}
+
+	expressions := or{}
+	for _, condition := range conditions {
+		if strings.Contains(condition, "|") {
+			return nil, fmt.Errorf("aggregation in condition %q is not supported", condition)
+		}
+
+		parser := &conditionParser{tokens: tokenizeCondition(condition), searches: searches}
+		e, err := parser.parseOr()
+		if err != nil {
+			return nil, fmt.Errorf("condition %q: %w", condition, err)
+		}
+		if parser.position < len(parser.tokens) {
+			return nil, fmt.Errorf("condition %q: unexpected %q", condition, parser.tokens[parser.position])
+		}
+		expressions = append(expressions, e)
+	}
+
+	if len(expressions) == 1 {
+		return expressions[0], nil
+	}
+
+	return expressions, nil
+}
+
+// parseSearch parses a search identifier: a map of fields, a list of maps or a list of keywords.
+func parseSearch(value interface{}) (expression, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return parseFields(v)
+	case []interface{}:
+		alternatives := or{}
+		for _, item := range v {
+			if fields, ok := item.(map[string]interface{}); ok {
+				e, err := parseFields(fields)
+				if err != nil {
+					return nil, err
+				}
+				alternatives = append(alternatives, e)
+				continue
+			}
+
+			s, err := formatValue(item)
+			if err != nil {
+				return nil, err
+			}
+			alternatives = append(alternatives, keyword{Pattern: parsePattern(s)})
+		}
+
+		return alternatives, nil
+	default:
+		return nil, fmt.Errorf("unsupported search of type %T", value)
+	}
+}
+
+func parseFields(fields map[string]interface{}) (expression, error) {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	matches := and{}
+	for _, key := range keys {
+		parts := strings.Split(key, "|")
+		field, modifiers := parts[0], parts[1:]
+
+		values := []interface{}{fields[key]}
+		if list, ok := fields[key].([]interface{}); ok {
+			values = list
+		}
+
+		all := false
+		transform := func(s string) string { return s }
+		regex := false
+		for _, modifier := range modifiers {
+			switch modifier {
+			case "contains":
+				transform = func(s string) string { return "*" + s + "*" }
+			case "startswith":
+				transform = func(s string) string { return s + "*" }
+			case "endswith":
+				transform = func(s string) string { return "*" + s }
+			case "all":
+				all = true
+			case "re":
+				regex = true
+			default:
+				return nil, fmt.Errorf("modifier %s of field %s is not supported", modifier, field)
+			}
+		}
+
+		alternatives := or{}
+		for _, value := range values {
+			s, err := formatValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", field, err)
+			}
+
+			if regex {
+				alternatives = append(alternatives, match{Field: field, Regex: s})
+				continue
+			}
+			alternatives = append(alternatives, match{Field: field, Pattern: parsePattern(transform(s))})
+		}
+
+		switch {
+		case len(alternatives) == 1:
+			matches = append(matches, alternatives[0])
+		case all:
+			matches = append(matches, and(alternatives))
+		default:
+			matches = append(matches, alternatives)
+		}
+	}
+
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+
+	return matches, nil
+}
+
+func formatValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case nil:
+		return "", fmt.Errorf("null values are not supported")
+	default:
+		return "", fmt.Errorf("unsupported value of type %T", value)
+	}
+}
+
+// parsePattern parses the wildcards of a Sigma value. A backslash escapes a wildcard or a backslash.
+func parsePattern(value string) pattern {
+	p := pattern{}
+	literal := strings.Builder{}
+	flush := func() {
+		if literal.Len() > 0 {
+			p = append(p, patternPart{Literal: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	runes := []rune(value)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == '\\' && i+1 < len(runes) && (runes[i+1] == '*' || runes[i+1] == '?' || runes[i+1] == '\\'):
+			literal.WriteRune(runes[i+1])
+			i++
+		case r == '*' || r == '?':
+			flush()
+			p = append(p, patternPart{Wildcard: r})
+		default:
+			literal.WriteRune(r)
+		}
+	}
+	flush()
+
+	return p
+}
+
+func (p pattern) hasWildcard() bool {
+	for _, part := range p {
+		if part.Wildcard != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p pattern) literal() string {
+	s := strings.Builder{}
+	for _, part := range p {
+		s.WriteString(part.Literal)
+	}
+
+	return s.String()
+}
+
+// negate pushes the negation of the expression down to its matches.
+func negate(e expression) expression {
+	switch v := e.(type) {
+	case and:
+		negated := or{}
+		for _, item := range v {
+			negated = append(negated, negate(item))
+		}
+		return negated
+	case or:
+		negated := and{}
+		for _, item := range v {
+			negated = append(negated, negate(item))
+		}
+		return negated
+	case match:
+		v.Negated = !v.Negated
+		return v
+	case keyword:
+		v.Negated = !v.Negated
+		return v
+	}
+
+	return e
+}
+
+func tokenizeCondition(condition string) []string {
+	condition = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(condition)
+	return strings.Fields(condition)
+}
+
+type conditionParser struct {
+	tokens   []string
+	position int
+	searches map[string]expression
+}
+
+func (p *conditionParser) peek() string {
+	if p.position < len(p.tokens) {
+		return p.tokens[p.position]
+	}
+
+	return ""
+}
+
+func (p *conditionParser) next() string {
+	token := p.peek()
+	p.position++
+	return token
+}
+
+func (p *conditionParser) parseOr() (expression, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	alternatives := or{left}
+	for strings.EqualFold(p.peek(), "or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, right)
+	}
+
+	if len(alternatives) == 1 {
+		return left, nil
+	}
+
+	return alternatives, nil
+}
+
+func (p *conditionParser) parseAnd() (expression, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	conjunction := and{left}
+	for strings.EqualFold(p.peek(), "and") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		conjunction = append(conjunction, right)
+	}
+
+	if len(conjunction) == 1 {
+		return left, nil
+	}
+
+	return conjunction, nil
+}
+
+func (p *conditionParser) parseNot() (expression, error) {
+	if strings.EqualFold(p.peek(), "not") {
+		p.next()
+		e, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		return negate(e), nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *conditionParser) parsePrimary() (expression, error) {
+	token := p.next()
+
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("unexpected end of condition")
+	case token == "(":
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		return e, nil
+	case token == "1" || strings.EqualFold(token, "all"):
+		if !strings.EqualFold(p.next(), "of") {
+			return nil, fmt.Errorf("expected \"of\" after %q", token)
+		}
+
+		searches, err := p.matchSearches(p.next())
+		if err != nil {
+			return nil, err
+		}
+
+		if token == "1" {
+			return or(searches), nil
+		}
+		return and(searches), nil
+	}
+
+	search, ok := p.searches[token]
+	if !ok {
+		return nil, fmt.Errorf("search %s not found", token)
+	}
+
+	return search, nil
+}
+
+// matchSearches returns the searches matching the target of "1 of" or "all of", sorted by name.
+// "them" matches all searches, except the ones starting with an underscore.
+func (p *conditionParser) matchSearches(target string) ([]expression, error) {
+	names := []string{}
+	for name := range p.searches {
+		if target == "them" {
+			if !strings.HasPrefix(name, "_") {
+				names = append(names, name)
+			}
+			continue
+		}
+
+		if matched, err := path.Match(target, name); err == nil && matched {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no search matches %q", target)
+	}
+	sort.Strings(names)
+
+	searches := []expression{}
+	for _, name := range names {
+		searches = append(searches, p.searches[name])
+	}
+
+	return searches, nil
+}
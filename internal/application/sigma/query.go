@@ -0,0 +1,229 @@
+package sigma
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// characters escaped in the values of Datadog log search and Lucene queries
+const queryReservedCharacters = `+-=&|><!(){}[]^"~*?:\/ `
+
+var invalidLokiLabelCharacters = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// DatadogLogsQuery translates the detection of the rule into a Datadog log search query.
+// The fields of the rule are searched as attributes, e.g. "@Image", unless they are mapped by fields.
+func DatadogLogsQuery(rule Rule, fields map[string]string) (string, error) {
+	e, err := parseDetection(rule.Detection)
+	if err != nil {
+		return "", err
+	}
+
+	return renderQuery(e, queryDialect{
+		and: "AND", or: "OR", not: "-",
+		field: func(name string) string {
+			if mapped, ok := fields[name]; ok {
+				return mapped
+			}
+			return "@" + name
+		},
+		regex: func(field string, regex string) (string, error) {
+			return "", fmt.Errorf("regular expressions are not supported by Datadog log search")
+		},
+	})
+}
+
+// LuceneQuery translates the detection of the rule into a Lucene query string for Elasticsearch.
+func LuceneQuery(rule Rule, fields map[string]string) (string, error) {
+	e, err := parseDetection(rule.Detection)
+	if err != nil {
+		return "", err
+	}
+
+	return renderQuery(e, queryDialect{
+		and: "AND", or: "OR", not: "NOT ",
+		field: func(name string) string {
+			if mapped, ok := fields[name]; ok {
+				return mapped
+			}
+			return escapeQueryValue(name)
+		},
+		regex: func(field string, regex string) (string, error) {
+			return fmt.Sprintf("%s:/%s/", field, strings.ReplaceAll(regex, "/", `\/`)), nil
+		},
+	})
+}
+
+// LokiQuery translates the detection of the rule into a LogQL log query on the streams of the selector.
+// The logs are parsed as JSON, which flattens the nested fields with underscores, e.g. "objectRef_resource".
+// Keywords are not supported, since the label filters cannot search the whole line.
+func LokiQuery(rule Rule, selector string, fields map[string]string) (string, error) {
+	if selector == "" {
+		return "", fmt.Errorf("stream selector is required")
+	}
+
+	e, err := parseDetection(rule.Detection)
+	if err != nil {
+		return "", err
+	}
+
+	filter, err := renderLokiFilter(e, fields)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s | json | %s", selector, filter), nil
+}
+
+type queryDialect struct {
+	and, or, not string
+	field        func(name string) string
+	regex        func(field string, regex string) (string, error)
+}
+
+func renderQuery(e expression, dialect queryDialect) (string, error) {
+	join := func(items []expression, operator string) (string, error) {
+		rendered := []string{}
+		for _, item := range items {
+			s, err := renderQuery(item, dialect)
+			if err != nil {
+				return "", err
+			}
+			rendered = append(rendered, s)
+		}
+
+		if len(rendered) == 1 {
+			return rendered[0], nil
+		}
+		return "(" + strings.Join(rendered, " "+operator+" ") + ")", nil
+	}
+
+	switch v := e.(type) {
+	case and:
+		return join(v, dialect.and)
+	case or:
+		return join(v, dialect.or)
+	case match:
+		field := dialect.field(v.Field)
+
+		var s string
+		if v.Regex != "" {
+			regex, err := dialect.regex(field, v.Regex)
+			if err != nil {
+				return "", err
+			}
+			s = regex
+		} else {
+			s = fmt.Sprintf("%s:%s", field, renderQueryPattern(v.Pattern))
+		}
+
+		if v.Negated {
+			return dialect.not + s, nil
+		}
+		return s, nil
+	case keyword:
+		s := renderQueryPattern(v.Pattern)
+		if v.Negated {
+			return dialect.not + s, nil
+		}
+		return s, nil
+	}
+
+	return "", fmt.Errorf("unsupported expression %T", e)
+}
+
+// renderQueryPattern escapes the literals of the pattern and keeps its wildcards, which both Datadog and Lucene support.
+func renderQueryPattern(p pattern) string {
+	if !p.hasWildcard() {
+		return strconv.Quote(p.literal())
+	}
+
+	s := strings.Builder{}
+	for _, part := range p {
+		if part.Wildcard != 0 {
+			s.WriteRune(part.Wildcard)
+			continue
+		}
+		s.WriteString(escapeQueryValue(part.Literal))
+	}
+
+	return s.String()
+}
+
+func escapeQueryValue(value string) string {
+	s := strings.Builder{}
+	for _, r := range value {
+		if strings.ContainsRune(queryReservedCharacters, r) {
+			s.WriteRune('\\')
+		}
+		s.WriteRune(r)
+	}
+
+	return s.String()
+}
+
+func renderLokiFilter(e expression, fields map[string]string) (string, error) {
+	join := func(items []expression, operator string) (string, error) {
+		rendered := []string{}
+		for _, item := range items {
+			s, err := renderLokiFilter(item, fields)
+			if err != nil {
+				return "", err
+			}
+			rendered = append(rendered, s)
+		}
+
+		if len(rendered) == 1 {
+			return rendered[0], nil
+		}
+		return "(" + strings.Join(rendered, " "+operator+" ") + ")", nil
+	}
+
+	switch v := e.(type) {
+	case and:
+		return join(v, "and")
+	case or:
+		return join(v, "or")
+	case match:
+		label, ok := fields[v.Field]
+		if !ok {
+			label = invalidLokiLabelCharacters.ReplaceAllString(v.Field, "_")
+		}
+
+		operator, value := "=", v.Pattern.literal()
+		switch {
+		case v.Regex != "":
+			// Sigma regular expressions match anywhere in the value, while LogQL anchors them
+			operator, value = "=~", fmt.Sprintf(".*(?:%s).*", v.Regex)
+		case v.Pattern.hasWildcard():
+			operator, value = "=~", lokiPatternRegex(v.Pattern)
+		}
+
+		if v.Negated {
+			operator = map[string]string{"=": "!=", "=~": "!~"}[operator]
+		}
+
+		return fmt.Sprintf("%s%s%s", label, operator, strconv.Quote(value)), nil
+	case keyword:
+		return "", fmt.Errorf("keywords are not supported by Loki")
+	}
+
+	return "", fmt.Errorf("unsupported expression %T", e)
+}
+
+func lokiPatternRegex(p pattern) string {
+	s := strings.Builder{}
+	for _, part := range p {
+		switch part.Wildcard {
+		case '*':
+			s.WriteString(".*")
+		case '?':
+			s.WriteString(".")
+		default:
+			s.WriteString(regexp.QuoteMeta(part.Literal))
+		}
+	}
+
+	return s.String()
+}
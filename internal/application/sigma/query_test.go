@@ -0,0 +1,133 @@
+package sigma
+
+import (
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func newRule(t *testing.T, detection string) Rule {
+	t.Helper()
+
+	rule := Rule{ID: "test"}
+	if err := yaml.Unmarshal([]byte(detection), &rule.Detection); err != nil {
+		t.Fatal(err)
+	}
+
+	return rule
+}
+
+func TestQueries(t *testing.T) {
+	tests := []struct {
+		name      string
+		detection string
+		fields    map[string]string
+		datadog   string
+		lucene    string
+		loki      string
+	}{
+		{
+			name: "single selection",
+			detection: `
+selection:
+  objectRef.resource: secrets
+  verb: list
+condition: selection`,
+			datadog: `(@objectRef.resource:"secrets" AND @verb:"list")`,
+			lucene:  `(objectRef.resource:"secrets" AND verb:"list")`,
+			loki:    `{job="audit"} | json | (objectRef_resource="secrets" and verb="list")`,
+		},
+		{
+			name: "list of values and modifiers",
+			detection: `
+selection:
+  Image|endswith:
+    - /bin/sh
+    - /bin/bash
+  CommandLine|contains: cat /etc/shadow
+condition: selection`,
+			datadog: `(@CommandLine:*cat\ \/etc\/shadow* AND (@Image:*\/bin\/sh OR @Image:*\/bin\/bash))`,
+			lucene:  `(CommandLine:*cat\ \/etc\/shadow* AND (Image:*\/bin\/sh OR Image:*\/bin\/bash))`,
+			loki:    `{job="audit"} | json | (CommandLine=~".*cat /etc/shadow.*" and (Image=~".*/bin/sh" or Image=~".*/bin/bash"))`,
+		},
+		{
+			name: "negated selection and mapped fields",
+			detection: `
+selection:
+  verb: get
+filter:
+  user.username|startswith: 'system:'
+  userAgent: kubectl
+condition: selection and not filter`,
+			fields:  map[string]string{"verb": "http_method"},
+			datadog: `(http_method:"get" AND (-@user.username:system\:* OR -@userAgent:"kubectl"))`,
+			lucene:  `(http_method:"get" AND (NOT user.username:system\:* OR NOT userAgent:"kubectl"))`,
+			loki:    `{job="audit"} | json | (http_method="get" and (user_username!~"system:.*" or userAgent!="kubectl"))`,
+		},
+		{
+			name: "1 of selections",
+			detection: `
+selection_exec:
+  verb: create
+  objectRef.subresource: exec
+selection_attach:
+  objectRef.subresource: attach
+condition: 1 of selection_*`,
+			datadog: `(@objectRef.subresource:"attach" OR (@objectRef.subresource:"exec" AND @verb:"create"))`,
+			lucene:  `(objectRef.subresource:"attach" OR (objectRef.subresource:"exec" AND verb:"create"))`,
+			loki:    `{job="audit"} | json | (objectRef_subresource="attach" or (objectRef_subresource="exec" and verb="create"))`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := newRule(t, tt.detection)
+
+			if got, err := DatadogLogsQuery(rule, tt.fields); err != nil || got != tt.datadog {
+				t.Errorf("expected Datadog query %s, got %s (err: %v)", tt.datadog, got, err)
+			}
+			if got, err := LuceneQuery(rule, tt.fields); err != nil || got != tt.lucene {
+				t.Errorf("expected Lucene query %s, got %s (err: %v)", tt.lucene, got, err)
+			}
+			if got, err := LokiQuery(rule, `{job="audit"}`, tt.fields); err != nil || got != tt.loki {
+				t.Errorf("expected Loki query %s, got %s (err: %v)", tt.loki, got, err)
+			}
+		})
+	}
+}
+
+func TestQueriesUnsupported(t *testing.T) {
+	tests := []struct {
+		name      string
+		detection string
+	}{
+		{name: "aggregation", detection: "selection: {verb: list}\ncondition: selection | count() > 10"},
+		{name: "unknown modifier", detection: "selection: {verb|base64: list}\ncondition: selection"},
+		{name: "unknown search", detection: "selection: {verb: list}\ncondition: selection and filter"},
+		{name: "null value", detection: "selection: {verb: null}\ncondition: selection"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DatadogLogsQuery(newRule(t, tt.detection), nil); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	keywords := newRule(t, "keywords: [mimikatz]\ncondition: keywords")
+	if got, err := LuceneQuery(keywords, nil); err != nil || got != `"mimikatz"` {
+		t.Errorf("expected keyword query, got %s (err: %v)", got, err)
+	}
+	if _, err := LokiQuery(keywords, `{job="audit"}`, nil); err == nil {
+		t.Error("expected keywords to be unsupported by Loki")
+	}
+
+	regex := newRule(t, "selection: {CommandLine|re: 'nc -e .*'}\ncondition: selection")
+	if _, err := DatadogLogsQuery(regex, nil); err == nil {
+		t.Error("expected regular expressions to be unsupported by Datadog")
+	}
+	if got, err := LokiQuery(regex, `{job="audit"}`, nil); err != nil || got != `{job="audit"} | json | CommandLine=~".*(?:nc -e .*).*"` {
+		t.Errorf("expected regular expression query, got %s (err: %v)", got, err)
+	}
+}
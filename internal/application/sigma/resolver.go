@@ -0,0 +1,223 @@
+package sigma
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// LoadRule loads the rule referenced by an expectation of a scenario in the namespace.
+// The path of a rule is relative to the rules directory.
+func LoadRule(ctx context.Context, reader client.Reader, namespace string, rulesDir string, ref threatestergithubiov1alpha1.SigmaRuleRef) (*Rule, error) {
+	var rules []Rule
+	switch {
+	case ref.ConfigMapName != "":
+		configMap := &corev1.ConfigMap{}
+		if err := reader.Get(ctx, types.NamespacedName{Name: ref.ConfigMapName, Namespace: namespace}, configMap); err != nil {
+			return nil, fmt.Errorf("failed to get Sigma rules ConfigMap %s: %w", ref.ConfigMapName, err)
+		}
+
+		keys := make([]string, 0, len(configMap.Data))
+		for key := range configMap.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			parsed, err := Parse([]byte(configMap.Data[key]), fmt.Sprintf("ConfigMap %s/%s %s", namespace, ref.ConfigMapName, key))
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, parsed...)
+		}
+	case ref.Path != "":
+		path, err := RulePath(rulesDir, ref.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		parsed, err := parseFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		rules = parsed
+	default:
+		return nil, fmt.Errorf("Sigma rule %s has neither configMapName nor path", ref.ID)
+	}
+
+	for _, rule := range rules {
+		if rule.ID == ref.ID {
+			return &rule, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Sigma rule %s not found", ref.ID)
+}
+
+// RulePath returns the path of the rule file or directory under the rules directory.
+// The path is relative to the rules directory and must not escape it, so that scenarios cannot read other files of the manager.
+func RulePath(rulesDir string, path string) (string, error) {
+	if rulesDir == "" {
+		return "", fmt.Errorf("Sigma rule path %s cannot be read: the Sigma rules directory is not configured", path)
+	}
+
+	if filepath.IsAbs(path) {
+		return "", fmt.Errorf("Sigma rule path %s must be relative to the Sigma rules directory", path)
+	}
+
+	joined := filepath.Join(rulesDir, path)
+	rel, err := filepath.Rel(rulesDir, joined)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("Sigma rule path %s escapes the Sigma rules directory", path)
+	}
+
+	return joined, nil
+}
+
+// parseFiles parses the rule file at the path, or the .yml and .yaml files under the directory at the path.
+func parseFiles(root string) ([]Rule, error) {
+	rules := []Rule{}
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || (path != root && filepath.Ext(path) != ".yml" && filepath.Ext(path) != ".yaml") {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		parsed, err := Parse(data, path)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, parsed...)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Sigma rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// ApplyRule translates the rule into the query of the Datadog logs, Elastic or Loki expectation, unless it is set.
+func ApplyRule(expectation *threatestergithubiov1alpha1.Expectation, rule Rule) error {
+	var fields map[string]string
+	if expectation.Sigma != nil {
+		fields = expectation.Sigma.Fields
+	}
+
+	var err error
+	switch {
+	case expectation.Datadog != nil && expectation.Datadog.Logs != nil && expectation.Datadog.Logs.Query == "":
+		expectation.Datadog.Logs.Query, err = DatadogLogsQuery(rule, fields)
+	case expectation.Elastic != nil && expectation.Elastic.Query == "":
+		expectation.Elastic.Query, err = LuceneQuery(rule, fields)
+	case expectation.Loki != nil && expectation.Loki.Query == "":
+		expectation.Loki.Query, err = LokiQuery(rule, expectation.Loki.Selector, fields)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to translate Sigma rule %s: %w", rule.ID, err)
+	}
+
+	return nil
+}
+
+// ResolveRules returns a copy of the scenario whose expectations referencing Sigma rules have their queries
+// translated from the rules, along with the rules sorted by ID. The paths of the rules are relative to the rules directory.
+func ResolveRules(ctx context.Context, reader client.Reader, rulesDir string, scenario threatestergithubiov1alpha1.Scenario) (*threatestergithubiov1alpha1.Scenario, []Rule, error) {
+	resolved := scenario.DeepCopy()
+	rules := map[string]Rule{}
+
+	resolve := func(expectations []threatestergithubiov1alpha1.Expectation) error {
+		for i := range expectations {
+			ref := expectations[i].Sigma
+			if ref == nil {
+				continue
+			}
+
+			rule, ok := rules[ref.ID]
+			if !ok {
+				loaded, err := LoadRule(ctx, reader, scenario.Namespace, rulesDir, *ref)
+				if err != nil {
+					return err
+				}
+				rule = *loaded
+				rules[ref.ID] = rule
+			}
+
+			if err := ApplyRule(&expectations[i], rule); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := resolve(resolved.Spec.Expectations); err != nil {
+		return nil, nil, err
+	}
+	for i := range resolved.Spec.Steps {
+		if err := resolve(resolved.Spec.Steps[i].Expectations); err != nil {
+			return nil, nil, fmt.Errorf("step %s: %w", resolved.Spec.Steps[i].Name, err)
+		}
+	}
+
+	sorted := []Rule{}
+	for _, rule := range rules {
+		sorted = append(sorted, rule)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	return resolved, sorted, nil
+}
+
+// RuleStatuses returns the statuses of the rules after the expectations ran.
+// A rule is validated when all expectations referencing it passed.
+func RuleStatuses(rules []Rule, result threatestergithubiov1alpha1.ExpectationResult) []threatestergithubiov1alpha1.SigmaRuleStatus {
+	failed := map[string]struct{}{}
+	for _, expectation := range result.FailedExpectations {
+		if expectation.Expectation.Sigma != nil {
+			failed[expectation.Expectation.Sigma.ID] = struct{}{}
+		}
+	}
+
+	succeeded := map[string]struct{}{}
+	for _, expectation := range result.SucceededExpectations {
+		if expectation.Sigma != nil {
+			succeeded[expectation.Sigma.ID] = struct{}{}
+		}
+	}
+
+	statuses := []threatestergithubiov1alpha1.SigmaRuleStatus{}
+	for _, rule := range rules {
+		_, hasFailed := failed[rule.ID]
+		_, hasSucceeded := succeeded[rule.ID]
+
+		statuses = append(statuses, threatestergithubiov1alpha1.SigmaRuleStatus{
+			ID:        rule.ID,
+			Title:     rule.Title,
+			Revision:  rule.Revision,
+			Modified:  rule.LastModified(),
+			Source:    rule.Source,
+			Validated: hasSucceeded && !hasFailed,
+		})
+	}
+
+	return statuses
+}
@@ -0,0 +1,199 @@
+package sigma
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const rules = `title: List secrets
+id: 6f1c4a0e-0d3e-4f7e-9d1b-1a2b3c4d5e6f
+date: 2023/04/01
+modified: 2023/05/01
+logsource:
+  product: kubernetes
+  service: audit
+detection:
+  selection:
+    objectRef.resource: secrets
+    verb: list
+  condition: selection
+---
+title: Exec into pod
+id: 0c3e1f4b-7a2d-4c5e-8f9a-b1c2d3e4f5a6
+date: 2023/04/01
+detection:
+  selection:
+    objectRef.subresource: exec
+  condition: selection
+`
+
+func TestParse(t *testing.T) {
+	parsed, err := Parse([]byte(rules), "rules.yml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(parsed) != 2 {
+		t.Fatalf("expected 2 rules, got %d", len(parsed))
+	}
+
+	if parsed[0].LastModified() != "2023/05/01" || parsed[1].LastModified() != "2023/04/01" {
+		t.Errorf("unexpected modified dates %s and %s", parsed[0].LastModified(), parsed[1].LastModified())
+	}
+
+	if parsed[0].Revision == parsed[1].Revision || len(parsed[0].Revision) != 64 {
+		t.Errorf("expected distinct SHA-256 revisions, got %s and %s", parsed[0].Revision, parsed[1].Revision)
+	}
+}
+
+func TestLoadRule(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "sigma-rules", Namespace: "default"},
+		Data:       map[string]string{"kubernetes.yml": rules},
+	}
+	c := fake.NewClientBuilder().WithObjects(configMap).Build()
+
+	rulesDir := t.TempDir()
+	dir := filepath.Join(rulesDir, "kubernetes")
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "kubernetes.yml"), []byte(rules), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# rules"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		ref    threatestergithubiov1alpha1.SigmaRuleRef
+		source string
+	}{
+		{
+			name:   "from a ConfigMap",
+			ref:    threatestergithubiov1alpha1.SigmaRuleRef{ID: "0c3e1f4b-7a2d-4c5e-8f9a-b1c2d3e4f5a6", ConfigMapName: "sigma-rules"},
+			source: "ConfigMap default/sigma-rules kubernetes.yml",
+		},
+		{
+			name:   "from a directory",
+			ref:    threatestergithubiov1alpha1.SigmaRuleRef{ID: "0c3e1f4b-7a2d-4c5e-8f9a-b1c2d3e4f5a6", Path: "kubernetes"},
+			source: filepath.Join(dir, "kubernetes.yml"),
+		},
+		{
+			name:   "from a file",
+			ref:    threatestergithubiov1alpha1.SigmaRuleRef{ID: "0c3e1f4b-7a2d-4c5e-8f9a-b1c2d3e4f5a6", Path: "kubernetes/kubernetes.yml"},
+			source: filepath.Join(dir, "kubernetes.yml"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := LoadRule(context.Background(), c, "default", rulesDir, tt.ref)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if rule.Title != "Exec into pod" || rule.Source != tt.source {
+				t.Errorf("unexpected rule %s from %s", rule.Title, rule.Source)
+			}
+		})
+	}
+
+	if _, err := LoadRule(context.Background(), c, "default", rulesDir, threatestergithubiov1alpha1.SigmaRuleRef{ID: "unknown", ConfigMapName: "sigma-rules"}); err == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+
+	for _, path := range []string{"/etc/passwd", "..", "../other", "kubernetes/../../other"} {
+		if _, err := LoadRule(context.Background(), c, "default", dir, threatestergithubiov1alpha1.SigmaRuleRef{ID: "0c3e1f4b-7a2d-4c5e-8f9a-b1c2d3e4f5a6", Path: path}); err == nil {
+			t.Errorf("expected an error for path %s escaping the rules directory", path)
+		}
+	}
+
+	if _, err := LoadRule(context.Background(), c, "default", "", threatestergithubiov1alpha1.SigmaRuleRef{ID: "0c3e1f4b-7a2d-4c5e-8f9a-b1c2d3e4f5a6", Path: "kubernetes"}); err == nil {
+		t.Error("expected an error without a rules directory")
+	}
+}
+
+func TestResolveRules(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "sigma-rules", Namespace: "default"},
+		Data:       map[string]string{"kubernetes.yml": rules},
+	}
+	c := fake.NewClientBuilder().WithObjects(configMap).Build()
+
+	listSecrets := &threatestergithubiov1alpha1.SigmaRuleRef{ID: "6f1c4a0e-0d3e-4f7e-9d1b-1a2b3c4d5e6f", ConfigMapName: "sigma-rules"}
+	scenario := threatestergithubiov1alpha1.Scenario{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-scenario", Namespace: "default"},
+		Spec: threatestergithubiov1alpha1.ScenarioSpec{
+			Expectations: []threatestergithubiov1alpha1.Expectation{
+				{
+					Datadog: &threatestergithubiov1alpha1.DatadogExpectation{Logs: &threatestergithubiov1alpha1.DatadogLogs{}},
+					Sigma:   listSecrets,
+				},
+			},
+			Steps: []threatestergithubiov1alpha1.Step{
+				{
+					Name: "exec",
+					Expectations: []threatestergithubiov1alpha1.Expectation{
+						{
+							Loki:  &threatestergithubiov1alpha1.LokiExpectation{URL: "http://loki", Selector: `{job="audit"}`},
+							Sigma: &threatestergithubiov1alpha1.SigmaRuleRef{ID: "0c3e1f4b-7a2d-4c5e-8f9a-b1c2d3e4f5a6", ConfigMapName: "sigma-rules"},
+						},
+						{
+							Elastic: &threatestergithubiov1alpha1.ElasticExpectation{URL: "http://elasticsearch", Index: "audit-*", Query: "verb:create"},
+							Sigma:   &threatestergithubiov1alpha1.SigmaRuleRef{ID: "0c3e1f4b-7a2d-4c5e-8f9a-b1c2d3e4f5a6", ConfigMapName: "sigma-rules"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	resolved, resolvedRules, err := ResolveRules(context.Background(), c, "", scenario)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := resolved.Spec.Expectations[0].Datadog.Logs.Query; got != `(@objectRef.resource:"secrets" AND @verb:"list")` {
+		t.Errorf("unexpected Datadog query %s", got)
+	}
+	if got := resolved.Spec.Steps[0].Expectations[0].Loki.Query; got != `{job="audit"} | json | objectRef_subresource="exec"` {
+		t.Errorf("unexpected Loki query %s", got)
+	}
+	if got := resolved.Spec.Steps[0].Expectations[1].Elastic.Query; got != "verb:create" {
+		t.Errorf("expected the query set in the expectation to be kept, got %s", got)
+	}
+	if scenario.Spec.Expectations[0].Datadog.Logs.Query != "" {
+		t.Error("expected the original scenario not to be modified")
+	}
+
+	ids := []string{}
+	for _, rule := range resolvedRules {
+		ids = append(ids, rule.ID)
+	}
+	if expected := []string{"0c3e1f4b-7a2d-4c5e-8f9a-b1c2d3e4f5a6", "6f1c4a0e-0d3e-4f7e-9d1b-1a2b3c4d5e6f"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected rules %v, got %v", expected, ids)
+	}
+
+	result := threatestergithubiov1alpha1.ExpectationResult{
+		SucceededExpectations: []threatestergithubiov1alpha1.Expectation{resolved.Spec.Expectations[0], resolved.Spec.Steps[0].Expectations[0]},
+		FailedExpectations:    []threatestergithubiov1alpha1.FailedExpectation{{Step: "exec", Expectation: resolved.Spec.Steps[0].Expectations[1]}},
+	}
+
+	statuses := RuleStatuses(resolvedRules, result)
+	if statuses[0].Validated || !statuses[1].Validated {
+		t.Errorf("expected only the rule whose expectations all passed to be validated, got %+v", statuses)
+	}
+	if statuses[1].Title != "List secrets" || statuses[1].Modified != "2023/05/01" || statuses[1].Revision != resolvedRules[1].Revision {
+		t.Errorf("unexpected status %+v", statuses[1])
+	}
+}
@@ -0,0 +1,66 @@
+// Package sigma loads the Sigma rules referenced by the expectations and translates their detections into the
+// queries of the log backends.
+package sigma
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+
+	"sigs.k8s.io/yaml"
+)
+
+var documentSeparator = regexp.MustCompile(`(?m)^---[ \t]*$`)
+
+// Rule is a Sigma rule, see https://github.com/SigmaHQ/sigma-specification.
+type Rule struct {
+	ID        string                 `json:"id"`
+	Title     string                 `json:"title"`
+	Date      string                 `json:"date"`
+	Modified  string                 `json:"modified"`
+	LogSource map[string]string      `json:"logsource"`
+	Detection map[string]interface{} `json:"detection"`
+
+	// Revision is the SHA-256 digest of the rule document.
+	Revision string `json:"-"`
+	// Source is the ConfigMap or the file the rule was loaded from.
+	Source string `json:"-"`
+}
+
+// Parse parses the rules of a file, which may hold several YAML documents.
+// Documents without an ID, such as the global sections of rule collections, are ignored.
+func Parse(data []byte, source string) ([]Rule, error) {
+	rules := []Rule{}
+	for _, document := range documentSeparator.Split(string(data), -1) {
+		if len(bytes.TrimSpace([]byte(document))) == 0 {
+			continue
+		}
+
+		rule := Rule{}
+		if err := yaml.Unmarshal([]byte(document), &rule); err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+
+		if rule.ID == "" {
+			continue
+		}
+
+		digest := sha256.Sum256(bytes.TrimSpace([]byte(document)))
+		rule.Revision = hex.EncodeToString(digest[:])
+		rule.Source = source
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// LastModified returns the modified date of the rule, or its date when it was never modified.
+func (r Rule) LastModified() string {
+	if r.Modified != "" {
+		return r.Modified
+	}
+
+	return r.Date
+}
@@ -0,0 +1,88 @@
+package suppression
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/mrtc0/threatester/internal/service/datadog"
+	batchv1 "k8s.io/api/batch/v1"
+)
+
+const (
+	DatadogProvider = "datadog"
+
+	defaultDowntimeDuration = time.Hour
+	downtimeMessage         = "threatester test: notifications of the alerts generated by the scenario are muted"
+)
+
+type DatadogSuppression struct {
+	datadogClient datadog.DatadogClient
+}
+
+func NewDatadogSuppression() DatadogSuppression {
+	return DatadogSuppression{
+		datadogClient: datadog.NewDatadogClient(),
+	}
+}
+
+// Suppress creates a downtime scoped to the tags that the Datadog Agent attaches to the pods of the scenario job.
+func (s *DatadogSuppression) Suppress(ctx context.Context, suppression threatestergithubiov1alpha1.DatadogSuppression, scenarioJob batchv1.Job) (*threatestergithubiov1alpha1.SuppressionRef, error) {
+	duration := defaultDowntimeDuration
+	if suppression.Duration != "" {
+		d, err := time.ParseDuration(suppression.Duration)
+		if err != nil {
+			return nil, fmt.Errorf("invalid suppression duration %q: %w", suppression.Duration, err)
+		}
+		duration = d
+	}
+
+	monitorTags := suppression.MonitorTags
+	if len(monitorTags) == 0 {
+		monitorTags = []string{"*"}
+	}
+
+	now := time.Now()
+
+	body := datadogV1.NewDowntime()
+	body.SetScope(DowntimeScope(scenarioJob))
+	body.SetMonitorTags(monitorTags)
+	body.SetStart(now.Unix())
+	body.SetEnd(now.Add(duration).Unix())
+	body.SetMessage(downtimeMessage)
+	body.SetMuteFirstRecoveryNotification(true)
+
+	downtime, err := s.datadogClient.CreateDowntime(ctx, *body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create downtime: %w", err)
+	}
+
+	return &threatestergithubiov1alpha1.SuppressionRef{
+		Provider: DatadogProvider,
+		ID:       strconv.FormatInt(downtime.GetId(), 10),
+	}, nil
+}
+
+func (s *DatadogSuppression) Unsuppress(ctx context.Context, suppression threatestergithubiov1alpha1.SuppressionRef) error {
+	downtimeID, err := strconv.ParseInt(suppression.ID, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid downtime id %q: %w", suppression.ID, err)
+	}
+
+	if err := s.datadogClient.CancelDowntime(ctx, downtimeID); err != nil {
+		return fmt.Errorf("failed to cancel downtime %d: %w", downtimeID, err)
+	}
+
+	return nil
+}
+
+// DowntimeScope returns the tags that identify the pods of the scenario job in Datadog.
+func DowntimeScope(scenarioJob batchv1.Job) []string {
+	return []string{
+		fmt.Sprintf("kube_namespace:%s", scenarioJob.Namespace),
+		fmt.Sprintf("kube_job:%s", scenarioJob.Name),
+	}
+}
@@ -0,0 +1,64 @@
+package suppression
+
+//go:generate moq -out suppression_service_mock.go . SuppressionService
+
+import (
+	"context"
+	"fmt"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	batchv1 "k8s.io/api/batch/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+type SuppressionService interface {
+	Suppress(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.SuppressionRef, error)
+	Unsuppress(ctx context.Context, suppressions []threatestergithubiov1alpha1.SuppressionRef) error
+}
+
+type suppressionService struct {
+	datadogSuppression DatadogSuppression
+}
+
+func NewSuppressionService() SuppressionService {
+	return &suppressionService{
+		datadogSuppression: NewDatadogSuppression(),
+	}
+}
+
+// Suppress mutes the notifications of the alerts generated by the scenario job.
+func (s *suppressionService) Suppress(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.SuppressionRef, error) {
+	suppressions := []threatestergithubiov1alpha1.SuppressionRef{}
+	if scenario.Spec.Suppression == nil {
+		return suppressions, nil
+	}
+
+	if scenario.Spec.Suppression.Datadog != nil {
+		ref, err := s.datadogSuppression.Suppress(ctx, *scenario.Spec.Suppression.Datadog, scenarioJob)
+		if err != nil {
+			return suppressions, err
+		}
+
+		suppressions = append(suppressions, *ref)
+	}
+
+	return suppressions, nil
+}
+
+// Unsuppress removes the given suppressions. Suppressions that no longer exist are ignored.
+func (s *suppressionService) Unsuppress(ctx context.Context, suppressions []threatestergithubiov1alpha1.SuppressionRef) error {
+	var errs []error
+
+	for _, suppression := range suppressions {
+		switch suppression.Provider {
+		case DatadogProvider:
+			if err := s.datadogSuppression.Unsuppress(ctx, suppression); err != nil {
+				errs = append(errs, err)
+			}
+		default:
+			errs = append(errs, fmt.Errorf("unknown suppression provider %q", suppression.Provider))
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
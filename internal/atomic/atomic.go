@@ -0,0 +1,264 @@
+// Package atomic converts the tests of Atomic Red Team into scenarios and scenario templates.
+package atomic
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/yaml"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+)
+
+const (
+	// TechniqueAnnotation holds the MITRE ATT&CK technique ID simulated by the scenario, e.g. "T1552.001".
+	TechniqueAnnotation = "threatester.github.io/mitre-technique"
+	// AtomicTestAnnotation holds the GUID of the Atomic Red Team test the scenario was imported from.
+	AtomicTestAnnotation = "threatester.github.io/atomic-test"
+
+	// DefaultImage runs the imported tests. Atomic Red Team tests expect a shell and coreutils.
+	DefaultImage = "ubuntu:22.04"
+)
+
+// DefaultPlatforms are the platforms of the tests that can run in a Kubernetes pod.
+var DefaultPlatforms = []string{"linux", "containers"}
+
+var (
+	inputArgumentPattern = regexp.MustCompile(`#\{([^}]+)\}`)
+	identifierPattern    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// Technique is an Atomic Red Team technique file, e.g. atomics/T1552.001/T1552.001.yaml.
+type Technique struct {
+	AttackTechnique string `json:"attack_technique"`
+	DisplayName     string `json:"display_name"`
+	AtomicTests     []Test `json:"atomic_tests"`
+}
+
+type Test struct {
+	Name               string                   `json:"name"`
+	GUID               string                   `json:"auto_generated_guid"`
+	Description        string                   `json:"description"`
+	SupportedPlatforms []string                 `json:"supported_platforms"`
+	InputArguments     map[string]InputArgument `json:"input_arguments"`
+	Executor           Executor                 `json:"executor"`
+}
+
+type InputArgument struct {
+	Description string      `json:"description"`
+	Type        string      `json:"type"`
+	Default     interface{} `json:"default"`
+}
+
+type Executor struct {
+	Name              string `json:"name"`
+	Command           string `json:"command"`
+	CleanupCommand    string `json:"cleanup_command"`
+	ElevationRequired bool   `json:"elevation_required"`
+}
+
+type Options struct {
+	// Image runs the tests. Defaults to DefaultImage.
+	Image string
+	// Namespace of the generated manifests. Cluster-scoped manifests ignore it.
+	Namespace string
+	// Platforms are the platforms of the tests to import. Defaults to DefaultPlatforms.
+	Platforms []string
+}
+
+// Parse parses an Atomic Red Team technique file.
+func Parse(data []byte) (*Technique, error) {
+	technique := &Technique{}
+	if err := yaml.Unmarshal(data, technique); err != nil {
+		return nil, err
+	}
+
+	if technique.AttackTechnique == "" {
+		return nil, fmt.Errorf("attack_technique is missing")
+	}
+
+	return technique, nil
+}
+
+// Supported returns why the test cannot be imported, or nil when it runs on one of the platforms with a shell executor.
+// Tests run manually or with PowerShell cannot be imported.
+func (t Test) Supported(platforms []string) error {
+	if len(platforms) == 0 {
+		platforms = DefaultPlatforms
+	}
+
+	if t.Executor.Name != "sh" && t.Executor.Name != "bash" {
+		return fmt.Errorf("executor %s is not supported", t.Executor.Name)
+	}
+
+	for _, supported := range t.SupportedPlatforms {
+		for _, platform := range platforms {
+			if supported == platform {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("platforms %s are not supported", strings.Join(t.SupportedPlatforms, ", "))
+}
+
+// Name returns the name of the manifest of the test at the index, e.g. "t1552-001-1" for the first test of T1552.001.
+// Tests are numbered from 1 as in Atomic Red Team.
+func Name(technique Technique, index int) string {
+	return fmt.Sprintf("%s-%d", strings.ToLower(strings.ReplaceAll(technique.AttackTechnique, ".", "-")), index+1)
+}
+
+// ScenarioTemplate returns the ScenarioTemplate, or the ClusterScenarioTemplate when cluster is set, of the test at the index.
+// The input arguments of the test become the parameters of the template.
+func ScenarioTemplate(technique Technique, index int, options Options, cluster bool) (metav1.Object, error) {
+	spec, err := templateSpec(technique, index, options)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := objectMeta(technique, index, options)
+	if cluster {
+		meta.Namespace = ""
+		return &threatestergithubiov1alpha1.ClusterScenarioTemplate{
+			TypeMeta:   metav1.TypeMeta{APIVersion: threatestergithubiov1alpha1.GroupVersion.String(), Kind: "ClusterScenarioTemplate"},
+			ObjectMeta: meta,
+			Spec:       *spec,
+		}, nil
+	}
+
+	return &threatestergithubiov1alpha1.ScenarioTemplate{
+		TypeMeta:   metav1.TypeMeta{APIVersion: threatestergithubiov1alpha1.GroupVersion.String(), Kind: "ScenarioTemplate"},
+		ObjectMeta: meta,
+		Spec:       *spec,
+	}, nil
+}
+
+// Scenario returns the scenario of the test at the index. The input arguments of the test become the parameters of the scenario.
+// The scenario has no expectation, since they depend on the detections of each environment,
+// but it declares the technique of the test so that it counts towards the coverage once expectations are added.
+func Scenario(technique Technique, index int, options Options) (*threatestergithubiov1alpha1.Scenario, error) {
+	spec, err := templateSpec(technique, index, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &threatestergithubiov1alpha1.Scenario{
+		TypeMeta:   metav1.TypeMeta{APIVersion: threatestergithubiov1alpha1.GroupVersion.String(), Kind: "Scenario"},
+		ObjectMeta: objectMeta(technique, index, options),
+		Spec: threatestergithubiov1alpha1.ScenarioSpec{
+			MitreAttack: &threatestergithubiov1alpha1.MitreAttack{Techniques: []string{technique.AttackTechnique}},
+			Parameters:  spec.Parameters,
+			Templates: []threatestergithubiov1alpha1.Template{
+				{
+					Name:        spec.Container.Name,
+					Container:   &spec.Container,
+					PodTemplate: spec.PodTemplate,
+				},
+			},
+		},
+	}, nil
+}
+
+func objectMeta(technique Technique, index int, options Options) metav1.ObjectMeta {
+	test := technique.AtomicTests[index]
+
+	annotations := map[string]string{TechniqueAnnotation: technique.AttackTechnique}
+	if test.GUID != "" {
+		annotations[AtomicTestAnnotation] = test.GUID
+	}
+
+	return metav1.ObjectMeta{
+		Name:        Name(technique, index),
+		Namespace:   options.Namespace,
+		Annotations: annotations,
+	}
+}
+
+func templateSpec(technique Technique, index int, options Options) (*threatestergithubiov1alpha1.ScenarioTemplateSpec, error) {
+	test := technique.AtomicTests[index]
+	if err := test.Supported(options.Platforms); err != nil {
+		return nil, fmt.Errorf("test %s of %s cannot be imported: %w", test.Name, technique.AttackTechnique, err)
+	}
+
+	image := options.Image
+	if image == "" {
+		image = DefaultImage
+	}
+
+	names := make([]string, 0, len(test.InputArguments))
+	for name := range test.InputArguments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parameters := []threatestergithubiov1alpha1.Parameter{}
+	for _, name := range names {
+		argument := test.InputArguments[name]
+		parameter := threatestergithubiov1alpha1.Parameter{
+			Name:        name,
+			Type:        parameterType(argument.Type),
+			Description: strings.TrimSpace(argument.Description),
+		}
+		if argument.Default != nil {
+			parameter.Default = pointer.String(formatDefault(argument.Default))
+		}
+
+		parameters = append(parameters, parameter)
+	}
+
+	spec := &threatestergithubiov1alpha1.ScenarioTemplateSpec{
+		Description: test.Name,
+		Parameters:  parameters,
+		Container: corev1.Container{
+			Name:    Name(technique, index),
+			Image:   image,
+			Command: []string{test.Executor.Name, "-c", ConvertCommand(test.Executor.Command)},
+		},
+	}
+
+	if test.Executor.ElevationRequired {
+		spec.Container.SecurityContext = &corev1.SecurityContext{
+			Privileged: pointer.Bool(true),
+			RunAsUser:  pointer.Int64(0),
+		}
+	}
+
+	return spec, nil
+}
+
+// ConvertCommand replaces the input arguments referenced as #{name} in the command by the parameters of the same name.
+func ConvertCommand(command string) string {
+	command = strings.TrimSpace(command)
+
+	return inputArgumentPattern.ReplaceAllStringFunc(command, func(match string) string {
+		name := inputArgumentPattern.FindStringSubmatch(match)[1]
+		if identifierPattern.MatchString(name) {
+			return fmt.Sprintf("{{ .Params.%s }}", name)
+		}
+
+		return fmt.Sprintf("{{ index .Params %q }}", name)
+	})
+}
+
+// formatDefault formats the default of an input argument, which YAML may have decoded as a number or a boolean.
+func formatDefault(value interface{}) string {
+	if number, ok := value.(float64); ok {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+
+	return fmt.Sprint(value)
+}
+
+func parameterType(argumentType string) threatestergithubiov1alpha1.ParameterType {
+	if strings.EqualFold(argumentType, "integer") {
+		return threatestergithubiov1alpha1.ParameterTypeInteger
+	}
+
+	return threatestergithubiov1alpha1.ParameterTypeString
+}
@@ -0,0 +1,138 @@
+package atomic
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+)
+
+const technique = `
+attack_technique: T1552.001
+display_name: 'Unsecured Credentials: Credentials In Files'
+atomic_tests:
+- name: Find AWS credentials
+  auto_generated_guid: 2b93758e-a8d7-4e3b-bc7b-d3aa8d7ecb17
+  supported_platforms:
+  - macos
+  - linux
+  input_arguments:
+    file_path:
+      description: Path to search
+      type: path
+      default: /
+    depth:
+      description: Depth of the search
+      type: integer
+      default: 3
+  executor:
+    command: |
+      find #{file_path} -maxdepth #{depth} -name "credentials" -type f -path "*/.aws/*" 2>/dev/null
+    name: sh
+    elevation_required: true
+- name: Access unattend.xml
+  auto_generated_guid: 367d4004-5fc0-446d-823f-960c74ae52c3
+  supported_platforms:
+  - windows
+  executor:
+    command: type C:\Windows\Panther\unattend.xml
+    name: command_prompt
+`
+
+func TestScenarioTemplate(t *testing.T) {
+	parsed, err := Parse([]byte(technique))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	manifest, err := ScenarioTemplate(*parsed, 0, Options{Namespace: "security"}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	template := manifest.(*threatestergithubiov1alpha1.ScenarioTemplate)
+	if template.Name != "t1552-001-1" || template.Namespace != "security" {
+		t.Errorf("unexpected name %s/%s", template.Namespace, template.Name)
+	}
+
+	if template.Annotations[TechniqueAnnotation] != "T1552.001" || template.Annotations[AtomicTestAnnotation] != "2b93758e-a8d7-4e3b-bc7b-d3aa8d7ecb17" {
+		t.Errorf("unexpected annotations %v", template.Annotations)
+	}
+
+	expectedCommand := []string{"sh", "-c", `find {{ .Params.file_path }} -maxdepth {{ .Params.depth }} -name "credentials" -type f -path "*/.aws/*" 2>/dev/null`}
+	if !reflect.DeepEqual(template.Spec.Container.Command, expectedCommand) {
+		t.Errorf("expected command %v, got %v", expectedCommand, template.Spec.Container.Command)
+	}
+
+	if template.Spec.Container.Image != DefaultImage {
+		t.Errorf("expected the default image, got %s", template.Spec.Container.Image)
+	}
+
+	if template.Spec.Container.SecurityContext == nil || !*template.Spec.Container.SecurityContext.Privileged {
+		t.Error("expected a privileged container for a test requiring elevation")
+	}
+
+	parameters := template.Spec.Parameters
+	if len(parameters) != 2 {
+		t.Fatalf("expected 2 parameters, got %d", len(parameters))
+	}
+
+	if parameters[0].Name != "depth" || parameters[0].Type != threatestergithubiov1alpha1.ParameterTypeInteger || *parameters[0].Default != "3" {
+		t.Errorf("unexpected parameter %+v", parameters[0])
+	}
+
+	if parameters[1].Name != "file_path" || parameters[1].Type != threatestergithubiov1alpha1.ParameterTypeString || *parameters[1].Default != "/" {
+		t.Errorf("unexpected parameter %+v", parameters[1])
+	}
+
+	if _, err := threatestergithubiov1alpha1.ParameterValues(parameters, nil, field.NewPath("spec", "parameters"), field.NewPath("spec", "arguments")); len(err) > 0 {
+		t.Errorf("expected the defaults to be valid, got %v", err)
+	}
+}
+
+func TestScenario(t *testing.T) {
+	parsed, err := Parse([]byte(technique))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	scenario, err := Scenario(*parsed, 0, Options{Image: "alpine"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(scenario.Spec.Parameters) != 2 || len(scenario.Spec.Templates) != 1 {
+		t.Fatalf("expected 2 parameters and 1 template, got %d and %d", len(scenario.Spec.Parameters), len(scenario.Spec.Templates))
+	}
+
+	if scenario.Spec.Templates[0].Container.Image != "alpine" {
+		t.Errorf("expected image alpine, got %s", scenario.Spec.Templates[0].Container.Image)
+	}
+
+	if techniques := scenario.Spec.MitreAttack.Techniques; len(techniques) != 1 || techniques[0] != parsed.AttackTechnique {
+		t.Errorf("expected technique %s, got %v", parsed.AttackTechnique, techniques)
+	}
+
+	if _, err := Scenario(*parsed, 1, Options{}); err == nil {
+		t.Error("expected an error for a Windows test")
+	}
+}
+
+func TestConvertCommand(t *testing.T) {
+	testCases := []struct {
+		command  string
+		expected string
+	}{
+		{command: "cat #{file}", expected: "cat {{ .Params.file }}"},
+		{command: "curl #{c2 host}", expected: `curl {{ index .Params "c2 host" }}`},
+		{command: "echo $HOME\n", expected: "echo $HOME"},
+	}
+
+	for _, tc := range testCases {
+		if got := ConvertCommand(tc.command); got != tc.expected {
+			t.Errorf("expected %q, got %q", tc.expected, got)
+		}
+	}
+}
@@ -18,25 +18,37 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/mrtc0/threatester/internal/application/cleanup"
 	"github.com/mrtc0/threatester/internal/application/expectation"
+	"github.com/mrtc0/threatester/internal/application/plan"
 	scenarioApplication "github.com/mrtc0/threatester/internal/application/scenario"
+	"github.com/mrtc0/threatester/internal/application/sigma"
+	"github.com/mrtc0/threatester/internal/application/suppression"
 )
 
 const (
@@ -47,6 +59,25 @@ const (
 	typeFailedScenario      = "Failed"
 	typeProgressingScenario = "Progressing"
 	typeDegradedScenario    = "Degraded"
+	typeCancelledScenario   = "Cancelled"
+	typeTimedOutScenario    = "TimedOut"
+	typePlannedScenario     = "Planned"
+
+	// cancelPollInterval is how often a running scenario checks for the cancel annotation.
+	cancelPollInterval = 5 * time.Second
+
+	// templateRefIndexField indexes the scenarios by the templates they reference.
+	templateRefIndexField = ".spec.templates.templateRef"
+
+	// maxFinalizerAttempts bounds the attempts of the finalizer to clean up a scenario, so that a detection backend
+	// that keeps failing, e.g. with a revoked key, does not prevent the scenario from being deleted.
+	maxFinalizerAttempts = 5
+	// finalizerRetryInterval is how long the finalizer waits before it tries to clean up a scenario again.
+	finalizerRetryInterval = 30 * time.Second
+
+	// maxStatusLogLength bounds the logs of each container kept in the scenario status.
+	// Longer logs are stored in the logs ConfigMap of the scenario.
+	maxStatusLogLength = 2048
 )
 
 // ScenarioReconciler reconciles a Scenario object
@@ -55,12 +86,26 @@ type ScenarioReconciler struct {
 	Scheme              *runtime.Scheme
 	ExpectationService  expectation.ExpectationService
 	ScenarioJobExecutor scenarioApplication.ScenarioJobExecutor
+	WorkloadExecutor    scenarioApplication.WorkloadExecutor
+	CleanupService      cleanup.CleanupService
+	SuppressionService  suppression.SuppressionService
+	// SigmaRulesDir is the directory the paths of the Sigma rules referenced by the expectations are relative to.
+	SigmaRulesDir string
 }
 
 //+kubebuilder:rbac:groups=threatester.github.io,resources=scenarios,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=threatester.github.io,resources=scenarios/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=threatester.github.io,resources=scenarios/finalizers,verbs=update
+//+kubebuilder:rbac:groups=threatester.github.io,resources=scenariotemplates,verbs=get;list;watch
+//+kubebuilder:rbac:groups=threatester.github.io,resources=clusterscenariotemplates,verbs=get;list;watch
 //+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list
+//+kubebuilder:rbac:groups="",resources=pods/exec,verbs=create
+//+kubebuilder:rbac:groups="",resources=pods/ephemeralcontainers,verbs=update;patch
+//+kubebuilder:rbac:groups="",resources=pods/log,verbs=get
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;delete
+//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -96,6 +141,21 @@ func (r *ScenarioReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		}
 	}
 
+	// a rerun request starts a new run whatever the status of the previous one, which is reset to Progressing
+	if scenarioApplication.IsRerunRequested(*scenario) && scenario.DeletionTimestamp.IsZero() {
+		log.Info(fmt.Sprintf("rerun of scenario %s/%s is requested", scenario.Namespace, scenario.Name))
+		if err := r.updateScenarioRerunRequest(ctx, req, scenario.Annotations[scenarioApplication.RerunAnnotation]); err != nil {
+			log.Error(err, "failed to update scenario rerun request")
+			return ctrl.Result{}, err
+		}
+
+		// the jobs kept by the cleanup policy would prevent the scenario from running again
+		if err := r.deleteScenarioJobs(ctx, scenario); err != nil {
+			log.Error(err, "failed to delete scenario jobs of the previous run")
+			return ctrl.Result{}, err
+		}
+	}
+
 	err = r.Get(ctx, req.NamespacedName, scenario)
 	if err != nil {
 		log.Error(err, "failed to fe-fetch scenario")
@@ -134,7 +194,35 @@ func (r *ScenarioReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 				return ctrl.Result{}, err
 			}
 
-			// TODO: remove all scneario jobs
+			finalizerErrs := []error{}
+			if err := r.cleanupScenario(ctx, req); err != nil {
+				log.Error(err, "failed to clean up scenario")
+				finalizerErrs = append(finalizerErrs, err)
+			}
+
+			if err := r.releaseSuppressions(ctx, req); err != nil {
+				log.Error(err, "failed to remove notification suppressions")
+				finalizerErrs = append(finalizerErrs, err)
+			}
+
+			if len(finalizerErrs) > 0 {
+				attempts, err := r.recordFinalizerFailure(ctx, req, utilerrors.NewAggregate(finalizerErrs))
+				if err != nil {
+					log.Error(err, "failed to update scenario status")
+					return ctrl.Result{}, err
+				}
+
+				if attempts < maxFinalizerAttempts {
+					return ctrl.Result{RequeueAfter: finalizerRetryInterval}, nil
+				}
+
+				log.Info(fmt.Sprintf("give up cleaning up scenario %s/%s after %d attempts", scenario.Namespace, scenario.Name, attempts))
+			}
+
+			if err := r.deleteScenarioResources(ctx, req); err != nil {
+				log.Error(err, "failed to delete scenario resources")
+				return ctrl.Result{}, err
+			}
 
 			err = r.updateScenarioStatus(ctx, req, metav1.Condition{Type: typeAvailableScenario, Status: metav1.ConditionTrue, Reason: "Finalizing", Message: fmt.Sprintf("Successfully finalizer operation %s", scenario.Name)})
 			if err != nil {
@@ -163,31 +251,208 @@ func (r *ScenarioReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 		return ctrl.Result{}, nil
 	}
 
-	if scenario.Status.Status == typeSucceededScenario {
-		log.Info(fmt.Sprintf("scenario %s/%s is already succeeded. skip.", scenario.Namespace, scenario.Name))
-		return ctrl.Result{}, nil
+	parameterizedScenario, err := scenarioApplication.ApplyParameters(*scenario)
+	if err != nil {
+		log.Error(err, "failed to substitute scenario parameters")
+		if err := r.updateScenarioStatus(ctx, req, metav1.Condition{Type: typeFailedScenario, Status: metav1.ConditionFalse, Reason: "Failed", Message: err.Error()}); err != nil {
+			log.Error(err, "failed update scenario status")
+		}
+		return ctrl.Result{}, err
+	}
+
+	resolvedScenario, templateRevisions, err := scenarioApplication.ResolveTemplates(ctx, r.Client, *parameterizedScenario)
+	if err != nil {
+		log.Error(err, "failed to resolve scenario templates")
+		if err := r.updateScenarioStatus(ctx, req, metav1.Condition{Type: typeFailedScenario, Status: metav1.ConditionFalse, Reason: "Failed", Message: err.Error()}); err != nil {
+			log.Error(err, "failed update scenario status")
+		}
+		return ctrl.Result{}, err
 	}
 
-	scenarioJob, err := scenarioApplication.NewScenarioJobBuilder().WithNamespace(req.Namespace).WithScenarioJobs(scenario.Spec.Templates).Build()
+	if scenario.Spec.DryRun {
+		// a dry run is rendered again when the scenario or its templates are updated, whatever the status of the last run
+		if isPlanned(*scenario) && !scenarioApplication.TemplateRevisionsChanged(scenario.Status.TemplateRevisions, templateRevisions) {
+			log.Info(fmt.Sprintf("scenario %s/%s is already planned. skip.", scenario.Namespace, scenario.Name))
+			return ctrl.Result{}, nil
+		}
+	} else {
+		switch scenario.Status.Status {
+		case typeSucceededScenario, typeFailedScenario, typeDegradedScenario, typeCancelledScenario, typeTimedOutScenario:
+			if !scenarioApplication.TemplateRevisionsChanged(scenario.Status.TemplateRevisions, templateRevisions) {
+				log.Info(fmt.Sprintf("scenario %s/%s is already %s. skip.", scenario.Namespace, scenario.Name, strings.ToLower(scenario.Status.Status)))
+				return ctrl.Result{}, nil
+			}
+
+			log.Info(fmt.Sprintf("templates of scenario %s/%s are updated. run again.", scenario.Namespace, scenario.Name))
+		}
+	}
+
+	if scenarioApplication.IsCancelRequested(*scenario) {
+		log.Info(fmt.Sprintf("scenario %s/%s is cancelled. skip.", scenario.Namespace, scenario.Name))
+		err := r.updateScenarioStatus(ctx, req, metav1.Condition{Type: typeCancelledScenario, Status: metav1.ConditionTrue, Reason: "Cancelled", Message: "scenario was cancelled before it started"})
+		return ctrl.Result{}, err
+	}
+
+	requestedScenario := *scenario
+	scenario = resolvedScenario
+
+	sigmaScenario, sigmaRules, err := sigma.ResolveRules(ctx, r.Client, r.SigmaRulesDir, *scenario)
+	if err != nil {
+		log.Error(err, "failed to resolve Sigma rules")
+		if err := r.updateScenarioStatus(ctx, req, metav1.Condition{Type: typeFailedScenario, Status: metav1.ConditionFalse, Reason: "Failed", Message: err.Error()}); err != nil {
+			log.Error(err, "failed update scenario status")
+		}
+		return ctrl.Result{}, err
+	}
+	scenario = sigmaScenario
+
+	scenarioJobs, err := scenarioApplication.BuildScenarioJobs(*scenario)
 	if err != nil {
 		log.Error(err, "failed to build scenario job")
 		return ctrl.Result{}, err
 	}
 
-	found := &batchv1.Job{}
-	err = r.Get(ctx, types.NamespacedName{Name: scenarioJob.Name, Namespace: scenarioJob.Namespace}, found)
+	if scenario.Spec.Target != nil {
+		nodes, err := r.selectTargetNodes(ctx, *scenario.Spec.Target)
+		if err != nil {
+			log.Error(err, "failed to select target nodes")
+			if err := r.updateScenarioStatus(ctx, req, metav1.Condition{Type: typeFailedScenario, Status: metav1.ConditionFalse, Reason: "Failed", Message: err.Error()}); err != nil {
+				log.Error(err, "failed update scenario status")
+			}
+			return ctrl.Result{}, err
+		}
+
+		scenarioJobs = scenarioApplication.PinScenarioJobs(scenarioJobs, nodes)
+	}
+
+	if scenario.Spec.DryRun {
+		log.Info(fmt.Sprintf("scenario %s/%s is a dry run. render the plan.", scenario.Namespace, scenario.Name))
+		if err := r.planScenario(ctx, req, requestedScenario, scenario, scenarioJobs, templateRevisions); err != nil {
+			log.Error(err, "failed to render scenario plan")
+			return ctrl.Result{}, err
+		}
 
-	if err == nil {
-		log.Info("scenario job already exists. skip.")
 		return ctrl.Result{}, nil
 	}
 
-	err = r.ScenarioJobExecutor.Execute(ctx, *scenarioJob)
-	defer r.ScenarioJobExecutor.DeleteScenarioJob(ctx, *scenarioJob)
+	for _, scenarioJob := range scenarioJobs {
+		found := &batchv1.Job{}
+		err = r.Get(ctx, types.NamespacedName{Name: scenarioJob.Name, Namespace: scenarioJob.Namespace}, found)
+
+		if err == nil && !found.DeletionTimestamp.IsZero() {
+			log.Info("scenario job of the previous run is being deleted. wait.")
+			return ctrl.Result{RequeueAfter: cancelPollInterval}, nil
+		}
+
+		if err == nil && found.Labels[scenarioApplication.ScenarioLabel] != scenario.Name {
+			err := fmt.Errorf("job %s/%s already exists and does not belong to scenario %s", found.Namespace, found.Name, scenario.Name)
+			log.Error(err, "scenario job conflicts with an existing job")
+			if err := r.updateScenarioStatus(ctx, req, metav1.Condition{Type: typeFailedScenario, Status: metav1.ConditionFalse, Reason: "Conflict", Message: err.Error()}); err != nil {
+				log.Error(err, "failed update scenario status")
+			}
+			return ctrl.Result{}, err
+		}
+
+		if err == nil {
+			log.Info("scenario job already exists. skip.")
+			return ctrl.Result{}, nil
+		}
+	}
+
+	for i := range scenarioJobs {
+		if err := controllerutil.SetControllerReference(scenario, &scenarioJobs[i], r.Scheme); err != nil {
+			log.Error(err, "failed to set owner reference of scenario job")
+			return ctrl.Result{}, err
+		}
+
+		if err := r.trackScenarioResource(ctx, req, &scenarioJobs[i]); err != nil {
+			log.Error(err, "failed to track scenario job")
+			return ctrl.Result{}, err
+		}
+	}
+
+	if scenarioApplication.HasStratusTemplates(scenario.Spec.Templates) {
+		if err := r.createStratusState(ctx, req, scenario); err != nil {
+			log.Error(err, "failed to create Stratus Red Team state")
+			return ctrl.Result{}, err
+		}
+	}
+
+	runCtx, cancelRun, err := r.runContext(ctx, req, scenario)
+	if err != nil {
+		log.Error(err, "failed to start scenario run")
+		return ctrl.Result{}, err
+	}
+	defer cancelRun()
+
+	startTime := metav1.Now()
+	if err := r.updateScenarioStartTime(ctx, req, startTime, templateRevisions); err != nil {
+		log.Error(err, "failed to update scenario start time")
+		return ctrl.Result{}, err
+	}
+
+	for _, scenarioJob := range scenarioJobs {
+		suppressions, err := r.SuppressionService.Suppress(ctx, *scenario, scenarioJob)
+		if len(suppressions) > 0 {
+			if err := r.addScenarioSuppressions(ctx, req, suppressions); err != nil {
+				log.Error(err, "failed to update scenario suppressions")
+			}
+		}
+		if err != nil {
+			log.Error(err, "failed to suppress notifications")
+			if err := r.releaseSuppressions(ctx, req); err != nil {
+				log.Error(err, "failed to remove notification suppressions")
+			}
+			return ctrl.Result{}, err
+		}
+	}
+
+	scenarioSucceeded := false
+	defer func() {
+		if !scenarioApplication.ShouldDeleteScenarioJobs(scenario.Spec.CleanupPolicy, scenarioSucceeded) {
+			log.Info(fmt.Sprintf("keep scenario jobs following the %s cleanup policy", scenario.Spec.CleanupPolicy))
+			return
+		}
+
+		for _, scenarioJob := range scenarioJobs {
+			_ = r.ScenarioJobExecutor.DeleteScenarioJob(ctx, scenarioJob)
+		}
+	}()
+
+	switch {
+	case len(scenario.Spec.Steps) > 0:
+		err = r.executeScenarioSteps(runCtx, req, scenario, scenarioJobs)
+	case scenario.Spec.Target != nil:
+		err = r.executeScenarioNodes(runCtx, req, scenarioJobs)
+	default:
+		err = r.executeScenarioTemplates(runCtx, req, scenario, scenarioJobs)
+	}
+
+	containers := r.collectScenarioResults(ctx, req, scenario, scenarioJobs)
+
+	if condition, interrupted := interruptedCondition(ctx, runCtx, err); interrupted {
+		return r.interruptScenario(ctx, req, scenario, condition)
+	}
 
 	if err != nil {
 		log.Error(err, "failed to execute scenario job")
-		err := r.updateScenarioStatus(ctx, req, metav1.Condition{Type: typeFailedScenario, Status: metav1.ConditionFalse, Reason: "Failed", Message: err.Error()})
+		message := err.Error()
+		if summary := scenarioApplication.SummarizeFailedContainers(containers); summary != "" {
+			message = fmt.Sprintf("%s: %s", message, summary)
+		}
+
+		condition := metav1.Condition{Type: typeFailedScenario, Status: metav1.ConditionFalse, Reason: "Failed", Message: message}
+		// a pod that cannot make progress needs a change to the scenario or the cluster, rather than a rerun
+		if stuck := scenarioApplication.FindStuckPodError(err); stuck != nil {
+			condition = metav1.Condition{Type: typeDegradedScenario, Status: metav1.ConditionTrue, Reason: stuck.Reason, Message: message}
+		}
+
+		// the attack may have failed after changing the cluster, e.g. after the warmup of a Stratus Red Team technique
+		if cleanupErr := r.cleanupScenarioRun(ctx, req, scenario); cleanupErr != nil {
+			log.Error(cleanupErr, "failed to clean up scenario")
+		}
+
+		err := r.updateScenarioStatus(ctx, req, condition)
 		if err != nil {
 			log.Error(err, "failed update scenario status")
 		}
@@ -197,12 +462,30 @@ func (r *ScenarioReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 
 	log.Info("Perform sceario expectation")
 
-	// TODO: sleep timeout seconds
+	result, err := r.runExpectations(runCtx, req, scenario, startTime)
+
+	if condition, interrupted := interruptedCondition(ctx, runCtx, err); interrupted {
+		return r.interruptScenario(ctx, req, scenario, condition)
+	}
+
+	if cleanupErr := r.cleanupScenarioRun(ctx, req, scenario); cleanupErr != nil {
+		log.Error(cleanupErr, "failed to clean up scenario")
+	}
+
+	if _, updateErr := r.updateScenarioExpectationResultStatus(ctx, req, result); updateErr != nil {
+		log.Error(updateErr, "failed update scenario expectation result")
+		if err == nil {
+			return ctrl.Result{}, updateErr
+		}
+	}
 
-	r.ExpectationService.SetExpectations(scenario.Spec.Expectations)
+	if updateErr := r.updateScenarioSigmaRules(ctx, req, sigma.RuleStatuses(sigmaRules, result)); updateErr != nil {
+		log.Error(updateErr, "failed update scenario Sigma rules")
+		if err == nil {
+			return ctrl.Result{}, updateErr
+		}
+	}
 
-	// TODO: Run all expectations and get the results
-	passed, err := r.ExpectationService.RunExpectation(ctx)
 	if err != nil {
 		log.Error(err, "failed to run expectation")
 		err := r.updateScenarioStatus(ctx, req, metav1.Condition{Type: typeFailedScenario, Status: metav1.ConditionTrue, Reason: "Failed", Message: err.Error()})
@@ -214,24 +497,107 @@ func (r *ScenarioReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 
 	log.Info("scenario expectation is success")
 
-	duration, _ := time.ParseDuration("1m") // TODO
-	result := threatestergithubiov1alpha1.ExpectationResult{
-		Passed:   passed,
-		Duration: duration, // TODO
-		SucceededExpectations: []threatestergithubiov1alpha1.Expectation{
-			scenario.Spec.Expectations[0],
-		}, // TODO
-		FailedExpectations: []threatestergithubiov1alpha1.FailedExpectation{}, // TODO
-
-	}
-	_, err = r.updateScenarioExpectationResultStatus(ctx, req, result)
+	err = r.updateScenarioStatus(ctx, req, metav1.Condition{Type: typeSucceededScenario, Status: metav1.ConditionTrue, Reason: "Success", Message: "Successfully run scenario expectations"})
 	if err != nil {
-		log.Error(err, "failed update scenario expectation result")
+		log.Error(err, "failed update scenario status")
 		return ctrl.Result{}, err
 	}
+	scenarioSucceeded = true
 
-	err = r.updateScenarioStatus(ctx, req, metav1.Condition{Type: typeSucceededScenario, Status: metav1.ConditionTrue, Reason: "Success", Message: "Successfully run scenario expectations"})
+	return ctrl.Result{}, nil
+}
+
+// runContext returns the context of a run of the scenario.
+// It is done when the deadline of the scenario is reached, or when the scenario is annotated to be cancelled.
+func (r *ScenarioReconciler) runContext(ctx context.Context, req reconcile.Request, scenario *threatestergithubiov1alpha1.Scenario) (context.Context, context.CancelFunc, error) {
+	log := log.FromContext(ctx)
+
+	deadline, err := scenarioApplication.ParseTimeout(scenario.Spec.Deadline)
 	if err != nil {
+		return nil, nil, err
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	if deadline > 0 {
+		var cancelDeadline context.CancelFunc
+		runCtx, cancelDeadline = context.WithTimeout(runCtx, deadline)
+		cancelRun := cancel
+		cancel = func() {
+			cancelDeadline()
+			cancelRun()
+		}
+	}
+
+	// the run blocks the reconciliation of the scenario, so the cancel annotation and the deletion are polled
+	go func() {
+		ticker := time.NewTicker(cancelPollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-runCtx.Done():
+				return
+			case <-ticker.C:
+				latest := &threatestergithubiov1alpha1.Scenario{}
+				if err := r.Get(runCtx, req.NamespacedName, latest); err != nil {
+					if apierrors.IsNotFound(err) {
+						log.Info(fmt.Sprintf("scenario %s is deleted, cancel its run", req.NamespacedName))
+						cancel()
+						return
+					}
+					continue
+				}
+
+				// the finalizer waits for the reconciliation, so the run would otherwise block the deletion until it ends
+				if latest.DeletionTimestamp != nil {
+					log.Info(fmt.Sprintf("scenario %s/%s is being deleted, cancel its run", latest.Namespace, latest.Name))
+					cancel()
+					return
+				}
+
+				if scenarioApplication.IsCancelRequested(*latest) {
+					log.Info(fmt.Sprintf("cancel scenario %s/%s", latest.Namespace, latest.Name))
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	return runCtx, cancel, nil
+}
+
+// interruptedCondition returns the terminal condition of a run aborted by a deadline or by the cancel annotation.
+func interruptedCondition(ctx context.Context, runCtx context.Context, err error) (metav1.Condition, bool) {
+	switch {
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		return metav1.Condition{Type: typeTimedOutScenario, Status: metav1.ConditionTrue, Reason: "TimedOut", Message: "scenario run has reached its deadline"}, true
+	case errors.Is(err, scenarioApplication.ErrScenarioJobDeadlineExceeded):
+		return metav1.Condition{Type: typeTimedOutScenario, Status: metav1.ConditionTrue, Reason: "TimedOut", Message: err.Error()}, true
+	case runCtx.Err() != nil && ctx.Err() == nil:
+		return metav1.Condition{Type: typeCancelledScenario, Status: metav1.ConditionTrue, Reason: "Cancelled", Message: "scenario run was cancelled"}, true
+	}
+
+	return metav1.Condition{}, false
+}
+
+// interruptScenario ends an aborted run. The cleanup phase runs right away, since the expectations will not complete.
+// The suppressions of a cancelled run are removed right away too, since its alerts are not expected anymore.
+func (r *ScenarioReconciler) interruptScenario(ctx context.Context, req reconcile.Request, scenario *threatestergithubiov1alpha1.Scenario, condition metav1.Condition) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+	log.Info(fmt.Sprintf("scenario run is %s: %s", strings.ToLower(condition.Type), condition.Message))
+
+	if err := r.cleanupScenarioRun(ctx, req, scenario); err != nil {
+		log.Error(err, "failed to clean up scenario")
+	}
+
+	if condition.Type == typeCancelledScenario {
+		if err := r.releaseSuppressions(ctx, req); err != nil {
+			log.Error(err, "failed to remove notification suppressions")
+		}
+	}
+
+	if err := r.updateScenarioStatus(ctx, req, condition); err != nil {
 		log.Error(err, "failed update scenario status")
 		return ctrl.Result{}, err
 	}
@@ -239,7 +605,162 @@ func (r *ScenarioReconciler) Reconcile(ctx context.Context, req ctrl.Request) (c
 	return ctrl.Result{}, nil
 }
 
-func (r *ScenarioReconciler) updateScenarioStatus(ctx context.Context, req reconcile.Request, condition metav1.Condition) error {
+// executeScenarioSteps runs the step jobs with the scheduler of the scenario steps, recording the status of each step
+// in the scenario.
+func (r *ScenarioReconciler) executeScenarioSteps(ctx context.Context, req reconcile.Request, scenario *threatestergithubiov1alpha1.Scenario, scenarioJobs []batchv1.Job) error {
+	jobs := map[string]batchv1.Job{}
+	for _, scenarioJob := range scenarioJobs {
+		jobs[scenarioJob.Labels[scenarioApplication.StepLabel]] = scenarioJob
+	}
+
+	return scenarioApplication.ExecuteSteps(ctx, *scenario,
+		func(ctx context.Context, step threatestergithubiov1alpha1.Step) error {
+			return r.executeStep(ctx, req, scenario, step, jobs)
+		},
+		func(stepStatus threatestergithubiov1alpha1.StepStatus) error {
+			return r.updateScenarioStepStatus(ctx, req, stepStatus)
+		},
+	)
+}
+
+// runExpectations evaluates the expectations of each step between the time the step started and the time it completed,
+// then the expectations of the scenario.
+// The result of each step is recorded in its status and rolled up into the result of the scenario.
+func (r *ScenarioReconciler) runExpectations(ctx context.Context, req reconcile.Request, scenario *threatestergithubiov1alpha1.Scenario, startTime metav1.Time) (threatestergithubiov1alpha1.ExpectationResult, error) {
+	result := threatestergithubiov1alpha1.ExpectationResult{
+		Passed:                true,
+		SucceededExpectations: []threatestergithubiov1alpha1.Expectation{},
+		FailedExpectations:    []threatestergithubiov1alpha1.FailedExpectation{},
+	}
+	hasStepExpectations := false
+
+	for _, step := range scenario.Spec.Steps {
+		if len(step.Expectations) == 0 {
+			continue
+		}
+		hasStepExpectations = true
+
+		stepStatus, err := r.getScenarioStepStatus(ctx, req, step.Name)
+		if err != nil {
+			return result, err
+		}
+
+		stepStartTime := startTime
+		if stepStatus.StartTime != nil {
+			stepStartTime = *stepStatus.StartTime
+		}
+
+		// the status keeps the completion time to the second, so the rest of that second belongs to the step too
+		var stepEndTime time.Time
+		if stepStatus.CompletionTime != nil {
+			stepEndTime = stepStatus.CompletionTime.Add(time.Second)
+		}
+
+		stepResult := expectation.Evaluate(ctx, r.ExpectationService, step.Name, step.Expectations, stepStartTime.Time, stepEndTime)
+		stepResult.Duration = time.Since(stepStartTime.Time)
+		expectation.MergeResult(&result, stepResult)
+
+		stepStatus.Result = &stepResult
+		if err := r.updateScenarioStepStatus(ctx, req, *stepStatus); err != nil {
+			return result, err
+		}
+	}
+
+	switch {
+	case scenario.Spec.Target != nil && len(scenario.Spec.Steps) == 0:
+		if err := r.runNodeExpectations(ctx, req, scenario, startTime, &result); err != nil {
+			return result, err
+		}
+	// a scenario without any expectation is evaluated as before, which fails since there is nothing to expect
+	case len(scenario.Spec.Expectations) > 0 || !hasStepExpectations:
+		expectation.MergeResult(&result, expectation.Evaluate(ctx, r.ExpectationService, "", scenario.Spec.Expectations, startTime.Time, time.Time{}))
+	}
+
+	result.Duration = time.Since(startTime.Time)
+
+	if err := expectation.FailureError(result); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// runNodeExpectations evaluates the expectations of the scenario on each node it ran on,
+// only accepting detections tagged with the node, and records the result in the status of the node.
+func (r *ScenarioReconciler) runNodeExpectations(ctx context.Context, req reconcile.Request, scenario *threatestergithubiov1alpha1.Scenario, startTime metav1.Time, result *threatestergithubiov1alpha1.ExpectationResult) error {
+	nodeTag := scenario.Spec.Target.NodeTag
+	if nodeTag == "" {
+		nodeTag = scenarioApplication.DefaultNodeTag
+	}
+
+	latest := &threatestergithubiov1alpha1.Scenario{}
+	if err := r.Get(ctx, req.NamespacedName, latest); err != nil {
+		return err
+	}
+
+	for _, nodeStatus := range latest.Status.Nodes {
+		var nodeResult threatestergithubiov1alpha1.ExpectationResult
+		if nodeStatus.Phase == threatestergithubiov1alpha1.StepPhaseSucceeded {
+			expectations := expectation.ScopeExpectationsToTag(scenario.Spec.Expectations, fmt.Sprintf("%s:%s", nodeTag, nodeStatus.Name))
+			nodeResult = expectation.Evaluate(ctx, r.ExpectationService, "", expectations, startTime.Time, time.Time{})
+		} else {
+			nodeResult = threatestergithubiov1alpha1.ExpectationResult{
+				Passed:             false,
+				FailedExpectations: []threatestergithubiov1alpha1.FailedExpectation{{Reason: fmt.Sprintf("scenario job did not succeed: %s", nodeStatus.Message)}},
+			}
+		}
+
+		for i := range nodeResult.FailedExpectations {
+			nodeResult.FailedExpectations[i].Node = nodeStatus.Name
+		}
+		nodeResult.Duration = time.Since(startTime.Time)
+		expectation.MergeResult(result, nodeResult)
+
+		nodeStatus.Result = &nodeResult
+		if err := r.updateScenarioNodeStatus(ctx, req, nodeStatus); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// collectScenarioResults records the exit code, termination reason and logs of the containers of the scenario jobs
+// before they are deleted. Logs that do not fit in the status are stored in the logs ConfigMap of the scenario.
+func (r *ScenarioReconciler) collectScenarioResults(ctx context.Context, req reconcile.Request, scenario *threatestergithubiov1alpha1.Scenario, scenarioJobs []batchv1.Job) []threatestergithubiov1alpha1.ContainerResult {
+	log := log.FromContext(ctx)
+
+	containers := []threatestergithubiov1alpha1.ContainerResult{}
+	for _, scenarioJob := range scenarioJobs {
+		results, err := r.ScenarioJobExecutor.CollectResults(ctx, scenarioJob)
+		if err != nil && !apierrors.IsNotFound(err) {
+			log.Error(err, fmt.Sprintf("failed to collect results of scenario job %s", scenarioJob.Name))
+		}
+
+		containers = append(containers, results...)
+	}
+	scenarioApplication.BoundLogs(containers, scenarioApplication.MaxTotalLogLength)
+
+	logs := map[string]string{}
+	for i := range containers {
+		if len(containers[i].Logs) <= maxStatusLogLength {
+			continue
+		}
+
+		logs[scenarioApplication.LogsConfigMapKey(containers[i])] = containers[i].Logs
+		containers[i].Logs = scenarioApplication.TailLogs(containers[i].Logs, maxStatusLogLength)
+		containers[i].LogsConfigMap = scenarioApplication.LogsConfigMapName(scenario.Name)
+	}
+
+	if len(logs) > 0 {
+		if err := r.storeScenarioLogs(ctx, req, scenario, logs); err != nil {
+			log.Error(err, "failed to store scenario logs")
+			for i := range containers {
+				containers[i].LogsConfigMap = ""
+			}
+		}
+	}
+
 	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		scenario := &threatestergithubiov1alpha1.Scenario{}
 
@@ -247,33 +768,670 @@ func (r *ScenarioReconciler) updateScenarioStatus(ctx context.Context, req recon
 			return err
 		}
 
-		scenario.Status.Status = condition.Type
-		meta.SetStatusCondition(&scenario.Status.Conditions, condition)
+		scenario.Status.Containers = containers
 
 		return r.Status().Update(ctx, scenario)
 	})
+	if err != nil {
+		log.Error(err, "failed to update scenario container results")
+	}
 
-	return err
+	return containers
 }
 
-func (r *ScenarioReconciler) updateScenarioExpectationResultStatus(ctx context.Context, req reconcile.Request, expectationResult threatestergithubiov1alpha1.ExpectationResult) (*threatestergithubiov1alpha1.Scenario, error) {
+// deleteScenarioResources deletes the objects created for the scenario, along with their dependents such as the job pods.
+// Jobs labeled with the scenario are deleted as well, in case they were created before being tracked.
+func (r *ScenarioReconciler) deleteScenarioResources(ctx context.Context, req reconcile.Request) error {
 	scenario := &threatestergithubiov1alpha1.Scenario{}
-
 	if err := r.Get(ctx, req.NamespacedName, scenario); err != nil {
-		return nil, err
+		return err
 	}
 
-	scenario.Status.Result = expectationResult
-	if err := r.Status().Update(ctx, scenario); err != nil {
-		return nil, err
+	errs := []error{}
+	for _, ref := range scenario.Status.Resources {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(ref.APIVersion)
+		obj.SetKind(ref.Kind)
+		obj.SetNamespace(ref.Namespace)
+		obj.SetName(ref.Name)
+
+		err := r.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground))
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("failed to delete %s %s/%s: %w", ref.Kind, ref.Namespace, ref.Name, err))
+		}
 	}
 
-	return scenario, nil
+	if err := r.deleteScenarioJobs(ctx, scenario); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		scenario := &threatestergithubiov1alpha1.Scenario{}
+
+		if err := r.Get(ctx, req.NamespacedName, scenario); err != nil {
+			return err
+		}
+
+		scenario.Status.Resources = nil
+
+		return r.Status().Update(ctx, scenario)
+	})
 }
 
-// SetupWithManager sets up the controller with the Manager.
-func (r *ScenarioReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&threatestergithubiov1alpha1.Scenario{}).
-		Complete(r)
+// trackScenarioResource records an object created for the scenario, so that the finalizer deletes it.
+func (r *ScenarioReconciler) trackScenarioResource(ctx context.Context, req reconcile.Request, obj client.Object) error {
+	gvk, err := apiutil.GVKForObject(obj, r.Scheme)
+	if err != nil {
+		return err
+	}
+
+	ref := threatestergithubiov1alpha1.ResourceRef{
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		scenario := &threatestergithubiov1alpha1.Scenario{}
+
+		if err := r.Get(ctx, req.NamespacedName, scenario); err != nil {
+			return err
+		}
+
+		for _, tracked := range scenario.Status.Resources {
+			if tracked == ref {
+				return nil
+			}
+		}
+
+		scenario.Status.Resources = append(scenario.Status.Resources, ref)
+
+		return r.Status().Update(ctx, scenario)
+	})
+}
+
+// deleteScenarioJobs deletes the jobs left by the scenario, along with their pods.
+func (r *ScenarioReconciler) deleteScenarioJobs(ctx context.Context, scenario *threatestergithubiov1alpha1.Scenario) error {
+	jobs := &batchv1.JobList{}
+	if err := r.List(ctx, jobs, client.InNamespace(scenario.Namespace), client.MatchingLabels{scenarioApplication.ScenarioLabel: scenario.Name}); err != nil {
+		return err
+	}
+
+	errs := []error{}
+	for _, job := range jobs.Items {
+		if err := r.ScenarioJobExecutor.DeleteScenarioJob(ctx, job); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// storeScenarioLogs writes the logs into the logs ConfigMap of the scenario, which is owned by the scenario.
+func (r *ScenarioReconciler) storeScenarioLogs(ctx context.Context, req reconcile.Request, scenario *threatestergithubiov1alpha1.Scenario, logs map[string]string) error {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      scenarioApplication.LogsConfigMapName(scenario.Name),
+			Namespace: scenario.Namespace,
+		},
+	}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, r.Client, configMap, func() error {
+		configMap.Labels = map[string]string{scenarioApplication.ScenarioLabel: scenario.Name}
+		configMap.Data = logs
+
+		return controllerutil.SetControllerReference(scenario, configMap, r.Scheme)
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.trackScenarioResource(ctx, req, configMap)
+}
+
+// executeStep runs the template of a step, either in the job of the step or inside an existing workload.
+func (r *ScenarioReconciler) executeStep(ctx context.Context, req reconcile.Request, scenario *threatestergithubiov1alpha1.Scenario, step threatestergithubiov1alpha1.Step, jobs map[string]batchv1.Job) error {
+	template, err := scenarioApplication.FindTemplate(scenario.Spec.Templates, step.Template)
+	if err != nil {
+		return err
+	}
+
+	if template.Workload != nil {
+		return r.executeWorkload(ctx, req, scenario, *template, step.Name)
+	}
+
+	return r.ScenarioJobExecutor.Execute(ctx, jobs[step.Name])
+}
+
+// executeScenarioTemplates runs the scenario job and the templates running inside existing workloads at the same time.
+func (r *ScenarioReconciler) executeScenarioTemplates(ctx context.Context, req reconcile.Request, scenario *threatestergithubiov1alpha1.Scenario, scenarioJobs []batchv1.Job) error {
+	results := make(chan error, len(scenarioJobs)+len(scenario.Spec.Templates))
+
+	running := 0
+	for _, scenarioJob := range scenarioJobs {
+		running++
+		go func(job batchv1.Job) {
+			results <- r.ScenarioJobExecutor.Execute(ctx, job)
+		}(scenarioJob)
+	}
+
+	for _, template := range scenario.Spec.Templates {
+		if template.Workload == nil {
+			continue
+		}
+
+		running++
+		go func(template threatestergithubiov1alpha1.Template) {
+			results <- r.executeWorkload(ctx, req, scenario, template, "")
+		}(template)
+	}
+
+	errs := []error{}
+	for i := 0; i < running; i++ {
+		if err := <-results; err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// executeWorkload runs a template inside an existing workload and records its outcome in the scenario status.
+func (r *ScenarioReconciler) executeWorkload(ctx context.Context, req reconcile.Request, scenario *threatestergithubiov1alpha1.Scenario, template threatestergithubiov1alpha1.Template, step string) error {
+	log := log.FromContext(ctx)
+
+	// the timeout of the scenario bounds workload templates like the active deadline of the scenario jobs
+	execCtx := ctx
+	if timeout, err := scenarioApplication.ParseTimeout(scenario.Spec.Timeout); err == nil && timeout > 0 {
+		var cancel context.CancelFunc
+		execCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	execution, err := r.WorkloadExecutor.Execute(execCtx, scenario.Namespace, template)
+	execution.Step = step
+	if err != nil {
+		log.Error(err, fmt.Sprintf("template %s failed in workload", template.Name))
+		execution.Message = err.Error()
+	}
+
+	if updateErr := r.addScenarioExecution(ctx, req, execution); updateErr != nil {
+		log.Error(updateErr, fmt.Sprintf("failed to record the execution of template %s", template.Name))
+	}
+
+	return err
+}
+
+// executeScenarioNodes runs the scenario jobs pinned to the target nodes in parallel.
+// A job failing on a node does not stop the others, it is recorded in the status of the node.
+func (r *ScenarioReconciler) executeScenarioNodes(ctx context.Context, req reconcile.Request, scenarioJobs []batchv1.Job) error {
+	log := log.FromContext(ctx)
+
+	type nodeResult struct {
+		name string
+		err  error
+	}
+	// buffered so that running jobs do not block when returning early
+	results := make(chan nodeResult, len(scenarioJobs))
+
+	for _, scenarioJob := range scenarioJobs {
+		name := scenarioJob.Annotations[scenarioApplication.NodeAnnotation]
+		if err := r.updateScenarioNodeStatus(ctx, req, threatestergithubiov1alpha1.NodeStatus{Name: name, Phase: threatestergithubiov1alpha1.StepPhaseRunning}); err != nil {
+			return err
+		}
+
+		go func(name string, job batchv1.Job) {
+			results <- nodeResult{name: name, err: r.ScenarioJobExecutor.Execute(ctx, job)}
+		}(name, scenarioJob)
+	}
+
+	for range scenarioJobs {
+		result := <-results
+
+		nodeStatus := threatestergithubiov1alpha1.NodeStatus{Name: result.name, Phase: threatestergithubiov1alpha1.StepPhaseSucceeded}
+		if result.err != nil {
+			log.Error(result.err, fmt.Sprintf("scenario job failed on node %s", result.name))
+			nodeStatus.Phase = threatestergithubiov1alpha1.StepPhaseFailed
+			nodeStatus.Message = result.err.Error()
+		}
+
+		if err := r.updateScenarioNodeStatus(ctx, req, nodeStatus); err != nil {
+			log.Error(err, fmt.Sprintf("failed to update status of node %s", result.name))
+		}
+	}
+
+	return nil
+}
+
+// selectTargetNodes returns the names of the nodes selected by the target.
+func (r *ScenarioReconciler) selectTargetNodes(ctx context.Context, target threatestergithubiov1alpha1.Target) ([]string, error) {
+	nodes := &corev1.NodeList{}
+	if err := r.List(ctx, nodes); err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	return scenarioApplication.SelectNodes(nodes.Items, target)
+}
+
+func (r *ScenarioReconciler) getScenarioStepStatus(ctx context.Context, req reconcile.Request, name string) (*threatestergithubiov1alpha1.StepStatus, error) {
+	scenario := &threatestergithubiov1alpha1.Scenario{}
+	if err := r.Get(ctx, req.NamespacedName, scenario); err != nil {
+		return nil, err
+	}
+
+	for i := range scenario.Status.Steps {
+		if scenario.Status.Steps[i].Name == name {
+			return &scenario.Status.Steps[i], nil
+		}
+	}
+
+	return &threatestergithubiov1alpha1.StepStatus{Name: name}, nil
+}
+
+func (r *ScenarioReconciler) updateScenarioStepStatus(ctx context.Context, req reconcile.Request, stepStatus threatestergithubiov1alpha1.StepStatus) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		scenario := &threatestergithubiov1alpha1.Scenario{}
+
+		if err := r.Get(ctx, req.NamespacedName, scenario); err != nil {
+			return err
+		}
+
+		for i := range scenario.Status.Steps {
+			if scenario.Status.Steps[i].Name == stepStatus.Name {
+				scenario.Status.Steps[i] = stepStatus
+				return r.Status().Update(ctx, scenario)
+			}
+		}
+
+		scenario.Status.Steps = append(scenario.Status.Steps, stepStatus)
+
+		return r.Status().Update(ctx, scenario)
+	})
+}
+
+func (r *ScenarioReconciler) updateScenarioNodeStatus(ctx context.Context, req reconcile.Request, nodeStatus threatestergithubiov1alpha1.NodeStatus) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		scenario := &threatestergithubiov1alpha1.Scenario{}
+
+		if err := r.Get(ctx, req.NamespacedName, scenario); err != nil {
+			return err
+		}
+
+		for i := range scenario.Status.Nodes {
+			if scenario.Status.Nodes[i].Name == nodeStatus.Name {
+				scenario.Status.Nodes[i] = nodeStatus
+				return r.Status().Update(ctx, scenario)
+			}
+		}
+
+		scenario.Status.Nodes = append(scenario.Status.Nodes, nodeStatus)
+
+		return r.Status().Update(ctx, scenario)
+	})
+}
+
+func (r *ScenarioReconciler) updateScenarioStatus(ctx context.Context, req reconcile.Request, condition metav1.Condition) error {
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		scenario := &threatestergithubiov1alpha1.Scenario{}
+
+		if err := r.Get(ctx, req.NamespacedName, scenario); err != nil {
+			return err
+		}
+
+		scenario.Status.Status = condition.Type
+		meta.SetStatusCondition(&scenario.Status.Conditions, condition)
+
+		return r.Status().Update(ctx, scenario)
+	})
+
+	return err
+}
+
+func (r *ScenarioReconciler) updateScenarioExpectationResultStatus(ctx context.Context, req reconcile.Request, expectationResult threatestergithubiov1alpha1.ExpectationResult) (*threatestergithubiov1alpha1.Scenario, error) {
+	scenario := &threatestergithubiov1alpha1.Scenario{}
+
+	if err := r.Get(ctx, req.NamespacedName, scenario); err != nil {
+		return nil, err
+	}
+
+	scenario.Status.Result = expectationResult
+	if err := r.Status().Update(ctx, scenario); err != nil {
+		return nil, err
+	}
+
+	return scenario, nil
+}
+
+// updateScenarioRerunRequest records the rerun request and resets the status of the previous run.
+func (r *ScenarioReconciler) updateScenarioRerunRequest(ctx context.Context, req reconcile.Request, request string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		scenario := &threatestergithubiov1alpha1.Scenario{}
+
+		if err := r.Get(ctx, req.NamespacedName, scenario); err != nil {
+			return err
+		}
+
+		scenario.Status.RerunRequest = request
+		scenario.Status.Status = typeProgressingScenario
+		meta.SetStatusCondition(&scenario.Status.Conditions, metav1.Condition{Type: typeProgressingScenario, Status: metav1.ConditionUnknown, Reason: "Rerun", Message: fmt.Sprintf("Rerun %s is requested", request)})
+
+		return r.Status().Update(ctx, scenario)
+	})
+}
+
+// updateScenarioSigmaRules records the revisions of the Sigma rules validated by the run.
+func (r *ScenarioReconciler) updateScenarioSigmaRules(ctx context.Context, req reconcile.Request, sigmaRules []threatestergithubiov1alpha1.SigmaRuleStatus) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		scenario := &threatestergithubiov1alpha1.Scenario{}
+
+		if err := r.Get(ctx, req.NamespacedName, scenario); err != nil {
+			return err
+		}
+
+		scenario.Status.SigmaRules = sigmaRules
+		if len(sigmaRules) == 0 {
+			scenario.Status.SigmaRules = nil
+		}
+
+		return r.Status().Update(ctx, scenario)
+	})
+}
+
+// updateScenarioStartTime records the start of a run, along with the revisions of the templates it runs.
+func (r *ScenarioReconciler) updateScenarioStartTime(ctx context.Context, req reconcile.Request, startTime metav1.Time, templateRevisions []threatestergithubiov1alpha1.TemplateRevision) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		scenario := &threatestergithubiov1alpha1.Scenario{}
+
+		if err := r.Get(ctx, req.NamespacedName, scenario); err != nil {
+			return err
+		}
+
+		scenario.Status.StartTime = &startTime
+		scenario.Status.TemplateRevisions = templateRevisions
+		// the states of the steps, nodes, executions, containers and cleanup belong to the previous run
+		scenario.Status.CleanupTime = nil
+		scenario.Status.Steps = nil
+		scenario.Status.Nodes = nil
+		scenario.Status.Executions = nil
+		scenario.Status.Containers = nil
+		scenario.Status.Plan = nil
+
+		return r.Status().Update(ctx, scenario)
+	})
+}
+
+// planScenario renders the plan of a dry run into the status, with the revisions of the templates it was rendered with.
+// The scenario is Planned, or Failed when one of its expectations is not valid.
+func (r *ScenarioReconciler) planScenario(ctx context.Context, req reconcile.Request, requested threatestergithubiov1alpha1.Scenario, resolved *threatestergithubiov1alpha1.Scenario, scenarioJobs []batchv1.Job, templateRevisions []threatestergithubiov1alpha1.TemplateRevision) error {
+	scenarioPlan, err := plan.Build(ctx, r.ExpectationService, requested, *resolved, scenarioJobs)
+	if err != nil {
+		if err := r.updateScenarioStatus(ctx, req, metav1.Condition{Type: typeFailedScenario, Status: metav1.ConditionFalse, Reason: "Failed", Message: err.Error()}); err != nil {
+			return err
+		}
+		return err
+	}
+
+	condition := metav1.Condition{Type: typePlannedScenario, Status: metav1.ConditionTrue, Reason: "DryRun", Message: fmt.Sprintf("Planned %d resources and %d expectations", len(scenarioPlan.Resources), len(scenarioPlan.Expectations))}
+	if err := plan.InvalidError(*scenarioPlan); err != nil {
+		condition = metav1.Condition{Type: typeFailedScenario, Status: metav1.ConditionFalse, Reason: "DryRun", Message: err.Error()}
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		scenario := &threatestergithubiov1alpha1.Scenario{}
+
+		if err := r.Get(ctx, req.NamespacedName, scenario); err != nil {
+			return err
+		}
+
+		scenario.Status.Plan = scenarioPlan
+		scenario.Status.TemplateRevisions = templateRevisions
+		scenario.Status.Status = condition.Type
+		meta.SetStatusCondition(&scenario.Status.Conditions, condition)
+
+		return r.Status().Update(ctx, scenario)
+	})
+}
+
+// isPlanned reports whether the dry run of the current generation of the scenario has been rendered.
+func isPlanned(scenario threatestergithubiov1alpha1.Scenario) bool {
+	if scenario.Status.Plan == nil || scenario.Status.Plan.Generation != scenario.Generation {
+		return false
+	}
+
+	return scenario.Status.Status == typePlannedScenario || scenario.Status.Status == typeFailedScenario
+}
+
+// cleanupScenario runs the cleanup phase of the scenario once: the cleanup of the detections and the revert of
+// the Stratus Red Team techniques. It is called after the expectations complete, after the attack fails and from the
+// finalizer, in case the run was aborted.
+func (r *ScenarioReconciler) cleanupScenario(ctx context.Context, req reconcile.Request) error {
+	scenario := &threatestergithubiov1alpha1.Scenario{}
+	if err := r.Get(ctx, req.NamespacedName, scenario); err != nil {
+		return err
+	}
+
+	hasStratusTemplates := scenarioApplication.HasStratusTemplates(scenario.Spec.Templates)
+	if (scenario.Spec.Cleanup == nil && !hasStratusTemplates) || scenario.Status.StartTime == nil || scenario.Status.CleanupTime != nil {
+		return nil
+	}
+
+	// the cleanup queries and the techniques may reference the parameters of the scenario
+	parameterizedScenario, err := scenarioApplication.ApplyParameters(*scenario)
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+	if scenario.Spec.Cleanup != nil {
+		if err := r.CleanupService.Cleanup(ctx, *parameterizedScenario); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if hasStratusTemplates {
+		if err := r.cleanupStratusTechniques(ctx, req, parameterizedScenario); err != nil {
+			errs = append(errs, fmt.Errorf("failed to revert Stratus Red Team techniques: %w", err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return utilerrors.NewAggregate(errs)
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		scenario := &threatestergithubiov1alpha1.Scenario{}
+
+		if err := r.Get(ctx, req.NamespacedName, scenario); err != nil {
+			return err
+		}
+
+		now := metav1.Now()
+		scenario.Status.CleanupTime = &now
+
+		return r.Status().Update(ctx, scenario)
+	})
+}
+
+// cleanupScenarioRun runs the cleanup phase at the end of a run. The suppressions of the run are removed once the
+// cleanup has resolved the monitors. Otherwise they are kept until their downtimes end, since the monitors triggered
+// by the run are usually still alerting when it ends.
+func (r *ScenarioReconciler) cleanupScenarioRun(ctx context.Context, req reconcile.Request, scenario *threatestergithubiov1alpha1.Scenario) error {
+	if err := r.cleanupScenario(ctx, req); err != nil {
+		return err
+	}
+
+	if scenario.Spec.Cleanup == nil || scenario.Spec.Cleanup.Datadog == nil || !scenario.Spec.Cleanup.Datadog.ResolveMonitors {
+		return nil
+	}
+
+	return r.releaseSuppressions(ctx, req)
+}
+
+// createStratusState creates the volume keeping the state of the Stratus Red Team techniques between the
+// detonation and the cleanup jobs. The volume is owned by the scenario and kept across reruns.
+func (r *ScenarioReconciler) createStratusState(ctx context.Context, req reconcile.Request, scenario *threatestergithubiov1alpha1.Scenario) error {
+	claim := scenarioApplication.BuildStratusStateClaim(*scenario)
+	if err := controllerutil.SetControllerReference(scenario, claim, r.Scheme); err != nil {
+		return err
+	}
+
+	if err := r.Create(ctx, claim); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+
+	return r.trackScenarioResource(ctx, req, claim)
+}
+
+// cleanupStratusTechniques runs the cleanup job reverting the Stratus Red Team techniques and deletes it once done.
+func (r *ScenarioReconciler) cleanupStratusTechniques(ctx context.Context, req reconcile.Request, scenario *threatestergithubiov1alpha1.Scenario) error {
+	job, err := scenarioApplication.BuildStratusCleanupJob(*scenario)
+	if err != nil {
+		return err
+	}
+
+	if err := controllerutil.SetControllerReference(scenario, job, r.Scheme); err != nil {
+		return err
+	}
+
+	if err := r.trackScenarioResource(ctx, req, job); err != nil {
+		return err
+	}
+
+	// a cleanup job left by a previous attempt would prevent the new one from being created
+	if err := r.ScenarioJobExecutor.DeleteScenarioJob(ctx, *job); err != nil {
+		return err
+	}
+
+	err = r.ScenarioJobExecutor.Execute(ctx, *job)
+	if deleteErr := r.ScenarioJobExecutor.DeleteScenarioJob(ctx, *job); deleteErr != nil {
+		return utilerrors.NewAggregate([]error{err, deleteErr})
+	}
+
+	return err
+}
+
+// recordFinalizerFailure records a failed attempt of the finalizer in the scenario status and returns the number of
+// failed attempts so far.
+func (r *ScenarioReconciler) recordFinalizerFailure(ctx context.Context, req reconcile.Request, finalizerErr error) (int32, error) {
+	var attempts int32
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		scenario := &threatestergithubiov1alpha1.Scenario{}
+
+		if err := r.Get(ctx, req.NamespacedName, scenario); err != nil {
+			return err
+		}
+
+		scenario.Status.FinalizerAttempts++
+		attempts = scenario.Status.FinalizerAttempts
+		meta.SetStatusCondition(&scenario.Status.Conditions, metav1.Condition{Type: typeAvailableScenario, Status: metav1.ConditionFalse, Reason: "FinalizerFailed", Message: fmt.Sprintf("attempt %d of %d failed: %s", attempts, maxFinalizerAttempts, finalizerErr)})
+
+		return r.Status().Update(ctx, scenario)
+	})
+
+	return attempts, err
+}
+
+// releaseSuppressions removes the notification suppressions recorded in the scenario status.
+func (r *ScenarioReconciler) releaseSuppressions(ctx context.Context, req reconcile.Request) error {
+	scenario := &threatestergithubiov1alpha1.Scenario{}
+	if err := r.Get(ctx, req.NamespacedName, scenario); err != nil {
+		return err
+	}
+
+	if len(scenario.Status.Suppressions) == 0 {
+		return nil
+	}
+
+	if err := r.SuppressionService.Unsuppress(ctx, scenario.Status.Suppressions); err != nil {
+		return err
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		scenario := &threatestergithubiov1alpha1.Scenario{}
+
+		if err := r.Get(ctx, req.NamespacedName, scenario); err != nil {
+			return err
+		}
+
+		scenario.Status.Suppressions = nil
+
+		return r.Status().Update(ctx, scenario)
+	})
+}
+
+func (r *ScenarioReconciler) addScenarioExecution(ctx context.Context, req reconcile.Request, execution threatestergithubiov1alpha1.ExecutionStatus) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		scenario := &threatestergithubiov1alpha1.Scenario{}
+
+		if err := r.Get(ctx, req.NamespacedName, scenario); err != nil {
+			return err
+		}
+
+		scenario.Status.Executions = append(scenario.Status.Executions, execution)
+
+		return r.Status().Update(ctx, scenario)
+	})
+}
+
+func (r *ScenarioReconciler) addScenarioSuppressions(ctx context.Context, req reconcile.Request, suppressions []threatestergithubiov1alpha1.SuppressionRef) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		scenario := &threatestergithubiov1alpha1.Scenario{}
+
+		if err := r.Get(ctx, req.NamespacedName, scenario); err != nil {
+			return err
+		}
+
+		scenario.Status.Suppressions = append(scenario.Status.Suppressions, suppressions...)
+
+		return r.Status().Update(ctx, scenario)
+	})
+}
+
+// SetupWithManager sets up the controller with the Manager.
+// Updating a ScenarioTemplate or ClusterScenarioTemplate reconciles the scenarios referencing it.
+func (r *ScenarioReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &threatestergithubiov1alpha1.Scenario{}, templateRefIndexField, func(obj client.Object) []string {
+		return scenarioApplication.TemplateRefKeys(*obj.(*threatestergithubiov1alpha1.Scenario))
+	})
+	if err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&threatestergithubiov1alpha1.Scenario{}).
+		Watches(&source.Kind{Type: &threatestergithubiov1alpha1.ScenarioTemplate{}}, handler.EnqueueRequestsFromMapFunc(r.findScenariosForTemplate)).
+		Watches(&source.Kind{Type: &threatestergithubiov1alpha1.ClusterScenarioTemplate{}}, handler.EnqueueRequestsFromMapFunc(r.findScenariosForTemplate)).
+		Complete(r)
+}
+
+// findScenariosForTemplate returns the requests of the scenarios referencing the template.
+func (r *ScenarioReconciler) findScenariosForTemplate(obj client.Object) []reconcile.Request {
+	ctx := context.Background()
+	log := log.FromContext(ctx)
+
+	kind := threatestergithubiov1alpha1.TemplateKindScenarioTemplate
+	opts := []client.ListOption{client.InNamespace(obj.GetNamespace())}
+	if _, ok := obj.(*threatestergithubiov1alpha1.ClusterScenarioTemplate); ok {
+		kind = threatestergithubiov1alpha1.TemplateKindClusterScenarioTemplate
+		opts = []client.ListOption{}
+	}
+	opts = append(opts, client.MatchingFields{templateRefIndexField: scenarioApplication.TemplateRefKey(kind, obj.GetName())})
+
+	scenarios := &threatestergithubiov1alpha1.ScenarioList{}
+	if err := r.List(ctx, scenarios, opts...); err != nil {
+		log.Error(err, fmt.Sprintf("failed to list scenarios referencing %s %s", kind, obj.GetName()))
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(scenarios.Items))
+	for _, scenario := range scenarios.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: scenario.Name, Namespace: scenario.Namespace}})
+	}
+
+	return requests
 }
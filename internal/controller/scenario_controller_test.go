@@ -6,15 +6,19 @@ import (
 	"time"
 
 	threatestergithubiov1alpha1 "github.com/mrtc0/threatester/api/v1alpha1"
+	"github.com/mrtc0/threatester/internal/application/cleanup"
 	"github.com/mrtc0/threatester/internal/application/expectation"
 	scenarioApplication "github.com/mrtc0/threatester/internal/application/scenario"
+	"github.com/mrtc0/threatester/internal/application/suppression"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -84,6 +88,7 @@ var _ = Describe("Scenario controller", func() {
 			}, time.Minute, time.Second).Should(Succeed())
 
 			By("Reconciling the custom resource created")
+			executedJobs := []batchv1.Job{}
 			scenarioReconciler := &ScenarioReconciler{
 				Client: k8sClient,
 				Scheme: k8sClient.Scheme(),
@@ -93,15 +98,36 @@ var _ = Describe("Scenario controller", func() {
 					},
 					SetExpectationsFunc: func(expectations []threatestergithubiov1alpha1.Expectation) {
 					},
+					SetStartTimeFunc: func(startTime time.Time) {
+					},
+					SetEndTimeFunc: func(endTime time.Time) {
+					},
 				},
 				ScenarioJobExecutor: &scenarioApplication.ScenarioJobExecutorMock{
 					ExecuteFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						executedJobs = append(executedJobs, scenarioJob)
 						return nil
 					},
+					CollectResultsFunc: func(ctx context.Context, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.ContainerResult, error) {
+						return []threatestergithubiov1alpha1.ContainerResult{}, nil
+					},
 					DeleteScenarioJobFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
 						return nil
 					},
 				},
+				CleanupService: &cleanup.CleanupServiceMock{
+					CleanupFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario) error {
+						return nil
+					},
+				},
+				SuppressionService: &suppression.SuppressionServiceMock{
+					SuppressFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.SuppressionRef, error) {
+						return []threatestergithubiov1alpha1.SuppressionRef{}, nil
+					},
+					UnsuppressFunc: func(ctx context.Context, suppressions []threatestergithubiov1alpha1.SuppressionRef) error {
+						return nil
+					},
+				},
 			}
 
 			_, err = scenarioReconciler.Reconcile(ctx, reconcile.Request{
@@ -129,6 +155,1556 @@ var _ = Describe("Scenario controller", func() {
 
 				return nil
 			}, time.Minute, time.Second).Should(Succeed())
+
+			By("Checking that the scenario owns and tracks its job")
+			Expect(executedJobs).To(HaveLen(1))
+			Expect(executedJobs[0].OwnerReferences).To(HaveLen(1))
+			Expect(executedJobs[0].OwnerReferences[0].Name).To(Equal(scenarioName))
+
+			found := &threatestergithubiov1alpha1.Scenario{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: scenarioName, Namespace: namespace.Name}, found)).To(Succeed())
+			Expect(found.Status.Resources).To(ContainElement(threatestergithubiov1alpha1.ResourceRef{
+				APIVersion: "batch/v1",
+				Kind:       "Job",
+				Namespace:  namespace.Name,
+				Name:       executedJobs[0].Name,
+			}))
+		})
+	})
+
+	Context("Scenario with steps", func() {
+		ctx := context.Background()
+		const scenarioName = "test-scenario-steps"
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "threatester-steps-test",
+			},
+		}
+
+		BeforeEach(func() {
+			By("Creating the namespace for tests")
+			err := k8sClient.Create(ctx, namespace)
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		AfterEach(func() {
+			By("Deleting the namespace for tests")
+			_ = k8sClient.Delete(ctx, namespace)
+		})
+
+		It("Should skip the remaining steps when a step fails", func() {
+			scenario := &threatestergithubiov1alpha1.Scenario{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      scenarioName,
+					Namespace: namespace.Name,
+				},
+				Spec: threatestergithubiov1alpha1.ScenarioSpec{
+					Templates: []threatestergithubiov1alpha1.Template{
+						{Name: "recon", Container: &corev1.Container{Name: "recon", Image: "alpine", Command: []string{"id"}}},
+						{Name: "credential-access", Container: &corev1.Container{Name: "credential-access", Image: "alpine", Command: []string{"cat", "/etc/shadow"}}},
+						{Name: "lateral-movement", Container: &corev1.Container{Name: "lateral-movement", Image: "alpine", Command: []string{"true"}}},
+					},
+					Steps: []threatestergithubiov1alpha1.Step{
+						{Name: "recon", Template: "recon"},
+						{Name: "credential-access", Template: "credential-access"},
+						{Name: "lateral-movement", Template: "lateral-movement"},
+					},
+				},
+			}
+			err := k8sClient.Create(ctx, scenario)
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("Reconciling the custom resource created")
+			scenarioReconciler := &ScenarioReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				ExpectationService: &expectation.ExpectationServiceMock{
+					RunExpectationFunc: func(ctx context.Context) (bool, error) {
+						return true, nil
+					},
+					SetExpectationsFunc: func(expectations []threatestergithubiov1alpha1.Expectation) {
+					},
+					SetStartTimeFunc: func(startTime time.Time) {
+					},
+					SetEndTimeFunc: func(endTime time.Time) {
+					},
+				},
+				ScenarioJobExecutor: &scenarioApplication.ScenarioJobExecutorMock{
+					ExecuteFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						if scenarioJob.Labels[scenarioApplication.StepLabel] == "credential-access" {
+							return fmt.Errorf("scenario job is failed")
+						}
+						return nil
+					},
+					CollectResultsFunc: func(ctx context.Context, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.ContainerResult, error) {
+						if scenarioJob.Labels[scenarioApplication.StepLabel] != "credential-access" {
+							return nil, errors.NewNotFound(batchv1.Resource("jobs"), scenarioJob.Name)
+						}
+
+						exitCode := int32(1)
+						return []threatestergithubiov1alpha1.ContainerResult{
+							{Job: scenarioJob.Name, Pod: scenarioJob.Name + "-abcde", Container: "credential-access", ExitCode: &exitCode, Reason: "Error", Logs: "cat: can't open '/etc/shadow': Permission denied\n"},
+						}, nil
+					},
+					DeleteScenarioJobFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						return nil
+					},
+				},
+				CleanupService: &cleanup.CleanupServiceMock{
+					CleanupFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario) error {
+						return nil
+					},
+				},
+				SuppressionService: &suppression.SuppressionServiceMock{
+					SuppressFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.SuppressionRef, error) {
+						return []threatestergithubiov1alpha1.SuppressionRef{}, nil
+					},
+					UnsuppressFunc: func(ctx context.Context, suppressions []threatestergithubiov1alpha1.SuppressionRef) error {
+						return nil
+					},
+				},
+			}
+
+			_, err = scenarioReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: scenarioName, Namespace: namespace.Name},
+			})
+			Expect(err).To(HaveOccurred())
+
+			found := &threatestergithubiov1alpha1.Scenario{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: scenarioName, Namespace: namespace.Name}, found)
+			Expect(err).To(Not(HaveOccurred()))
+
+			Expect(found.Status.Status).To(Equal(typeFailedScenario))
+			Expect(found.Status.Steps).To(HaveLen(3))
+			Expect(found.Status.Steps[0].Phase).To(Equal(threatestergithubiov1alpha1.StepPhaseSucceeded))
+			Expect(found.Status.Steps[1].Phase).To(Equal(threatestergithubiov1alpha1.StepPhaseFailed))
+			Expect(found.Status.Steps[2].Phase).To(Equal(threatestergithubiov1alpha1.StepPhaseSkipped))
+
+			Expect(found.Status.Containers).To(HaveLen(1))
+			Expect(found.Status.Conditions[len(found.Status.Conditions)-1].Message).To(ContainSubstring("cat: can't open '/etc/shadow': Permission denied"))
+
+			By("Skipping the failed scenario when it is reconciled again")
+			scenarioJobExecutor := scenarioReconciler.ScenarioJobExecutor.(*scenarioApplication.ScenarioJobExecutorMock)
+			Expect(scenarioJobExecutor.ExecuteCalls()).To(HaveLen(2))
+
+			_, err = scenarioReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: scenarioName, Namespace: namespace.Name},
+			})
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(scenarioJobExecutor.ExecuteCalls()).To(HaveLen(2))
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: scenarioName, Namespace: namespace.Name}, found)
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(found.Status.Status).To(Equal(typeFailedScenario))
+		})
+	})
+
+	Context("Scenario with step expectations", func() {
+		ctx := context.Background()
+		const scenarioName = "test-scenario-step-expectations"
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "threatester-step-expectations-test",
+			},
+		}
+
+		BeforeEach(func() {
+			By("Creating the namespace for tests")
+			err := k8sClient.Create(ctx, namespace)
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		AfterEach(func() {
+			By("Deleting the namespace for tests")
+			_ = k8sClient.Delete(ctx, namespace)
+		})
+
+		It("Should record which steps were detected", func() {
+			detected := threatestergithubiov1alpha1.Expectation{
+				Datadog: &threatestergithubiov1alpha1.DatadogExpectation{
+					Monitor: &threatestergithubiov1alpha1.DatadogMonitor{ID: "1", Status: "Alert"},
+				},
+			}
+			missed := threatestergithubiov1alpha1.Expectation{
+				Datadog: &threatestergithubiov1alpha1.DatadogExpectation{
+					Monitor: &threatestergithubiov1alpha1.DatadogMonitor{ID: "2", Status: "Alert"},
+				},
+			}
+
+			scenario := &threatestergithubiov1alpha1.Scenario{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      scenarioName,
+					Namespace: namespace.Name,
+				},
+				Spec: threatestergithubiov1alpha1.ScenarioSpec{
+					Templates: []threatestergithubiov1alpha1.Template{
+						{Name: "recon", Container: &corev1.Container{Name: "recon", Image: "alpine", Command: []string{"id"}}},
+						{Name: "credential-access", Container: &corev1.Container{Name: "credential-access", Image: "alpine", Command: []string{"cat", "/etc/shadow"}}},
+					},
+					Steps: []threatestergithubiov1alpha1.Step{
+						{Name: "recon", Template: "recon", Expectations: []threatestergithubiov1alpha1.Expectation{detected}},
+						{Name: "credential-access", Template: "credential-access", Expectations: []threatestergithubiov1alpha1.Expectation{missed}},
+					},
+				},
+			}
+			err := k8sClient.Create(ctx, scenario)
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("Reconciling the custom resource created")
+			var expectations []threatestergithubiov1alpha1.Expectation
+			scenarioReconciler := &ScenarioReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				ExpectationService: &expectation.ExpectationServiceMock{
+					RunExpectationFunc: func(ctx context.Context) (bool, error) {
+						if expectations[0].Datadog.Monitor.ID == missed.Datadog.Monitor.ID {
+							return false, fmt.Errorf("monitor 2 did not transition to Alert")
+						}
+						return true, nil
+					},
+					SetExpectationsFunc: func(e []threatestergithubiov1alpha1.Expectation) {
+						expectations = e
+					},
+					SetStartTimeFunc: func(startTime time.Time) {
+					},
+					SetEndTimeFunc: func(endTime time.Time) {
+					},
+				},
+				ScenarioJobExecutor: &scenarioApplication.ScenarioJobExecutorMock{
+					ExecuteFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						return nil
+					},
+					CollectResultsFunc: func(ctx context.Context, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.ContainerResult, error) {
+						return []threatestergithubiov1alpha1.ContainerResult{}, nil
+					},
+					DeleteScenarioJobFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						return nil
+					},
+				},
+				CleanupService: &cleanup.CleanupServiceMock{
+					CleanupFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario) error {
+						return nil
+					},
+				},
+				SuppressionService: &suppression.SuppressionServiceMock{
+					SuppressFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.SuppressionRef, error) {
+						return []threatestergithubiov1alpha1.SuppressionRef{}, nil
+					},
+					UnsuppressFunc: func(ctx context.Context, suppressions []threatestergithubiov1alpha1.SuppressionRef) error {
+						return nil
+					},
+				},
+			}
+
+			_, err = scenarioReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: scenarioName, Namespace: namespace.Name},
+			})
+			Expect(err).To(HaveOccurred())
+
+			found := &threatestergithubiov1alpha1.Scenario{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: scenarioName, Namespace: namespace.Name}, found)
+			Expect(err).To(Not(HaveOccurred()))
+
+			Expect(found.Status.Status).To(Equal(typeFailedScenario))
+			Expect(found.Status.Steps).To(HaveLen(2))
+			Expect(found.Status.Steps[0].Result.Passed).To(BeTrue())
+			Expect(found.Status.Steps[1].Result.Passed).To(BeFalse())
+
+			Expect(found.Status.Result.Passed).To(BeFalse())
+			Expect(found.Status.Result.SucceededExpectations).To(HaveLen(1))
+			Expect(found.Status.Result.FailedExpectations).To(HaveLen(1))
+			Expect(found.Status.Result.FailedExpectations[0].Step).To(Equal("credential-access"))
+		})
+	})
+
+	Context("Scenario with a target", func() {
+		ctx := context.Background()
+		const scenarioName = "test-scenario-target"
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "threatester-target-test",
+			},
+		}
+
+		BeforeEach(func() {
+			By("Creating the namespace for tests")
+			err := k8sClient.Create(ctx, namespace)
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("Creating the nodes for tests")
+			for _, name := range []string{"cws-node", "legacy-node"} {
+				node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"threatester-test": "true"}}}
+				Expect(k8sClient.Create(ctx, node)).To(Succeed())
+
+				node.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}
+				Expect(k8sClient.Status().Update(ctx, node)).To(Succeed())
+			}
+		})
+
+		AfterEach(func() {
+			By("Deleting the namespace for tests")
+			_ = k8sClient.Delete(ctx, namespace)
+
+			By("Deleting the nodes for tests")
+			for _, name := range []string{"cws-node", "legacy-node"} {
+				_ = k8sClient.Delete(ctx, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}})
+			}
+		})
+
+		It("Should run the scenario on each selected node and record the nodes without detection", func() {
+			scenario := &threatestergithubiov1alpha1.Scenario{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      scenarioName,
+					Namespace: namespace.Name,
+				},
+				Spec: threatestergithubiov1alpha1.ScenarioSpec{
+					Templates: []threatestergithubiov1alpha1.Template{
+						{Name: "recon", Container: &corev1.Container{Name: "recon", Image: "alpine", Command: []string{"id"}}},
+					},
+					Target: &threatestergithubiov1alpha1.Target{
+						NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"threatester-test": "true"}},
+					},
+					Expectations: []threatestergithubiov1alpha1.Expectation{
+						{
+							Datadog: &threatestergithubiov1alpha1.DatadogExpectation{
+								Monitor: &threatestergithubiov1alpha1.DatadogMonitor{ID: "1", Status: "Alert"},
+							},
+						},
+					},
+				},
+			}
+			err := k8sClient.Create(ctx, scenario)
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("Reconciling the custom resource created")
+			var expectations []threatestergithubiov1alpha1.Expectation
+			pinnedNodes := []string{}
+			scenarioReconciler := &ScenarioReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				ExpectationService: &expectation.ExpectationServiceMock{
+					RunExpectationFunc: func(ctx context.Context) (bool, error) {
+						if expectations[0].Datadog.Monitor.Group == "host:legacy-node" {
+							return false, fmt.Errorf("monitor 1 has no group matching %q", expectations[0].Datadog.Monitor.Group)
+						}
+						return true, nil
+					},
+					SetExpectationsFunc: func(e []threatestergithubiov1alpha1.Expectation) {
+						expectations = e
+					},
+					SetStartTimeFunc: func(startTime time.Time) {
+					},
+					SetEndTimeFunc: func(endTime time.Time) {
+					},
+				},
+				ScenarioJobExecutor: &scenarioApplication.ScenarioJobExecutorMock{
+					ExecuteFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						return nil
+					},
+					CollectResultsFunc: func(ctx context.Context, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.ContainerResult, error) {
+						return []threatestergithubiov1alpha1.ContainerResult{}, nil
+					},
+					DeleteScenarioJobFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						pinnedNodes = append(pinnedNodes, scenarioJob.Spec.Template.Spec.NodeName)
+						return nil
+					},
+				},
+				CleanupService: &cleanup.CleanupServiceMock{
+					CleanupFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario) error {
+						return nil
+					},
+				},
+				SuppressionService: &suppression.SuppressionServiceMock{
+					SuppressFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.SuppressionRef, error) {
+						return []threatestergithubiov1alpha1.SuppressionRef{}, nil
+					},
+					UnsuppressFunc: func(ctx context.Context, suppressions []threatestergithubiov1alpha1.SuppressionRef) error {
+						return nil
+					},
+				},
+			}
+
+			_, err = scenarioReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: scenarioName, Namespace: namespace.Name},
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(pinnedNodes).To(ConsistOf("cws-node", "legacy-node"))
+
+			found := &threatestergithubiov1alpha1.Scenario{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: scenarioName, Namespace: namespace.Name}, found)
+			Expect(err).To(Not(HaveOccurred()))
+
+			Expect(found.Status.Status).To(Equal(typeFailedScenario))
+			Expect(found.Status.Nodes).To(HaveLen(2))
+			for _, node := range found.Status.Nodes {
+				Expect(node.Phase).To(Equal(threatestergithubiov1alpha1.StepPhaseSucceeded))
+				Expect(node.Result.Passed).To(Equal(node.Name == "cws-node"))
+			}
+
+			Expect(found.Status.Result.FailedExpectations).To(HaveLen(1))
+			Expect(found.Status.Result.FailedExpectations[0].Node).To(Equal("legacy-node"))
+		})
+	})
+
+	Context("Scenario with a workload template", func() {
+		ctx := context.Background()
+		const scenarioName = "test-scenario-workload"
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "threatester-workload-test",
+			},
+		}
+
+		BeforeEach(func() {
+			By("Creating the namespace for tests")
+			err := k8sClient.Create(ctx, namespace)
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		AfterEach(func() {
+			By("Deleting the namespace for tests")
+			_ = k8sClient.Delete(ctx, namespace)
+		})
+
+		It("Should record the output of the command run inside the workload", func() {
+			scenario := &threatestergithubiov1alpha1.Scenario{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      scenarioName,
+					Namespace: namespace.Name,
+				},
+				Spec: threatestergithubiov1alpha1.ScenarioSpec{
+					Templates: []threatestergithubiov1alpha1.Template{
+						{
+							Name:      "read-token",
+							Container: &corev1.Container{Name: "read-token", Image: "alpine", Command: []string{"cat", "/var/run/secrets/kubernetes.io/serviceaccount/token"}},
+							Workload: &threatestergithubiov1alpha1.Workload{
+								Selector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+							},
+						},
+					},
+					Expectations: []threatestergithubiov1alpha1.Expectation{
+						{
+							Datadog: &threatestergithubiov1alpha1.DatadogExpectation{
+								Monitor: &threatestergithubiov1alpha1.DatadogMonitor{ID: "1", Status: "Alert"},
+							},
+						},
+					},
+				},
+			}
+			err := k8sClient.Create(ctx, scenario)
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("Reconciling the custom resource created")
+			scenarioReconciler := &ScenarioReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				ExpectationService: &expectation.ExpectationServiceMock{
+					RunExpectationFunc: func(ctx context.Context) (bool, error) {
+						return true, nil
+					},
+					SetExpectationsFunc: func(expectations []threatestergithubiov1alpha1.Expectation) {
+					},
+					SetStartTimeFunc: func(startTime time.Time) {
+					},
+					SetEndTimeFunc: func(endTime time.Time) {
+					},
+				},
+				ScenarioJobExecutor: &scenarioApplication.ScenarioJobExecutorMock{
+					ExecuteFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						return fmt.Errorf("no scenario job is expected")
+					},
+					CollectResultsFunc: func(ctx context.Context, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.ContainerResult, error) {
+						return []threatestergithubiov1alpha1.ContainerResult{}, nil
+					},
+					DeleteScenarioJobFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						return nil
+					},
+				},
+				WorkloadExecutor: &scenarioApplication.WorkloadExecutorMock{
+					ExecuteFunc: func(ctx context.Context, namespace string, template threatestergithubiov1alpha1.Template) (threatestergithubiov1alpha1.ExecutionStatus, error) {
+						exitCode := int32(0)
+						return threatestergithubiov1alpha1.ExecutionStatus{Template: template.Name, Pod: "web-0", Container: "app", ExitCode: &exitCode, Output: "token"}, nil
+					},
+				},
+				CleanupService: &cleanup.CleanupServiceMock{
+					CleanupFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario) error {
+						return nil
+					},
+				},
+				SuppressionService: &suppression.SuppressionServiceMock{
+					SuppressFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.SuppressionRef, error) {
+						return []threatestergithubiov1alpha1.SuppressionRef{}, nil
+					},
+					UnsuppressFunc: func(ctx context.Context, suppressions []threatestergithubiov1alpha1.SuppressionRef) error {
+						return nil
+					},
+				},
+			}
+
+			_, err = scenarioReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: scenarioName, Namespace: namespace.Name},
+			})
+			Expect(err).To(Not(HaveOccurred()))
+
+			found := &threatestergithubiov1alpha1.Scenario{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: scenarioName, Namespace: namespace.Name}, found)
+			Expect(err).To(Not(HaveOccurred()))
+
+			Expect(found.Status.Status).To(Equal(typeSucceededScenario))
+			Expect(found.Status.Executions).To(HaveLen(1))
+			Expect(found.Status.Executions[0].Pod).To(Equal("web-0"))
+			Expect(*found.Status.Executions[0].ExitCode).To(Equal(int32(0)))
+			Expect(found.Status.Executions[0].Output).To(Equal("token"))
+		})
+	})
+
+	Context("Scenario with a deadline", func() {
+		ctx := context.Background()
+		const scenarioName = "test-scenario-deadline"
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "threatester-deadline-test",
+			},
+		}
+
+		BeforeEach(func() {
+			By("Creating the namespace for tests")
+			err := k8sClient.Create(ctx, namespace)
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		AfterEach(func() {
+			By("Deleting the namespace for tests")
+			_ = k8sClient.Delete(ctx, namespace)
+		})
+
+		It("Should time out a run that exceeds the deadline and clean it up", func() {
+			scenario := &threatestergithubiov1alpha1.Scenario{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      scenarioName,
+					Namespace: namespace.Name,
+				},
+				Spec: threatestergithubiov1alpha1.ScenarioSpec{
+					Deadline: "1s",
+					Templates: []threatestergithubiov1alpha1.Template{
+						{
+							Name:      "hang",
+							Container: &corev1.Container{Name: "hang", Image: "alpine", Command: []string{"sleep", "infinity"}},
+							Workload: &threatestergithubiov1alpha1.Workload{
+								Selector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+							},
+						},
+					},
+				},
+			}
+			err := k8sClient.Create(ctx, scenario)
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("Reconciling the custom resource created")
+			cleanedUp := false
+			scenarioReconciler := &ScenarioReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				ExpectationService: &expectation.ExpectationServiceMock{
+					RunExpectationFunc: func(ctx context.Context) (bool, error) {
+						return false, fmt.Errorf("no expectation is expected")
+					},
+					SetExpectationsFunc: func(expectations []threatestergithubiov1alpha1.Expectation) {
+					},
+					SetStartTimeFunc: func(startTime time.Time) {
+					},
+					SetEndTimeFunc: func(endTime time.Time) {
+					},
+				},
+				ScenarioJobExecutor: &scenarioApplication.ScenarioJobExecutorMock{
+					ExecuteFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						return fmt.Errorf("no scenario job is expected")
+					},
+					CollectResultsFunc: func(ctx context.Context, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.ContainerResult, error) {
+						return []threatestergithubiov1alpha1.ContainerResult{}, nil
+					},
+					DeleteScenarioJobFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						return nil
+					},
+				},
+				WorkloadExecutor: &scenarioApplication.WorkloadExecutorMock{
+					ExecuteFunc: func(ctx context.Context, namespace string, template threatestergithubiov1alpha1.Template) (threatestergithubiov1alpha1.ExecutionStatus, error) {
+						<-ctx.Done()
+						return threatestergithubiov1alpha1.ExecutionStatus{Template: template.Name}, ctx.Err()
+					},
+				},
+				CleanupService: &cleanup.CleanupServiceMock{
+					CleanupFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario) error {
+						cleanedUp = true
+						return nil
+					},
+				},
+				SuppressionService: &suppression.SuppressionServiceMock{
+					SuppressFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.SuppressionRef, error) {
+						return []threatestergithubiov1alpha1.SuppressionRef{}, nil
+					},
+					UnsuppressFunc: func(ctx context.Context, suppressions []threatestergithubiov1alpha1.SuppressionRef) error {
+						return nil
+					},
+				},
+			}
+
+			_, err = scenarioReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: scenarioName, Namespace: namespace.Name},
+			})
+			Expect(err).To(Not(HaveOccurred()))
+
+			found := &threatestergithubiov1alpha1.Scenario{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: scenarioName, Namespace: namespace.Name}, found)
+			Expect(err).To(Not(HaveOccurred()))
+
+			Expect(found.Status.Status).To(Equal(typeTimedOutScenario))
+			Expect(cleanedUp).To(BeTrue())
+
+			By("Skipping a scenario that timed out")
+			_, err = scenarioReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: scenarioName, Namespace: namespace.Name},
+			})
+			Expect(err).To(Not(HaveOccurred()))
+		})
+	})
+	Context("Scenario with a stuck pod", func() {
+		ctx := context.Background()
+		const scenarioName = "test-scenario-stuck"
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "threatester-stuck-test",
+			},
+		}
+
+		BeforeEach(func() {
+			By("Creating the namespace for tests")
+			err := k8sClient.Create(ctx, namespace)
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		AfterEach(func() {
+			By("Deleting the namespace for tests")
+			_ = k8sClient.Delete(ctx, namespace)
+		})
+
+		It("Should degrade the scenario with the reason the pod is stuck", func() {
+			scenario := &threatestergithubiov1alpha1.Scenario{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      scenarioName,
+					Namespace: namespace.Name,
+				},
+				Spec: threatestergithubiov1alpha1.ScenarioSpec{
+					Templates: []threatestergithubiov1alpha1.Template{
+						{
+							Name:      "attack",
+							Container: &corev1.Container{Name: "attack", Image: "registry.invalid/attack"},
+						},
+					},
+				},
+			}
+			err := k8sClient.Create(ctx, scenario)
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("Reconciling the custom resource created")
+			scenarioReconciler := &ScenarioReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				ExpectationService: &expectation.ExpectationServiceMock{
+					RunExpectationFunc: func(ctx context.Context) (bool, error) {
+						return false, fmt.Errorf("no expectation is expected")
+					},
+					SetExpectationsFunc: func(expectations []threatestergithubiov1alpha1.Expectation) {
+					},
+					SetStartTimeFunc: func(startTime time.Time) {
+					},
+					SetEndTimeFunc: func(endTime time.Time) {
+					},
+				},
+				ScenarioJobExecutor: &scenarioApplication.ScenarioJobExecutorMock{
+					ExecuteFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						return &scenarioApplication.StuckPodError{Pod: "scenario-abcde", Container: "attack", Reason: "ImagePullBackOff", Message: "Back-off pulling image"}
+					},
+					CollectResultsFunc: func(ctx context.Context, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.ContainerResult, error) {
+						return []threatestergithubiov1alpha1.ContainerResult{}, nil
+					},
+					DeleteScenarioJobFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						return nil
+					},
+				},
+				CleanupService: &cleanup.CleanupServiceMock{
+					CleanupFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario) error {
+						return nil
+					},
+				},
+				SuppressionService: &suppression.SuppressionServiceMock{
+					SuppressFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.SuppressionRef, error) {
+						return []threatestergithubiov1alpha1.SuppressionRef{}, nil
+					},
+					UnsuppressFunc: func(ctx context.Context, suppressions []threatestergithubiov1alpha1.SuppressionRef) error {
+						return nil
+					},
+				},
+			}
+
+			_, err = scenarioReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: scenarioName, Namespace: namespace.Name},
+			})
+			Expect(err).To(Not(HaveOccurred()))
+
+			found := &threatestergithubiov1alpha1.Scenario{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: scenarioName, Namespace: namespace.Name}, found)
+			Expect(err).To(Not(HaveOccurred()))
+
+			Expect(found.Status.Status).To(Equal(typeDegradedScenario))
+			condition := found.Status.Conditions[len(found.Status.Conditions)-1]
+			Expect(condition.Reason).To(Equal("ImagePullBackOff"))
+			Expect(condition.Message).To(ContainSubstring("scenario-abcde/attack"))
+		})
+	})
+	Context("Scenario with a conflicting job", func() {
+		ctx := context.Background()
+		const scenarioName = "test-scenario-conflict"
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "threatester-conflict-test",
+			},
+		}
+
+		BeforeEach(func() {
+			By("Creating the namespace for tests")
+			err := k8sClient.Create(ctx, namespace)
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		AfterEach(func() {
+			By("Deleting the namespace for tests")
+			_ = k8sClient.Delete(ctx, namespace)
+		})
+
+		It("Should fail the scenario when its job belongs to another scenario", func() {
+			By("Creating the job of another scenario with the same name")
+			job := &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      scenarioApplication.ScenarioJobName(scenarioName),
+					Namespace: namespace.Name,
+					Labels:    map[string]string{scenarioApplication.ScenarioLabel: "other"},
+				},
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							RestartPolicy: corev1.RestartPolicyNever,
+							Containers:    []corev1.Container{{Name: "attack", Image: "alpine"}},
+						},
+					},
+				},
+			}
+			err := k8sClient.Create(ctx, job)
+			Expect(err).To(Not(HaveOccurred()))
+
+			scenario := &threatestergithubiov1alpha1.Scenario{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      scenarioName,
+					Namespace: namespace.Name,
+				},
+				Spec: threatestergithubiov1alpha1.ScenarioSpec{
+					Templates: []threatestergithubiov1alpha1.Template{
+						{
+							Name:      "attack",
+							Container: &corev1.Container{Name: "attack", Image: "alpine"},
+						},
+					},
+				},
+			}
+			err = k8sClient.Create(ctx, scenario)
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("Reconciling the custom resource created")
+			scenarioJobExecutor := &scenarioApplication.ScenarioJobExecutorMock{
+				ExecuteFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+					return nil
+				},
+				CollectResultsFunc: func(ctx context.Context, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.ContainerResult, error) {
+					return []threatestergithubiov1alpha1.ContainerResult{}, nil
+				},
+				DeleteScenarioJobFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+					return nil
+				},
+			}
+			scenarioReconciler := &ScenarioReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				ExpectationService: &expectation.ExpectationServiceMock{
+					RunExpectationFunc: func(ctx context.Context) (bool, error) {
+						return false, fmt.Errorf("no expectation is expected")
+					},
+					SetExpectationsFunc: func(expectations []threatestergithubiov1alpha1.Expectation) {
+					},
+					SetStartTimeFunc: func(startTime time.Time) {
+					},
+					SetEndTimeFunc: func(endTime time.Time) {
+					},
+				},
+				ScenarioJobExecutor: scenarioJobExecutor,
+				CleanupService: &cleanup.CleanupServiceMock{
+					CleanupFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario) error {
+						return nil
+					},
+				},
+				SuppressionService: &suppression.SuppressionServiceMock{
+					SuppressFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.SuppressionRef, error) {
+						return []threatestergithubiov1alpha1.SuppressionRef{}, nil
+					},
+					UnsuppressFunc: func(ctx context.Context, suppressions []threatestergithubiov1alpha1.SuppressionRef) error {
+						return nil
+					},
+				},
+			}
+
+			_, err = scenarioReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: scenarioName, Namespace: namespace.Name},
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(scenarioJobExecutor.ExecuteCalls()).To(BeEmpty())
+
+			found := &threatestergithubiov1alpha1.Scenario{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: scenarioName, Namespace: namespace.Name}, found)
+			Expect(err).To(Not(HaveOccurred()))
+
+			Expect(found.Status.Status).To(Equal(typeFailedScenario))
+			condition := found.Status.Conditions[len(found.Status.Conditions)-1]
+			Expect(condition.Reason).To(Equal("Conflict"))
+			Expect(condition.Message).To(ContainSubstring("does not belong to scenario " + scenarioName))
+		})
+	})
+	Context("Scenario with a template reference", func() {
+		ctx := context.Background()
+		const scenarioName = "test-scenario-template-ref"
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "threatester-template-ref-test",
+			},
+		}
+
+		BeforeEach(func() {
+			By("Creating the namespace for tests")
+			err := k8sClient.Create(ctx, namespace)
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		AfterEach(func() {
+			By("Deleting the namespace for tests")
+			_ = k8sClient.Delete(ctx, namespace)
+		})
+
+		It("Should run the referenced template and run again when it is updated", func() {
+			scenarioTemplate := &threatestergithubiov1alpha1.ScenarioTemplate{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "read-file",
+					Namespace: namespace.Name,
+				},
+				Spec: threatestergithubiov1alpha1.ScenarioTemplateSpec{
+					Parameters: []threatestergithubiov1alpha1.Parameter{{Name: "path"}},
+					Container:  corev1.Container{Name: "read-file", Image: "alpine", Command: []string{"cat", "{{ .Params.path }}"}},
+				},
+			}
+			err := k8sClient.Create(ctx, scenarioTemplate)
+			Expect(err).To(Not(HaveOccurred()))
+
+			scenario := &threatestergithubiov1alpha1.Scenario{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      scenarioName,
+					Namespace: namespace.Name,
+				},
+				Spec: threatestergithubiov1alpha1.ScenarioSpec{
+					Templates: []threatestergithubiov1alpha1.Template{
+						{
+							Name: "read-shadow",
+							TemplateRef: &threatestergithubiov1alpha1.TemplateRef{
+								Name:       scenarioTemplate.Name,
+								Parameters: map[string]string{"path": "/etc/shadow"},
+							},
+						},
+					},
+					Expectations: []threatestergithubiov1alpha1.Expectation{
+						{
+							Datadog: &threatestergithubiov1alpha1.DatadogExpectation{
+								Monitor: &threatestergithubiov1alpha1.DatadogMonitor{ID: "1", Status: "Alert"},
+							},
+						},
+					},
+				},
+			}
+			err = k8sClient.Create(ctx, scenario)
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("Reconciling the custom resource created")
+			commands := [][]string{}
+			scenarioReconciler := &ScenarioReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				ExpectationService: &expectation.ExpectationServiceMock{
+					RunExpectationFunc: func(ctx context.Context) (bool, error) {
+						return true, nil
+					},
+					SetExpectationsFunc: func(expectations []threatestergithubiov1alpha1.Expectation) {
+					},
+					SetStartTimeFunc: func(startTime time.Time) {
+					},
+					SetEndTimeFunc: func(endTime time.Time) {
+					},
+				},
+				ScenarioJobExecutor: &scenarioApplication.ScenarioJobExecutorMock{
+					ExecuteFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						commands = append(commands, scenarioJob.Spec.Template.Spec.Containers[0].Command)
+						return nil
+					},
+					CollectResultsFunc: func(ctx context.Context, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.ContainerResult, error) {
+						return []threatestergithubiov1alpha1.ContainerResult{}, nil
+					},
+					DeleteScenarioJobFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						return nil
+					},
+				},
+				CleanupService: &cleanup.CleanupServiceMock{
+					CleanupFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario) error {
+						return nil
+					},
+				},
+				SuppressionService: &suppression.SuppressionServiceMock{
+					SuppressFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.SuppressionRef, error) {
+						return []threatestergithubiov1alpha1.SuppressionRef{}, nil
+					},
+					UnsuppressFunc: func(ctx context.Context, suppressions []threatestergithubiov1alpha1.SuppressionRef) error {
+						return nil
+					},
+				},
+			}
+
+			reconcileScenario := func() {
+				_, err := scenarioReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: scenarioName, Namespace: namespace.Name},
+				})
+				Expect(err).To(Not(HaveOccurred()))
+			}
+
+			reconcileScenario()
+
+			found := &threatestergithubiov1alpha1.Scenario{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: scenarioName, Namespace: namespace.Name}, found)
+			Expect(err).To(Not(HaveOccurred()))
+
+			Expect(found.Status.Status).To(Equal(typeSucceededScenario))
+			Expect(commands).To(Equal([][]string{{"cat", "/etc/shadow"}}))
+			Expect(found.Status.TemplateRevisions).To(HaveLen(1))
+			Expect(found.Status.TemplateRevisions[0].Name).To(Equal(scenarioTemplate.Name))
+
+			By("Skipping the succeeded scenario while the template is unchanged")
+			reconcileScenario()
+			Expect(commands).To(HaveLen(1))
+
+			By("Running the scenario again once the template is updated")
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: scenarioTemplate.Name, Namespace: namespace.Name}, scenarioTemplate)
+			Expect(err).To(Not(HaveOccurred()))
+			scenarioTemplate.Spec.Container.Command = []string{"head", "{{ .Params.path }}"}
+			err = k8sClient.Update(ctx, scenarioTemplate)
+			Expect(err).To(Not(HaveOccurred()))
+
+			reconcileScenario()
+			Expect(commands).To(Equal([][]string{{"cat", "/etc/shadow"}, {"head", "/etc/shadow"}}))
+
+			By("Running the scenario again once a rerun is requested")
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: scenarioName, Namespace: namespace.Name}, found)
+			Expect(err).To(Not(HaveOccurred()))
+			found.Annotations = map[string]string{scenarioApplication.RerunAnnotation: "1"}
+			err = k8sClient.Update(ctx, found)
+			Expect(err).To(Not(HaveOccurred()))
+
+			reconcileScenario()
+			Expect(commands).To(HaveLen(3))
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: scenarioName, Namespace: namespace.Name}, found)
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(found.Status.Status).To(Equal(typeSucceededScenario))
+			Expect(found.Status.RerunRequest).To(Equal("1"))
+
+			reconcileScenario()
+			Expect(commands).To(HaveLen(3))
+		})
+	})
+
+	Context("Scenario with a Stratus Red Team technique", func() {
+		ctx := context.Background()
+		const scenarioName = "test-scenario-stratus"
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "threatester-stratus-test",
+			},
+		}
+
+		BeforeEach(func() {
+			By("Creating the namespace for tests")
+			err := k8sClient.Create(ctx, namespace)
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		AfterEach(func() {
+			By("Deleting the namespace for tests")
+			_ = k8sClient.Delete(ctx, namespace)
+		})
+
+		It("Should revert the technique even when the expectations fail", func() {
+			scenario := &threatestergithubiov1alpha1.Scenario{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      scenarioName,
+					Namespace: namespace.Name,
+				},
+				Spec: threatestergithubiov1alpha1.ScenarioSpec{
+					Templates: []threatestergithubiov1alpha1.Template{
+						{Name: "steal-token", Stratus: &threatestergithubiov1alpha1.StratusTemplate{Technique: "k8s.credential-access.steal-serviceaccount-token"}},
+					},
+					Expectations: []threatestergithubiov1alpha1.Expectation{
+						{
+							Datadog: &threatestergithubiov1alpha1.DatadogExpectation{
+								Monitor: &threatestergithubiov1alpha1.DatadogMonitor{ID: "1", Status: "Alert"},
+							},
+						},
+					},
+				},
+			}
+			err := k8sClient.Create(ctx, scenario)
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("Reconciling the custom resource created")
+			executed := []string{}
+			scenarioReconciler := &ScenarioReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				ExpectationService: &expectation.ExpectationServiceMock{
+					RunExpectationFunc: func(ctx context.Context) (bool, error) {
+						return false, fmt.Errorf("monitor 1 did not transition to Alert")
+					},
+					SetExpectationsFunc: func(e []threatestergithubiov1alpha1.Expectation) {
+					},
+					SetStartTimeFunc: func(startTime time.Time) {
+					},
+					SetEndTimeFunc: func(endTime time.Time) {
+					},
+				},
+				ScenarioJobExecutor: &scenarioApplication.ScenarioJobExecutorMock{
+					ExecuteFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						executed = append(executed, scenarioJob.Name)
+						return nil
+					},
+					CollectResultsFunc: func(ctx context.Context, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.ContainerResult, error) {
+						return []threatestergithubiov1alpha1.ContainerResult{}, nil
+					},
+					DeleteScenarioJobFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						return nil
+					},
+				},
+				CleanupService: &cleanup.CleanupServiceMock{
+					CleanupFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario) error {
+						return nil
+					},
+				},
+				SuppressionService: &suppression.SuppressionServiceMock{
+					SuppressFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.SuppressionRef, error) {
+						return []threatestergithubiov1alpha1.SuppressionRef{}, nil
+					},
+					UnsuppressFunc: func(ctx context.Context, suppressions []threatestergithubiov1alpha1.SuppressionRef) error {
+						return nil
+					},
+				},
+			}
+
+			_, err = scenarioReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: scenarioName, Namespace: namespace.Name},
+			})
+			Expect(err).To(HaveOccurred())
+
+			Expect(executed).To(Equal([]string{scenarioApplication.ScenarioJobName(scenarioName), scenarioApplication.StratusCleanupJobName(scenarioName)}))
+
+			claim := &corev1.PersistentVolumeClaim{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: scenarioApplication.StratusStateClaimName(scenarioName), Namespace: namespace.Name}, claim)
+			Expect(err).To(Not(HaveOccurred()))
+
+			found := &threatestergithubiov1alpha1.Scenario{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: scenarioName, Namespace: namespace.Name}, found)
+			Expect(err).To(Not(HaveOccurred()))
+
+			Expect(found.Status.Status).To(Equal(typeFailedScenario))
+			Expect(found.Status.CleanupTime).To(Not(BeNil()))
+		})
+	})
+	Context("Scenario with a dry run", func() {
+		ctx := context.Background()
+		const scenarioName = "test-scenario-dry-run"
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "threatester-dry-run-test",
+			},
+		}
+
+		BeforeEach(func() {
+			By("Creating the namespace for tests")
+			err := k8sClient.Create(ctx, namespace)
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		AfterEach(func() {
+			By("Deleting the namespace for tests")
+			_ = k8sClient.Delete(ctx, namespace)
+		})
+
+		It("Should render the plan and validate the expectations without running anything", func() {
+			scenario := &threatestergithubiov1alpha1.Scenario{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      scenarioName,
+					Namespace: namespace.Name,
+				},
+				Spec: threatestergithubiov1alpha1.ScenarioSpec{
+					DryRun: true,
+					Parameters: []threatestergithubiov1alpha1.Parameter{
+						{Name: "monitor", Type: threatestergithubiov1alpha1.ParameterTypeString},
+					},
+					Arguments: map[string]string{"monitor": "123456"},
+					Templates: []threatestergithubiov1alpha1.Template{
+						{Name: "test", Container: &corev1.Container{Name: "test", Image: "alpine", Command: []string{"echo", "hello"}}},
+					},
+					PodTemplate: &threatestergithubiov1alpha1.PodTemplate{ServiceAccountName: "attacker"},
+					Expectations: []threatestergithubiov1alpha1.Expectation{
+						{Datadog: &threatestergithubiov1alpha1.DatadogExpectation{Monitor: &threatestergithubiov1alpha1.DatadogMonitor{ID: "{{ .Params.monitor }}", Status: "Alert"}}},
+					},
+				},
+			}
+			err := k8sClient.Create(ctx, scenario)
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("Reconciling the custom resource created")
+			validated := []string{}
+			monitorExists := true
+			scenarioReconciler := &ScenarioReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				ExpectationService: &expectation.ExpectationServiceMock{
+					RunExpectationFunc: func(ctx context.Context) (bool, error) {
+						return false, fmt.Errorf("no expectation is expected")
+					},
+					ValidateExpectationFunc: func(ctx context.Context, expectation threatestergithubiov1alpha1.Expectation) error {
+						validated = append(validated, expectation.Datadog.Monitor.ID)
+						if !monitorExists {
+							return fmt.Errorf("monitor %s is not found", expectation.Datadog.Monitor.ID)
+						}
+						return nil
+					},
+					SetExpectationsFunc: func(expectations []threatestergithubiov1alpha1.Expectation) {
+					},
+					SetStartTimeFunc: func(startTime time.Time) {
+					},
+					SetEndTimeFunc: func(endTime time.Time) {
+					},
+				},
+				ScenarioJobExecutor: &scenarioApplication.ScenarioJobExecutorMock{
+					ExecuteFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						return fmt.Errorf("no scenario job is expected")
+					},
+				},
+				CleanupService: &cleanup.CleanupServiceMock{
+					CleanupFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario) error {
+						return fmt.Errorf("no cleanup is expected")
+					},
+				},
+			}
+
+			_, err = scenarioReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: scenarioName, Namespace: namespace.Name},
+			})
+			Expect(err).To(Not(HaveOccurred()))
+
+			found := &threatestergithubiov1alpha1.Scenario{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: scenarioName, Namespace: namespace.Name}, found)
+			Expect(err).To(Not(HaveOccurred()))
+
+			Expect(found.Status.Status).To(Equal(typePlannedScenario))
+			Expect(found.Status.StartTime).To(BeNil())
+			Expect(found.Status.Plan).NotTo(BeNil())
+			Expect(found.Status.Plan.Generation).To(Equal(found.Generation))
+			Expect(found.Status.Plan.Parameters).To(Equal(map[string]string{"monitor": "123456"}))
+			Expect(found.Status.Plan.Resources).To(HaveLen(1))
+			Expect(found.Status.Plan.Resources[0].Kind).To(Equal("Job"))
+			Expect(found.Status.Plan.Resources[0].ServiceAccountName).To(Equal("attacker"))
+			Expect(found.Status.Plan.Resources[0].Manifest).To(ContainSubstring("image: alpine"))
+			Expect(found.Status.Plan.Expectations).To(HaveLen(1))
+			Expect(found.Status.Plan.Expectations[0].Valid).To(BeTrue())
+			Expect(validated).To(Equal([]string{"123456"}))
+
+			jobs := &batchv1.JobList{}
+			err = k8sClient.List(ctx, jobs, client.InNamespace(namespace.Name))
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(jobs.Items).To(BeEmpty())
+
+			By("Skipping a scenario that is already planned")
+			_, err = scenarioReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: scenarioName, Namespace: namespace.Name},
+			})
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(validated).To(HaveLen(1))
+
+			By("Failing the plan of an updated scenario whose monitor does not exist")
+			monitorExists = false
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: scenarioName, Namespace: namespace.Name}, found)
+			Expect(err).To(Not(HaveOccurred()))
+			found.Spec.Arguments["monitor"] = "654321"
+			err = k8sClient.Update(ctx, found)
+			Expect(err).To(Not(HaveOccurred()))
+
+			_, err = scenarioReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: scenarioName, Namespace: namespace.Name},
+			})
+			Expect(err).To(Not(HaveOccurred()))
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: scenarioName, Namespace: namespace.Name}, found)
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(found.Status.Status).To(Equal(typeFailedScenario))
+			Expect(found.Status.Plan.Expectations[0].Valid).To(BeFalse())
+			Expect(meta.FindStatusCondition(found.Status.Conditions, typeFailedScenario).Message).To(ContainSubstring("monitor 654321 is not found"))
+		})
+	})
+	Context("Scenario whose cleanup keeps failing", func() {
+		ctx := context.Background()
+		const scenarioName = "test-scenario-finalizer"
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "threatester-finalizer-test",
+			},
+		}
+
+		BeforeEach(func() {
+			By("Creating the namespace for tests")
+			err := k8sClient.Create(ctx, namespace)
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		AfterEach(func() {
+			By("Deleting the namespace for tests")
+			_ = k8sClient.Delete(ctx, namespace)
+		})
+
+		It("Should delete the scenario once the attempts of the finalizer are exhausted", func() {
+			scenario := &threatestergithubiov1alpha1.Scenario{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       scenarioName,
+					Namespace:  namespace.Name,
+					Finalizers: []string{scenarioFinalizer},
+				},
+				Spec: threatestergithubiov1alpha1.ScenarioSpec{
+					Templates: []threatestergithubiov1alpha1.Template{
+						{Name: "attack", Container: &corev1.Container{Name: "attack", Image: "alpine"}},
+					},
+					Cleanup: &threatestergithubiov1alpha1.Cleanup{
+						Datadog: &threatestergithubiov1alpha1.DatadogCleanup{ResolveMonitors: true},
+					},
+				},
+			}
+			err := k8sClient.Create(ctx, scenario)
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("Interrupting the run before its cleanup phase")
+			startTime := metav1.Now()
+			scenario.Status.StartTime = &startTime
+			scenario.Status.Conditions = []metav1.Condition{{Type: typeProgressingScenario, Status: metav1.ConditionUnknown, Reason: "Reconciling", Message: "Starting Reconciling", LastTransitionTime: startTime}}
+			err = k8sClient.Status().Update(ctx, scenario)
+			Expect(err).To(Not(HaveOccurred()))
+
+			err = k8sClient.Delete(ctx, scenario)
+			Expect(err).To(Not(HaveOccurred()))
+
+			cleanupService := &cleanup.CleanupServiceMock{
+				CleanupFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario) error {
+					return fmt.Errorf("403 Forbidden")
+				},
+			}
+			scenarioReconciler := &ScenarioReconciler{
+				Client:         k8sClient,
+				Scheme:         k8sClient.Scheme(),
+				CleanupService: cleanupService,
+				SuppressionService: &suppression.SuppressionServiceMock{
+					UnsuppressFunc: func(ctx context.Context, suppressions []threatestergithubiov1alpha1.SuppressionRef) error {
+						return nil
+					},
+				},
+			}
+
+			By("Retrying the cleanup while attempts remain")
+			for attempt := 1; attempt < maxFinalizerAttempts; attempt++ {
+				result, err := scenarioReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: scenarioName, Namespace: namespace.Name},
+				})
+				Expect(err).To(Not(HaveOccurred()))
+				Expect(result.RequeueAfter).To(Equal(finalizerRetryInterval))
+			}
+
+			found := &threatestergithubiov1alpha1.Scenario{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: scenarioName, Namespace: namespace.Name}, found)
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(found.Status.FinalizerAttempts).To(Equal(int32(maxFinalizerAttempts - 1)))
+			condition := meta.FindStatusCondition(found.Status.Conditions, typeAvailableScenario)
+			Expect(condition).To(Not(BeNil()))
+			Expect(condition.Reason).To(Equal("FinalizerFailed"))
+			Expect(condition.Message).To(ContainSubstring("403 Forbidden"))
+
+			By("Removing the finalizer once the attempts are exhausted")
+			_, err = scenarioReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: scenarioName, Namespace: namespace.Name},
+			})
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(cleanupService.CleanupCalls()).To(HaveLen(maxFinalizerAttempts))
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: scenarioName, Namespace: namespace.Name}, found)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+	Context("Scenario with a suppression", func() {
+		ctx := context.Background()
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "threatester-suppression-test",
+			},
+		}
+
+		BeforeEach(func() {
+			By("Creating the namespace for tests")
+			err := k8sClient.Create(ctx, namespace)
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		AfterEach(func() {
+			By("Deleting the namespace for tests")
+			_ = k8sClient.Delete(ctx, namespace)
+		})
+
+		It("Should keep the downtime after the run unless the cleanup resolves the monitors", func() {
+			unsuppressed := []threatestergithubiov1alpha1.SuppressionRef{}
+			scenarioReconciler := &ScenarioReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				ExpectationService: &expectation.ExpectationServiceMock{
+					RunExpectationFunc: func(ctx context.Context) (bool, error) {
+						return true, nil
+					},
+					SetExpectationsFunc: func(expectations []threatestergithubiov1alpha1.Expectation) {
+					},
+					SetStartTimeFunc: func(startTime time.Time) {
+					},
+					SetEndTimeFunc: func(endTime time.Time) {
+					},
+				},
+				ScenarioJobExecutor: &scenarioApplication.ScenarioJobExecutorMock{
+					ExecuteFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						return nil
+					},
+					CollectResultsFunc: func(ctx context.Context, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.ContainerResult, error) {
+						return []threatestergithubiov1alpha1.ContainerResult{}, nil
+					},
+					DeleteScenarioJobFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						return nil
+					},
+				},
+				CleanupService: &cleanup.CleanupServiceMock{
+					CleanupFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario) error {
+						return nil
+					},
+				},
+				SuppressionService: &suppression.SuppressionServiceMock{
+					SuppressFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.SuppressionRef, error) {
+						return []threatestergithubiov1alpha1.SuppressionRef{{Provider: suppression.DatadogProvider, ID: scenario.Name}}, nil
+					},
+					UnsuppressFunc: func(ctx context.Context, suppressions []threatestergithubiov1alpha1.SuppressionRef) error {
+						unsuppressed = append(unsuppressed, suppressions...)
+						return nil
+					},
+				},
+			}
+
+			newScenario := func(name string, cleanupSpec *threatestergithubiov1alpha1.Cleanup) *threatestergithubiov1alpha1.Scenario {
+				return &threatestergithubiov1alpha1.Scenario{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace.Name},
+					Spec: threatestergithubiov1alpha1.ScenarioSpec{
+						Templates: []threatestergithubiov1alpha1.Template{
+							{Name: "attack", Container: &corev1.Container{Name: "attack", Image: "alpine"}},
+						},
+						Expectations: []threatestergithubiov1alpha1.Expectation{
+							{
+								Datadog: &threatestergithubiov1alpha1.DatadogExpectation{
+									Monitor: &threatestergithubiov1alpha1.DatadogMonitor{ID: "1", Status: "Alert"},
+								},
+							},
+						},
+						Suppression: &threatestergithubiov1alpha1.Suppression{Datadog: &threatestergithubiov1alpha1.DatadogSuppression{}},
+						Cleanup:     cleanupSpec,
+					},
+				}
+			}
+
+			By("Keeping the downtime of a run whose monitors are not resolved")
+			err := k8sClient.Create(ctx, newScenario("test-scenario-suppression", nil))
+			Expect(err).To(Not(HaveOccurred()))
+
+			_, err = scenarioReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: "test-scenario-suppression", Namespace: namespace.Name},
+			})
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(unsuppressed).To(BeEmpty())
+
+			found := &threatestergithubiov1alpha1.Scenario{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: "test-scenario-suppression", Namespace: namespace.Name}, found)
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(found.Status.Status).To(Equal(typeSucceededScenario))
+			Expect(found.Status.Suppressions).To(HaveLen(1))
+
+			By("Removing the downtime once the cleanup resolved the monitors")
+			err = k8sClient.Create(ctx, newScenario("test-scenario-suppression-resolved", &threatestergithubiov1alpha1.Cleanup{
+				Datadog: &threatestergithubiov1alpha1.DatadogCleanup{ResolveMonitors: true},
+			}))
+			Expect(err).To(Not(HaveOccurred()))
+
+			_, err = scenarioReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: "test-scenario-suppression-resolved", Namespace: namespace.Name},
+			})
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(unsuppressed).To(Equal([]threatestergithubiov1alpha1.SuppressionRef{{Provider: suppression.DatadogProvider, ID: "test-scenario-suppression-resolved"}}))
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: "test-scenario-suppression-resolved", Namespace: namespace.Name}, found)
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(found.Status.Suppressions).To(BeEmpty())
+		})
+	})
+	Context("Scenario deleted while running", func() {
+		ctx := context.Background()
+		const scenarioName = "test-scenario-deleted"
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "threatester-deleted-test",
+			},
+		}
+
+		BeforeEach(func() {
+			By("Creating the namespace for tests")
+			err := k8sClient.Create(ctx, namespace)
+			Expect(err).To(Not(HaveOccurred()))
+		})
+
+		AfterEach(func() {
+			By("Deleting the namespace for tests")
+			_ = k8sClient.Delete(ctx, namespace)
+		})
+
+		It("Should end the run and finalize the scenario", func() {
+			scenario := &threatestergithubiov1alpha1.Scenario{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      scenarioName,
+					Namespace: namespace.Name,
+				},
+				Spec: threatestergithubiov1alpha1.ScenarioSpec{
+					Templates: []threatestergithubiov1alpha1.Template{
+						{
+							Name:      "hang",
+							Container: &corev1.Container{Name: "hang", Image: "alpine", Command: []string{"sleep", "infinity"}},
+							Workload: &threatestergithubiov1alpha1.Workload{
+								Selector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+							},
+						},
+					},
+					Cleanup: &threatestergithubiov1alpha1.Cleanup{
+						Datadog: &threatestergithubiov1alpha1.DatadogCleanup{ResolveMonitors: true},
+					},
+				},
+			}
+			err := k8sClient.Create(ctx, scenario)
+			Expect(err).To(Not(HaveOccurred()))
+
+			By("Deleting the scenario once its run has started")
+			started := make(chan struct{})
+			cleanups := 0
+			scenarioReconciler := &ScenarioReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				ExpectationService: &expectation.ExpectationServiceMock{
+					RunExpectationFunc: func(ctx context.Context) (bool, error) {
+						return false, fmt.Errorf("no expectation is expected")
+					},
+					SetExpectationsFunc: func(expectations []threatestergithubiov1alpha1.Expectation) {
+					},
+					SetStartTimeFunc: func(startTime time.Time) {
+					},
+					SetEndTimeFunc: func(endTime time.Time) {
+					},
+				},
+				ScenarioJobExecutor: &scenarioApplication.ScenarioJobExecutorMock{
+					ExecuteFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						return fmt.Errorf("no scenario job is expected")
+					},
+					CollectResultsFunc: func(ctx context.Context, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.ContainerResult, error) {
+						return []threatestergithubiov1alpha1.ContainerResult{}, nil
+					},
+					DeleteScenarioJobFunc: func(ctx context.Context, scenarioJob batchv1.Job) error {
+						return nil
+					},
+				},
+				WorkloadExecutor: &scenarioApplication.WorkloadExecutorMock{
+					ExecuteFunc: func(ctx context.Context, namespace string, template threatestergithubiov1alpha1.Template) (threatestergithubiov1alpha1.ExecutionStatus, error) {
+						close(started)
+						<-ctx.Done()
+						return threatestergithubiov1alpha1.ExecutionStatus{Template: template.Name}, ctx.Err()
+					},
+				},
+				CleanupService: &cleanup.CleanupServiceMock{
+					CleanupFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario) error {
+						cleanups++
+						return nil
+					},
+				},
+				SuppressionService: &suppression.SuppressionServiceMock{
+					SuppressFunc: func(ctx context.Context, scenario threatestergithubiov1alpha1.Scenario, scenarioJob batchv1.Job) ([]threatestergithubiov1alpha1.SuppressionRef, error) {
+						return []threatestergithubiov1alpha1.SuppressionRef{}, nil
+					},
+					UnsuppressFunc: func(ctx context.Context, suppressions []threatestergithubiov1alpha1.SuppressionRef) error {
+						return nil
+					},
+				},
+			}
+
+			go func() {
+				defer GinkgoRecover()
+
+				<-started
+				Expect(k8sClient.Delete(ctx, scenario)).To(Succeed())
+			}()
+
+			done := make(chan error)
+			go func() {
+				_, err := scenarioReconciler.Reconcile(ctx, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: scenarioName, Namespace: namespace.Name},
+				})
+				done <- err
+			}()
+			Eventually(done, 3*cancelPollInterval).Should(Receive(BeNil()))
+			Expect(cleanups).To(Equal(1))
+
+			found := &threatestergithubiov1alpha1.Scenario{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: scenarioName, Namespace: namespace.Name}, found)
+			Expect(err).To(Not(HaveOccurred()))
+			Expect(found.Status.Status).To(Equal(typeCancelledScenario))
+
+			By("Finalizing the scenario")
+			_, err = scenarioReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: scenarioName, Namespace: namespace.Name},
+			})
+			Expect(err).To(Not(HaveOccurred()))
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: scenarioName, Namespace: namespace.Name}, found)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
 		})
 	})
 })
@@ -0,0 +1,131 @@
+// Package dag provides a minimal directed acyclic graph used to schedule scenario steps.
+package dag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Graph is a directed acyclic graph of named nodes, each depending on zero or more other nodes.
+type Graph struct {
+	nodes        []string
+	dependencies map[string][]string
+}
+
+// CycleError is returned when the dependencies of the nodes form a cycle.
+type CycleError struct {
+	Path []string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("dependency cycle detected: %s", strings.Join(e.Path, " -> "))
+}
+
+// New builds a graph from the nodes and their dependencies.
+// It fails when a node is declared twice, depends on an unknown node or when the dependencies form a cycle.
+func New(nodes []string, dependencies map[string][]string) (*Graph, error) {
+	known := map[string]struct{}{}
+	for _, node := range nodes {
+		if _, ok := known[node]; ok {
+			return nil, fmt.Errorf("duplicate node %q", node)
+		}
+		known[node] = struct{}{}
+	}
+
+	for _, node := range nodes {
+		for _, dependency := range dependencies[node] {
+			if _, ok := known[dependency]; !ok {
+				return nil, fmt.Errorf("%q depends on unknown node %q", node, dependency)
+			}
+		}
+	}
+
+	if cycle := FindCycle(nodes, dependencies); cycle != nil {
+		return nil, &CycleError{Path: cycle}
+	}
+
+	return &Graph{nodes: nodes, dependencies: dependencies}, nil
+}
+
+// Nodes returns the nodes of the graph in declaration order.
+func (g *Graph) Nodes() []string {
+	return g.nodes
+}
+
+// Dependencies returns the nodes that the given node depends on.
+func (g *Graph) Dependencies(node string) []string {
+	return g.dependencies[node]
+}
+
+// DependsOn reports whether the node depends on the other node, directly or through other nodes.
+func (g *Graph) DependsOn(node string, other string) bool {
+	visited := map[string]struct{}{}
+	queue := append([]string{}, g.dependencies[node]...)
+
+	for len(queue) > 0 {
+		dependency := queue[0]
+		queue = queue[1:]
+
+		if dependency == other {
+			return true
+		}
+
+		if _, ok := visited[dependency]; ok {
+			continue
+		}
+		visited[dependency] = struct{}{}
+
+		queue = append(queue, g.dependencies[dependency]...)
+	}
+
+	return false
+}
+
+// FindCycle returns the path of the first dependency cycle found, e.g. [a b a], or nil when there is none.
+// Dependencies on unknown nodes are ignored.
+func FindCycle(nodes []string, dependencies map[string][]string) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := map[string]int{}
+	stack := []string{}
+
+	var visit func(node string) []string
+	visit = func(node string) []string {
+		state[node] = visiting
+		stack = append(stack, node)
+
+		for _, dependency := range dependencies[node] {
+			switch state[dependency] {
+			case visiting:
+				for i := range stack {
+					if stack[i] == dependency {
+						cycle := append([]string{}, stack[i:]...)
+						return append(cycle, dependency)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dependency); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[node] = visited
+		return nil
+	}
+
+	for _, node := range nodes {
+		if state[node] == unvisited {
+			if cycle := visit(node); cycle != nil {
+				return cycle
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package dag
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name         string
+		nodes        []string
+		dependencies map[string][]string
+		cycle        []string
+		wantErr      bool
+	}{
+		{
+			name:  "fan-out and fan-in",
+			nodes: []string{"spray-a", "spray-b", "spray-c", "exfiltrate"},
+			dependencies: map[string][]string{
+				"exfiltrate": {"spray-a", "spray-b", "spray-c"},
+			},
+		},
+		{
+			name:  "cycle",
+			nodes: []string{"a", "b", "c"},
+			dependencies: map[string][]string{
+				"a": {"c"},
+				"b": {"a"},
+				"c": {"b"},
+			},
+			cycle:   []string{"a", "c", "b", "a"},
+			wantErr: true,
+		},
+		{
+			name:         "self dependency",
+			nodes:        []string{"a"},
+			dependencies: map[string][]string{"a": {"a"}},
+			cycle:        []string{"a", "a"},
+			wantErr:      true,
+		},
+		{
+			name:         "unknown dependency",
+			nodes:        []string{"a"},
+			dependencies: map[string][]string{"a": {"b"}},
+			wantErr:      true,
+		},
+		{
+			name:    "duplicate node",
+			nodes:   []string{"a", "a"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.nodes, tt.dependencies)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %t, got %v", tt.wantErr, err)
+			}
+
+			var cycleErr *CycleError
+			if tt.cycle != nil {
+				if !errors.As(err, &cycleErr) {
+					t.Fatalf("expected a cycle error, got %v", err)
+				}
+
+				if !reflect.DeepEqual(cycleErr.Path, tt.cycle) {
+					t.Errorf("expected cycle %v, got %v", tt.cycle, cycleErr.Path)
+				}
+			}
+		})
+	}
+}
+
+func TestDependsOn(t *testing.T) {
+	g, err := New([]string{"recon", "spray-a", "spray-b", "exfiltrate"}, map[string][]string{
+		"spray-a":    {"recon"},
+		"spray-b":    {"recon"},
+		"exfiltrate": {"spray-a", "spray-b"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		node      string
+		other     string
+		dependsOn bool
+	}{
+		{node: "spray-a", other: "recon", dependsOn: true},
+		{node: "exfiltrate", other: "recon", dependsOn: true},
+		{node: "recon", other: "exfiltrate", dependsOn: false},
+		{node: "spray-a", other: "spray-b", dependsOn: false},
+		{node: "recon", other: "recon", dependsOn: false},
+	}
+
+	for _, tt := range tests {
+		if got := g.DependsOn(tt.node, tt.other); got != tt.dependsOn {
+			t.Errorf("expected %s depending on %s to be %t, got %t", tt.node, tt.other, tt.dependsOn, got)
+		}
+	}
+}
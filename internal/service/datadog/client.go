@@ -1,14 +1,29 @@
 package datadog
 
+//go:generate moq -out client_mock.go . DatadogClient
+
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	dd "github.com/DataDog/datadog-api-client-go/v2/api/datadog"
 	ddv1 "github.com/DataDog/datadog-api-client-go/v2/api/datadogV1"
+	ddv2 "github.com/DataDog/datadog-api-client-go/v2/api/datadogV2"
 )
 
 type DatadogClient interface {
 	GetMonitor(ctx context.Context, monitorID int64) (*ddv1.Monitor, error)
+	ResolveMonitorGroups(ctx context.Context, monitorID int64, groups []string) error
+	CreateDowntime(ctx context.Context, downtime ddv1.Downtime) (*ddv1.Downtime, error)
+	CancelDowntime(ctx context.Context, downtimeID int64) error
+	SearchSecuritySignals(ctx context.Context, query string, from time.Time, to time.Time) ([]ddv2.SecurityMonitoringSignal, error)
+	ArchiveSecuritySignal(ctx context.Context, signalID string, comment string) error
+	SearchLogs(ctx context.Context, query string, from time.Time, to time.Time, limit int32) ([]ddv2.Log, error)
 }
 
 type datadogClient struct {
@@ -26,10 +41,169 @@ func (d datadogClient) GetMonitor(ctx context.Context, monitorID int64) (*ddv1.M
 	ddCtx := dd.NewDefaultContext(ctx)
 	api := ddv1.NewMonitorsApi(d.client)
 
-	resp, _, err := api.GetMonitor(ddCtx, monitorID, *ddv1.NewGetMonitorOptionalParameters())
+	resp, _, err := api.GetMonitor(ddCtx, monitorID, *ddv1.NewGetMonitorOptionalParameters().WithGroupStates("all"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// ResolveMonitorGroups resolves the given groups of a monitor.
+// The API client does not provide the bulk resolve endpoint, so the request is built by hand.
+func (d datadogClient) ResolveMonitorGroups(ctx context.Context, monitorID int64, groups []string) error {
+	ddCtx := dd.NewDefaultContext(ctx)
+
+	basePath, err := d.client.GetConfig().ServerURLWithContext(ddCtx, "v1.MonitorsApi.GetMonitor")
+	if err != nil {
+		return err
+	}
+
+	resolve := []map[string]string{}
+	for _, group := range groups {
+		resolve = append(resolve, map[string]string{strconv.FormatInt(monitorID, 10): group})
+	}
+
+	headers := map[string]string{
+		"Content-Type": "application/json",
+		"Accept":       "application/json",
+	}
+	dd.SetAuthKeys(
+		ddCtx,
+		&headers,
+		[2]string{"apiKeyAuth", "DD-API-KEY"},
+		[2]string{"appKeyAuth", "DD-APPLICATION-KEY"},
+	)
+
+	req, err := d.client.PrepareRequest(ddCtx, basePath+"/api/v1/monitor/bulk_resolve", http.MethodPost, map[string]interface{}{"resolve": resolve}, headers, url.Values{}, url.Values{}, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := d.client.CallAPI(req)
+	if err != nil {
+		return err
+	}
+
+	body, err := dd.ReadBody(resp)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to resolve monitor %d: %s: %s", monitorID, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
+
+func (d datadogClient) CreateDowntime(ctx context.Context, downtime ddv1.Downtime) (*ddv1.Downtime, error) {
+	ddCtx := dd.NewDefaultContext(ctx)
+	api := ddv1.NewDowntimesApi(d.client)
+
+	resp, _, err := api.CreateDowntime(ddCtx, downtime)
 	if err != nil {
 		return nil, err
 	}
 
 	return &resp, nil
 }
+
+func (d datadogClient) CancelDowntime(ctx context.Context, downtimeID int64) error {
+	ddCtx := dd.NewDefaultContext(ctx)
+	api := ddv1.NewDowntimesApi(d.client)
+
+	resp, err := api.CancelDowntime(ddCtx, downtimeID)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		// the downtime has already been cancelled or has expired
+		return nil
+	}
+
+	return err
+}
+
+func (d datadogClient) SearchSecuritySignals(ctx context.Context, query string, from time.Time, to time.Time) ([]ddv2.SecurityMonitoringSignal, error) {
+	ddCtx := dd.NewDefaultContext(ctx)
+	api := ddv2.NewSecurityMonitoringApi(d.client)
+
+	filter := ddv2.NewSecurityMonitoringSignalListRequestFilter()
+	filter.SetQuery(query)
+	filter.SetFrom(from)
+	filter.SetTo(to)
+
+	body := ddv2.NewSecurityMonitoringSignalListRequest()
+	body.SetFilter(*filter)
+
+	signals := []ddv2.SecurityMonitoringSignal{}
+	items, cancel := api.SearchSecurityMonitoringSignalsWithPagination(ddCtx, *ddv2.NewSearchSecurityMonitoringSignalsOptionalParameters().WithBody(*body))
+	defer cancel()
+
+	for item := range items {
+		if item.Error != nil {
+			return nil, item.Error
+		}
+		signals = append(signals, item.Item)
+	}
+
+	return signals, nil
+}
+
+func (d datadogClient) ArchiveSecuritySignal(ctx context.Context, signalID string, comment string) error {
+	ddCtx := dd.NewDefaultContext(ctx)
+	api := ddv2.NewSecurityMonitoringApi(d.client)
+
+	attributes := ddv2.NewSecurityMonitoringSignalStateUpdateAttributes(ddv2.SECURITYMONITORINGSIGNALSTATE_ARCHIVED)
+	attributes.SetArchiveReason(ddv2.SECURITYMONITORINGSIGNALARCHIVEREASON_TESTING_OR_MAINTENANCE)
+	attributes.SetArchiveComment(comment)
+
+	body := ddv2.NewSecurityMonitoringSignalStateUpdateRequest(*ddv2.NewSecurityMonitoringSignalStateUpdateData(*attributes))
+
+	_, _, err := api.EditSecurityMonitoringSignalState(ddCtx, signalID, *body)
+	return err
+}
+
+// SearchLogs returns up to limit logs matching the query between from and to.
+func (d datadogClient) SearchLogs(ctx context.Context, query string, from time.Time, to time.Time, limit int32) ([]ddv2.Log, error) {
+	ddCtx := dd.NewDefaultContext(ctx)
+	api := ddv2.NewLogsApi(d.client)
+
+	filter := ddv2.NewLogsQueryFilter()
+	filter.SetQuery(query)
+	filter.SetFrom(from.Format(time.RFC3339))
+	filter.SetTo(to.Format(time.RFC3339))
+
+	page := ddv2.NewLogsListRequestPage()
+	page.SetLimit(limit)
+
+	body := ddv2.NewLogsListRequest()
+	body.SetFilter(*filter)
+	body.SetPage(*page)
+
+	resp, _, err := api.ListLogs(ddCtx, *ddv2.NewListLogsOptionalParameters().WithBody(*body))
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.GetData(), nil
+}
+
+// MatchMonitorGroup reports whether the monitor group name contains all tags of the expected group.
+// An empty expected group matches any group.
+func MatchMonitorGroup(name string, expectGroup string) bool {
+	if expectGroup == "" {
+		return true
+	}
+
+	tags := map[string]struct{}{}
+	for _, tag := range strings.Split(name, ",") {
+		tags[strings.TrimSpace(tag)] = struct{}{}
+	}
+
+	for _, tag := range strings.Split(expectGroup, ",") {
+		if _, ok := tags[strings.TrimSpace(tag)]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
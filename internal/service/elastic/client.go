@@ -0,0 +1,97 @@
+package elastic
+
+//go:generate moq -out client_mock.go . ElasticClient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+type ElasticClient interface {
+	// Count returns the number of documents of the index matching the query string, whose timestamp field is between from and to.
+	Count(ctx context.Context, baseURL string, index string, query string, timestampField string, from time.Time, to time.Time) (int64, error)
+}
+
+type elasticClient struct {
+	httpClient *http.Client
+	apiKey     string
+	username   string
+	password   string
+}
+
+// NewElasticClient returns a client authenticating with ELASTICSEARCH_API_KEY,
+// or with ELASTICSEARCH_USERNAME and ELASTICSEARCH_PASSWORD.
+func NewElasticClient() ElasticClient {
+	return elasticClient{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		apiKey:     os.Getenv("ELASTICSEARCH_API_KEY"),
+		username:   os.Getenv("ELASTICSEARCH_USERNAME"),
+		password:   os.Getenv("ELASTICSEARCH_PASSWORD"),
+	}
+}
+
+func (c elasticClient) Count(ctx context.Context, baseURL string, index string, query string, timestampField string, from time.Time, to time.Time) (int64, error) {
+	body := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": []interface{}{
+					map[string]interface{}{"query_string": map[string]interface{}{"query": query}},
+					map[string]interface{}{"range": map[string]interface{}{
+						timestampField: map[string]interface{}{"gte": from.Format(time.RFC3339), "lte": to.Format(time.RFC3339)},
+					}},
+				},
+			},
+		},
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return 0, err
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/_count", strings.TrimSuffix(baseURL, "/"), url.PathEscape(index))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	switch {
+	case c.apiKey != "":
+		req.Header.Set("Authorization", "ApiKey "+c.apiKey)
+	case c.username != "":
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+
+	if resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("failed to search %s: %s: %s", index, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	count := struct {
+		Count int64 `json:"count"`
+	}{}
+	if err := json.Unmarshal(respBody, &count); err != nil {
+		return 0, err
+	}
+
+	return count.Count, nil
+}
@@ -0,0 +1,106 @@
+package loki
+
+//go:generate moq -out client_mock.go . LokiClient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type LokiClient interface {
+	// QueryRange returns up to limit log lines matching the LogQL query between from and to.
+	QueryRange(ctx context.Context, baseURL string, tenantID string, query string, from time.Time, to time.Time, limit int) ([]string, error)
+}
+
+type lokiClient struct {
+	httpClient  *http.Client
+	bearerToken string
+	username    string
+	password    string
+}
+
+// NewLokiClient returns a client authenticating with LOKI_BEARER_TOKEN, or with LOKI_USERNAME and LOKI_PASSWORD.
+func NewLokiClient() LokiClient {
+	return lokiClient{
+		httpClient:  &http.Client{Timeout: 30 * time.Second},
+		bearerToken: os.Getenv("LOKI_BEARER_TOKEN"),
+		username:    os.Getenv("LOKI_USERNAME"),
+		password:    os.Getenv("LOKI_PASSWORD"),
+	}
+}
+
+type queryRangeResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Values [][2]string `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+func (c lokiClient) QueryRange(ctx context.Context, baseURL string, tenantID string, query string, from time.Time, to time.Time, limit int) ([]string, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", strconv.FormatInt(from.UnixNano(), 10))
+	params.Set("end", strconv.FormatInt(to.UnixNano(), 10))
+	params.Set("limit", strconv.Itoa(limit))
+	params.Set("direction", "forward")
+
+	endpoint := fmt.Sprintf("%s/loki/api/v1/query_range?%s", strings.TrimSuffix(baseURL, "/"), params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if tenantID != "" {
+		req.Header.Set("X-Scope-OrgID", tenantID)
+	}
+	switch {
+	case c.bearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	case c.username != "":
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to query Loki: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	result := queryRangeResponse{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+
+	if result.Data.ResultType != "streams" {
+		return nil, fmt.Errorf("query %q is not a log query, got %s result", query, result.Data.ResultType)
+	}
+
+	lines := []string{}
+	for _, stream := range result.Data.Result {
+		for _, value := range stream.Values {
+			lines = append(lines, value[1])
+		}
+	}
+
+	return lines, nil
+}